@@ -0,0 +1,65 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// accountTokenExpiryDelta is how long before its expiry a Server-to-Server OAuth token is
+// replaced, so requests in flight don't use an expired token.
+const accountTokenExpiryDelta = time.Minute
+
+// accountTokenCache holds the token of the Server-to-Server OAuth app. Zoom doesn't revoke
+// earlier tokens when a new one is minted, so each server of a cluster keeps its own.
+type accountTokenCache struct {
+	lock sync.Mutex
+
+	// zoomURL and credentials are the settings the token was minted with, so it is replaced
+	// when the admin changes them.
+	zoomURL     string
+	credentials zoom.AccountCredentials
+	token       *oauth2.Token
+}
+
+// accountCredentials returns the credentials of the Server-to-Server OAuth app.
+func (c *configuration) accountCredentials() zoom.AccountCredentials {
+	return zoom.AccountCredentials{
+		AccountID:    c.ZoomAccountID,
+		ClientID:     c.OAuthClientID,
+		ClientSecret: c.OAuthClientSecret,
+	}
+}
+
+// getAccountToken returns the cached token of the Server-to-Server OAuth app, minting a new one
+// when it is missing or about to expire.
+func (p *Plugin) getAccountToken() (*oauth2.Token, error) {
+	credentials := p.getConfiguration().accountCredentials()
+	zoomURL := p.getZoomURL()
+
+	cache := &p.accountTokens
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if cache.token != nil && cache.zoomURL == zoomURL && cache.credentials == credentials &&
+		time.Until(cache.token.Expiry) > accountTokenExpiryDelta {
+		return cache.token, nil
+	}
+
+	token, err := zoom.FetchAccountToken(zoomURL, credentials)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not mint a Server-to-Server OAuth token")
+	}
+
+	cache.zoomURL = zoomURL
+	cache.credentials = credentials
+	cache.token = token
+	return token, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// newAccountTokenServer stands in for the Zoom token endpoint and the users API of a
+// Server-to-Server OAuth app.
+func newAccountTokenServer(t *testing.T, expiresIn int, minted *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			clientID, clientSecret, ok := r.BasicAuth()
+			if !ok || clientID != "s2s-client-id" || clientSecret != "s2s-client-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "account_credentials", r.FormValue("grant_type"))
+			assert.Equal(t, "account-id", r.FormValue("account_id"))
+
+			atomic.AddInt32(minted, 1)
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": "account-token",
+				"token_type":   "bearer",
+				"expires_in":   expiresIn,
+			}))
+		case "/v2/users/user@example.com":
+			if r.Header.Get("Authorization") != "Bearer account-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(zoom.User{ID: "zoom-user-id", Email: "user@example.com"}))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func serverToServerTestPlugin(api *plugintest.API, zoomURL string) *Plugin {
+	p := webinarTestPlugin(api)
+	config := *testConfig
+	config.ServerToServerApp = true
+	config.ZoomAccountID = "account-id"
+	config.OAuthClientID = "s2s-client-id"
+	config.OAuthClientSecret = "s2s-client-secret"
+	config.ZoomURL = zoomURL
+	config.ZoomAPIURL = zoomURL + "/v2"
+	p.setConfiguration(&config)
+	return p
+}
+
+func TestServerToServerClient(t *testing.T) {
+	var minted int32
+	ts := newAccountTokenServer(t, 3600, &minted)
+	defer ts.Close()
+
+	user := &model.User{Id: "user-id", Email: "user@example.com"}
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("GetUser", "user-id").Return(user, nil)
+	p := serverToServerTestPlugin(api, ts.URL)
+
+	for i := 0; i < 2; i++ {
+		zoomUser, authErr := p.authenticateAndFetchZoomUser(user)
+		require.Nil(t, authErr)
+		assert.Equal(t, "zoom-user-id", zoomUser.ID)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&minted), "the token is cached until it expires")
+
+	email, err := p.getZoomEmail("user-id")
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", email)
+	assert.False(t, p.canConnect(&model.User{Roles: model.SystemAdminRoleId}))
+}
+
+func TestGetAccountToken(t *testing.T) {
+	var minted int32
+	ts := newAccountTokenServer(t, 30, &minted)
+	defer ts.Close()
+
+	api := &plugintest.API{}
+	p := serverToServerTestPlugin(api, ts.URL)
+
+	_, err := p.getAccountToken()
+	require.NoError(t, err)
+	_, err = p.getAccountToken()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&minted), "tokens about to expire are replaced")
+
+	config := *p.getConfiguration()
+	config.OAuthClientSecret = "wrong-secret"
+	p.setConfiguration(&config)
+	_, err = p.getAccountToken()
+	assert.Error(t, err, "changed credentials mint a new token")
+}
+
+func TestConfigurationServerToServerIsValid(t *testing.T) {
+	config := *testConfig
+	config.ServerToServerApp = true
+	assert.EqualError(t, config.IsValid(false), "please configure ZoomAccountID")
+
+	config.ZoomAccountID = "account-id"
+	assert.NoError(t, config.IsValid(false))
+
+	config.AccountLevelApp = true
+	assert.Error(t, config.IsValid(false))
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	alternativeHostsNone          = "none"
+	alternativeHostsChannelAdmins = "channel_admins"
+	alternativeHostsUsers         = "users"
+
+	channelSettingsFieldAlternativeHosts     = "alternative_hosts"
+	channelSettingsFieldAlternativeHostUsers = "alternative_host_users"
+
+	channelMembersPerPage = 200
+)
+
+// channelAlternativeHostIDs returns the Mattermost users who become alternative hosts of the
+// meetings created in a channel, as chosen with `/zoom channel-settings`.
+func (p *Plugin) channelAlternativeHostIDs(channelID string) ([]string, error) {
+	settings, err := p.listZoomChannelSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	value := settings[channelID]
+	switch value.AlternativeHosts {
+	case alternativeHostsChannelAdmins:
+		return p.listChannelAdminIDs(channelID)
+	case alternativeHostsUsers:
+		return value.AlternativeHostUserIDs, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (p *Plugin) listChannelAdminIDs(channelID string) ([]string, error) {
+	var adminIDs []string
+	for page := 0; ; page++ {
+		members, appErr := p.API.GetChannelMembers(channelID, page, channelMembersPerPage)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "could not list the channel members")
+		}
+		for _, member := range members {
+			if member.SchemeAdmin {
+				adminIDs = append(adminIDs, member.UserId)
+			}
+		}
+		if len(members) < channelMembersPerPage {
+			return adminIDs, nil
+		}
+	}
+}
+
+// getZoomEmail returns the email of the Zoom account of a Mattermost user. Account level apps
+// use the Mattermost email, as they do to start meetings.
+func (p *Plugin) getZoomEmail(userID string) (string, error) {
+	if p.getConfiguration().isAccountLevel() {
+		user, appErr := p.API.GetUser(userID)
+		if appErr != nil {
+			return "", appErr
+		}
+		return user.Email, nil
+	}
+
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, userID)
+	if err != nil {
+		return "", err
+	}
+	return info.ZoomEmail, nil
+}
+
+// addAlternativeHosts adds the alternative hosts of a channel to a new meeting, on top of the
+// ones a template may have set. Users who aren't connected to Zoom are skipped, and so is the
+// host, whom Zoom doesn't accept as an alternative host.
+func (p *Plugin) addAlternativeHosts(zoomUser *zoom.User, channelID string, request *zoom.CreateMeetingRequest) error {
+	userIDs, err := p.channelAlternativeHostIDs(channelID)
+	if err != nil {
+		return errors.Wrap(err, "could not get the alternative hosts of the channel")
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	hostEmail := zoomUser.Email
+	if request.ScheduleFor != "" {
+		hostEmail = request.ScheduleFor
+	}
+
+	var emails []string
+	seen := map[string]bool{strings.ToLower(hostEmail): true}
+	for _, email := range strings.Split(request.Settings.AlternativeHosts, ",") {
+		if email = strings.TrimSpace(email); email != "" && !seen[strings.ToLower(email)] {
+			seen[strings.ToLower(email)] = true
+			emails = append(emails, email)
+		}
+	}
+	for _, userID := range userIDs {
+		email, err := p.getZoomEmail(userID)
+		if err != nil {
+			p.API.LogDebug("Skipping an alternative host without a Zoom account", "user_id", userID, "error", err.Error())
+			continue
+		}
+		if email == "" || seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+		emails = append(emails, email)
+	}
+
+	request.Settings.AlternativeHosts = strings.Join(emails, ",")
+	return nil
+}
+
+// alternativeHostNames returns who can host a meeting besides its creator, for the meeting card.
+// Emails of Mattermost users are shown as mentions.
+func (p *Plugin) alternativeHostNames(alternativeHosts string) []string {
+	var names []string
+	for _, email := range strings.Split(alternativeHosts, ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		if user, appErr := p.API.GetUserByEmail(email); appErr == nil {
+			names = append(names, "@"+user.Username)
+			continue
+		}
+		names = append(names, email)
+	}
+	return names
+}
+
+// parseAlternativeHostUsers resolves the comma separated usernames of the channel settings dialog.
+func (p *Plugin) parseAlternativeHostUsers(value string) ([]string, error) {
+	var userIDs []string
+	for _, username := range strings.Split(value, ",") {
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if username == "" {
+			continue
+		}
+		user, appErr := p.API.GetUserByUsername(username)
+		if appErr != nil {
+			return nil, fmt.Errorf("@%s is not a user", username)
+		}
+		userIDs = append(userIDs, user.Id)
+	}
+	return userIDs, nil
+}
+
+// formatUserMentions returns the comma separated mentions of users, skipping deleted users.
+func (p *Plugin) formatUserMentions(userIDs []string) string {
+	usernames := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if user, appErr := p.API.GetUser(userID); appErr == nil {
+			usernames = append(usernames, "@"+user.Username)
+		}
+	}
+	return strings.Join(usernames, ", ")
+}
+
+// alternativeHostsDialogElements returns the elements of the channel settings dialog choosing
+// the alternative hosts of the channel's meetings.
+func (p *Plugin) alternativeHostsDialogElements(current ZoomChannelSettingsMapValue) []model.DialogElement {
+	mode := current.AlternativeHosts
+	if mode == "" {
+		mode = alternativeHostsNone
+	}
+
+	return []model.DialogElement{
+		{
+			DisplayName: "Alternative hosts",
+			HelpText:    "Who else can start and manage the meetings created in this channel.",
+			Name:        channelSettingsFieldAlternativeHosts,
+			Type:        "select",
+			Default:     mode,
+			Options: []*model.PostActionOptions{
+				{Text: "Nobody", Value: alternativeHostsNone},
+				{Text: "Channel admins", Value: alternativeHostsChannelAdmins},
+				{Text: "Selected users", Value: alternativeHostsUsers},
+			},
+		},
+		{
+			DisplayName: "Selected alternative hosts",
+			HelpText:    "Used with Selected users. Usernames separated by commas, for example @alice, @bob. Zoom only accepts users of the same Zoom account.",
+			Name:        channelSettingsFieldAlternativeHostUsers,
+			Type:        "text",
+			Optional:    true,
+			Default:     p.formatUserMentions(current.AlternativeHostUserIDs),
+		},
+	}
+}
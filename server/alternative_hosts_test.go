@@ -0,0 +1,67 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestAddAlternativeHosts(t *testing.T) {
+	settings, err := json.Marshal(ZoomChannelSettingsMap{
+		"admins-id": {AlternativeHosts: alternativeHostsChannelAdmins},
+		"users-id":  {AlternativeHosts: alternativeHostsUsers, AlternativeHostUserIDs: []string{"bob-id", "gone-id"}},
+	})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", zoomChannelSettings).Return(settings, nil)
+	api.On("GetChannelMembers", "admins-id", 0, channelMembersPerPage).Return(model.ChannelMembers{
+		{UserId: "host-id", SchemeAdmin: true},
+		{UserId: "ann-id", SchemeAdmin: true},
+		{UserId: "member-id"},
+	}, nil)
+	api.On("GetUser", "host-id").Return(&model.User{Id: "host-id", Email: "host@example.com"}, nil)
+	api.On("GetUser", "ann-id").Return(&model.User{Id: "ann-id", Email: "ann@example.com"}, nil)
+	api.On("GetUser", "bob-id").Return(&model.User{Id: "bob-id", Email: "bob@example.com"}, nil)
+	api.On("GetUser", "gone-id").Return(nil, &model.AppError{Message: "not found"})
+
+	p := webinarTestPlugin(api)
+	config := *testConfig
+	config.AccountLevelApp = true
+	p.setConfiguration(&config)
+	zoomUser := &zoom.User{Email: "Host@example.com"}
+
+	request := &zoom.CreateMeetingRequest{}
+	request.Settings.AlternativeHosts = "ann@example.com,carol@example.com"
+	require.NoError(t, p.addAlternativeHosts(zoomUser, "admins-id", request))
+	assert.Equal(t, "ann@example.com,carol@example.com", request.Settings.AlternativeHosts)
+
+	request = &zoom.CreateMeetingRequest{}
+	require.NoError(t, p.addAlternativeHosts(zoomUser, "users-id", request))
+	assert.Equal(t, "bob@example.com", request.Settings.AlternativeHosts)
+
+	request = &zoom.CreateMeetingRequest{}
+	require.NoError(t, p.addAlternativeHosts(zoomUser, "other-id", request))
+	assert.Empty(t, request.Settings.AlternativeHosts)
+}
+
+func TestAlternativeHostNames(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUserByEmail", "ann@example.com").Return(&model.User{Username: "ann"}, nil)
+	api.On("GetUserByEmail", "carol@example.com").Return(nil, &model.AppError{Message: "not found"})
+
+	p := webinarTestPlugin(api)
+	assert.Equal(t, []string{"@ann", "carol@example.com"}, p.alternativeHostNames("ann@example.com, carol@example.com"))
+	assert.Empty(t, p.alternativeHostNames(""))
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	attendanceTimeFormat = "15:04 MST"
+
+	// attendanceReportMaxRunes leaves room under the post size limit for the note about
+	// attendees that didn't fit in the table.
+	attendanceReportMaxRunes = model.PostMessageMaxRunesV2 - 200
+)
+
+// attendee is a participant of an ended meeting, merged across the times they rejoined.
+type attendee struct {
+	Name      string
+	Email     string
+	Username  string
+	JoinTime  time.Time
+	LeaveTime time.Time
+	Total     time.Duration
+}
+
+// buildAttendance merges the attendance records of the same participant and sorts the
+// attendees by the time they first joined.
+func buildAttendance(records []zoom.PastMeetingParticipant) []*attendee {
+	byKey := map[string]*attendee{}
+	var attendees []*attendee
+	for _, record := range records {
+		key := strings.ToLower(record.UserEmail)
+		if key == "" {
+			key = "id:" + record.ID
+		}
+		if record.ID == "" && record.UserEmail == "" {
+			key = "name:" + record.Name
+		}
+
+		total := time.Duration(record.Duration) * time.Second
+		if total == 0 && record.LeaveTime.After(record.JoinTime) {
+			total = record.LeaveTime.Sub(record.JoinTime)
+		}
+
+		a, ok := byKey[key]
+		if !ok {
+			a = &attendee{Name: record.Name, Email: record.UserEmail, JoinTime: record.JoinTime, LeaveTime: record.LeaveTime}
+			byKey[key] = a
+			attendees = append(attendees, a)
+		}
+		if record.JoinTime.Before(a.JoinTime) {
+			a.JoinTime = record.JoinTime
+		}
+		if record.LeaveTime.After(a.LeaveTime) {
+			a.LeaveTime = record.LeaveTime
+		}
+		a.Total += total
+	}
+
+	sort.SliceStable(attendees, func(i, j int) bool {
+		return attendees[i].JoinTime.Before(attendees[j].JoinTime)
+	})
+	return attendees
+}
+
+// mapAttendees fills in the Mattermost username of the attendees whose Zoom email matches a user.
+func (p *Plugin) mapAttendees(attendees []*attendee) {
+	for _, a := range attendees {
+		if a.Email == "" {
+			continue
+		}
+
+		user, appErr := p.API.GetUserByEmail(a.Email)
+		if appErr != nil || user.DeleteAt != 0 {
+			continue
+		}
+		a.Username = user.Username
+	}
+}
+
+func formatAttendanceDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "< 1m"
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func formatAttendanceTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(attendanceTimeFormat)
+}
+
+// attendanceReportMessage renders the attendees as a Markdown table, mentioning the matched users.
+// Attendees that don't fit in a single post are left to the CSV.
+func attendanceReportMessage(attendees []*attendee) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("#### Attendance report\n\n%d attendee(s)\n\n", len(attendees)))
+	b.WriteString("| Attendee | Joined | Left | Total time |\n|:---|:---|:---|:---|\n")
+
+	for i, a := range attendees {
+		name := strings.ReplaceAll(a.Name, "|", "\\|")
+		if a.Username != "" {
+			name = "@" + a.Username
+		}
+
+		row := fmt.Sprintf("| %s | %s | %s | %s |\n", name, formatAttendanceTime(a.JoinTime), formatAttendanceTime(a.LeaveTime), formatAttendanceDuration(a.Total))
+		if len([]rune(b.String()))+len([]rune(row)) > attendanceReportMaxRunes {
+			b.WriteString(fmt.Sprintf("\n_%d more attendee(s) are listed in the attached CSV._", len(attendees)-i))
+			break
+		}
+		b.WriteString(row)
+	}
+
+	return b.String()
+}
+
+func attendanceReportCSV(attendees []*attendee) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"Name", "Email", "Mattermost Username", "Joined", "Left", "Total Minutes"}); err != nil {
+		return nil, err
+	}
+
+	for _, a := range attendees {
+		joined, left := "", ""
+		if !a.JoinTime.IsZero() {
+			joined = a.JoinTime.UTC().Format(time.RFC3339)
+		}
+		if !a.LeaveTime.IsZero() {
+			left = a.LeaveTime.UTC().Format(time.RFC3339)
+		}
+
+		record := []string{a.Name, a.Email, a.Username, joined, left, strconv.FormatFloat(a.Total.Minutes(), 'f', 1, 64)}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// meetingPostOwner returns the Mattermost user whose Zoom connection is used for follow-up
+// requests about a meeting post. Cards posted by the bot belong to the user who subscribed the channel.
+func (p *Plugin) meetingPostOwner(post *model.Post) string {
+	if post.UserId != p.botUserID {
+		return post.UserId
+	}
+
+	meetingID, ok := post.Props["meeting_id"].(float64)
+	if !ok {
+		return ""
+	}
+
+	entry, appErr := p.getMeetingChannelEntry(int(meetingID))
+	if appErr != nil || entry == nil {
+		return ""
+	}
+	if subscription := entry.subscription(post.ChannelId); subscription != nil {
+		return subscription.CreatedBy
+	}
+	return ""
+}
+
+// getMeetingPostClient returns a Zoom client for the first owner of the posts that has one.
+func (p *Plugin) getMeetingPostClient(posts []*model.Post) (zoom.Client, error) {
+	userIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		userIDs = append(userIDs, p.meetingPostOwner(post))
+	}
+	return p.getFirstActiveClient(userIDs)
+}
+
+// getFirstActiveClient returns a Zoom client for the first of the users that is connected to Zoom.
+func (p *Plugin) getFirstActiveClient(userIDs []string) (zoom.Client, error) {
+	err := errors.New("none of the users is connected to Zoom")
+	for _, userID := range userIDs {
+		if userID == "" {
+			continue
+		}
+
+		user, appErr := p.API.GetUser(userID)
+		if appErr != nil {
+			err = appErr
+			continue
+		}
+
+		var client zoom.Client
+		client, _, err = p.getActiveClient(user)
+		if err == nil {
+			return client, nil
+		}
+	}
+
+	return nil, err
+}
+
+// postAttendanceReports replies to the ended meeting posts with the attendance report of the
+// meeting instance, along with a CSV version of it.
+func (p *Plugin) postAttendanceReports(meetingID int, meetingUUID string, posts []*model.Post) {
+	if meetingUUID == "" || len(posts) == 0 {
+		return
+	}
+
+	client, err := p.getMeetingPostClient(posts)
+	if err != nil {
+		p.API.LogDebug("Could not get a Zoom client for the attendance report", "err", err.Error())
+		return
+	}
+
+	records, err := client.GetPastMeetingParticipants(meetingUUID)
+	if err != nil {
+		p.API.LogWarn("Could not fetch the meeting participants", "meeting_uuid", meetingUUID, "err", err.Error())
+		return
+	}
+
+	attendees := buildAttendance(records)
+	if len(attendees) == 0 {
+		return
+	}
+	p.mapAttendees(attendees)
+
+	message := attendanceReportMessage(attendees)
+	data, err := attendanceReportCSV(attendees)
+	if err != nil {
+		p.API.LogWarn("Could not write the attendance CSV", "err", err.Error())
+		return
+	}
+
+	for _, post := range posts {
+		reply := &model.Post{
+			UserId:    p.botUserID,
+			ChannelId: post.ChannelId,
+			RootId:    post.Id,
+			Message:   message,
+		}
+		if post.RootId != "" {
+			reply.RootId = post.RootId
+		}
+
+		if fileInfo, appErr := p.API.UploadFile(data, post.ChannelId, fmt.Sprintf("attendance_%d.csv", meetingID)); appErr != nil {
+			p.API.LogWarn("Could not upload the attendance CSV", "channel_id", post.ChannelId, "err", appErr.Error())
+		} else {
+			reply.FileIds = []string{fileInfo.Id}
+		}
+
+		if _, appErr := p.API.CreatePost(reply); appErr != nil {
+			p.API.LogWarn("Could not post the attendance report", "post_id", post.Id, "err", appErr.Error())
+		}
+	}
+}
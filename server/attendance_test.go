@@ -0,0 +1,71 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestBuildAttendance(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2026, time.March, 4, 14, minute, 0, 0, time.UTC)
+	}
+
+	attendees := buildAttendance([]zoom.PastMeetingParticipant{
+		{ID: "b", Name: "Bob", UserEmail: "bob@example.com", JoinTime: at(5), LeaveTime: at(20), Duration: 900},
+		{ID: "a1", Name: "Alice", UserEmail: "Alice@example.com", JoinTime: at(1), LeaveTime: at(10)},
+		{ID: "a2", Name: "Alice", UserEmail: "alice@example.com", JoinTime: at(15), LeaveTime: at(40)},
+		{Name: "Dial-in"},
+	})
+
+	require.Len(t, attendees, 3)
+	assert.Equal(t, &attendee{Name: "Dial-in"}, attendees[0])
+	assert.Equal(t, &attendee{Name: "Alice", Email: "Alice@example.com", JoinTime: at(1), LeaveTime: at(40), Total: 34 * time.Minute}, attendees[1])
+	assert.Equal(t, &attendee{Name: "Bob", Email: "bob@example.com", JoinTime: at(5), LeaveTime: at(20), Total: 15 * time.Minute}, attendees[2])
+}
+
+func TestAttendanceReport(t *testing.T) {
+	attendees := []*attendee{
+		{
+			Name:      "Alice",
+			Email:     "alice@example.com",
+			Username:  "alice",
+			JoinTime:  time.Date(2026, time.March, 4, 14, 1, 0, 0, time.UTC),
+			LeaveTime: time.Date(2026, time.March, 4, 15, 6, 0, 0, time.UTC),
+			Total:     65 * time.Minute,
+		},
+		{Name: "Guest | Phone", Total: 20 * time.Second},
+	}
+
+	message := attendanceReportMessage(attendees)
+	assert.Contains(t, message, "| @alice | 14:01 UTC | 15:06 UTC | 1h 5m |")
+	assert.Contains(t, message, `| Guest \| Phone | - | - | < 1m |`)
+
+	data, err := attendanceReportCSV(attendees)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"Name,Email,Mattermost Username,Joined,Left,Total Minutes",
+		"Alice,alice@example.com,alice,2026-03-04T14:01:00Z,2026-03-04T15:06:00Z,65.0",
+		"Guest | Phone,,,,,0.3",
+		"",
+	}, "\n"), string(data))
+}
+
+func TestAttendanceReportMessageIsTruncated(t *testing.T) {
+	attendees := make([]*attendee, 1000)
+	for i := range attendees {
+		attendees[i] = &attendee{Name: strings.Repeat("x", 40)}
+	}
+
+	message := attendanceReportMessage(attendees)
+	assert.LessOrEqual(t, len([]rune(message)), attendanceReportMaxRunes+200)
+	assert.Contains(t, message, "more attendee(s) are listed in the attached CSV.")
+}
@@ -7,12 +7,95 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"io"
 )
 
+// sealedValue is a value of the KV store encrypted with AES-256-GCM. KeyID identifies the
+// encryption key it was sealed with, so values sealed with a retired key can still be opened.
+type sealedValue struct {
+	KeyID      string `json:"kid"`
+	Ciphertext []byte `json:"ct"`
+}
+
+var errUnknownEncryptionKey = errors.New("the value was encrypted with an unknown key")
+
+// deriveKey derives a key for purpose from the EncryptionKey setting, so the setting can be of
+// any length and is never used for two purposes.
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// encryptionKeyID identifies an encryption key without revealing it.
+func encryptionKeyID(secret string) string {
+	return base64.RawURLEncoding.EncodeToString(deriveKey(secret, "key id")[:12])
+}
+
+func newAEAD(secret string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(secret, "token encryption"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with secret and returns the encoded sealedValue.
+func seal(secret string, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	keyID := encryptionKeyID(secret)
+	return json.Marshal(sealedValue{
+		KeyID:      keyID,
+		Ciphertext: aead.Seal(nonce, nonce, plaintext, []byte(keyID)),
+	})
+}
+
+// parseSealedValue decodes a value written by seal, and reports whether it is one. Values stored
+// before tokens were sealed are plain JSON without a key ID.
+func parseSealedValue(raw []byte) (*sealedValue, bool) {
+	var sealed sealedValue
+	if err := json.Unmarshal(raw, &sealed); err != nil || sealed.KeyID == "" || len(sealed.Ciphertext) == 0 {
+		return nil, false
+	}
+	return &sealed, true
+}
+
+// open decrypts the value with secret, which must be the key it was sealed with.
+func (v *sealedValue) open(secret string) ([]byte, error) {
+	if encryptionKeyID(secret) != v.KeyID {
+		return nil, errUnknownEncryptionKey
+	}
+
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Ciphertext) < aead.NonceSize() {
+		return nil, errors.New("the sealed value is too short")
+	}
+
+	nonce, ciphertext := v.Ciphertext[:aead.NonceSize()], v.Ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(v.KeyID))
+}
+
+// pad, unpad, encryptLegacy and decryptLegacy implement the format access tokens were encrypted
+// with before whole tokens were sealed. It is only read, to migrate the tokens stored in it.
+
 func pad(src []byte) []byte {
 	padding := aes.BlockSize - len(src)%aes.BlockSize
 	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
@@ -30,7 +113,7 @@ func unpad(src []byte) ([]byte, error) {
 	return src[:(length - unpadding)], nil
 }
 
-func encrypt(key []byte, text string) (string, error) {
+func encryptLegacy(key []byte, text string) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
@@ -49,7 +132,7 @@ func encrypt(key []byte, text string) (string, error) {
 	return finalMsg, nil
 }
 
-func decrypt(key []byte, text string) (string, error) {
+func decryptLegacy(key []byte, text string) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
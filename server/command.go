@@ -4,10 +4,12 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
 
@@ -18,8 +20,9 @@ import (
 )
 
 const (
-	starterText   = "###### Mattermost Zoom Plugin - Slash Command Help\n"
-	helpText      = `* |/zoom start| - Start a Zoom meeting`
+	starterText = "###### Mattermost Zoom Plugin - Slash Command Help\n"
+	helpText    = `* |/zoom start| - Start a Zoom meeting
+* |/zoom schedule| - Schedule a Zoom meeting, optionally repeating`
 	oAuthHelpText = `* |/zoom connect| - Connect to Zoom
 * |/zoom disconnect| - Disconnect from Zoom`
 	settingHelpText               = `* |/zoom settings| - Update your preferences`
@@ -28,6 +31,23 @@ const (
 	subscriptionHelpText          = `* |/zoom subscription add [meetingID]| - Subscribe this channel to a Zoom meeting
 * |/zoom subscription remove [meetingID]| - Unsubscribe this channel from a Zoom meeting
 * |/zoom subscription list| - List all meeting subscriptions`
+	webhooksHelpText = `* |/zoom webhooks list| - List the pending and failed Zoom webhook events
+* |/zoom webhooks show [eventID]| - Show a failed Zoom webhook event
+* |/zoom webhooks replay [eventID]| - Process a failed Zoom webhook event again, or every failed event with |all|`
+	searchHelpText  = `* |/zoom search [terms]| - Search the transcripts of the meetings in this channel`
+	webinarHelpText = `* |/zoom webinar create| - Create a Zoom webinar in this channel
+* |/zoom webinar registrants [webinarID]| - List the registrants of a webinar you host`
+	delegateHelpText = `* |/zoom start --for @username [topic]| - Start a Zoom meeting hosted by a user who allowed you to schedule for them
+* |/zoom schedule --for @username| - Schedule a Zoom meeting hosted by a user who allowed you to schedule for them
+* |/zoom delegate add @username| - Allow a user to schedule Zoom meetings for you
+* |/zoom delegate remove @username| - Stop a user from scheduling Zoom meetings for you
+* |/zoom delegate list| - List who can schedule meetings for you, and for whom you can`
+	templateHelpText = `* |/zoom start --template [name] [topic]| - Start a Zoom meeting with the settings of a template
+* |/zoom template list| - List the meeting templates of this channel
+* |/zoom template create [name]| - Create or edit a meeting template
+* |/zoom template delete [name]| - Delete a meeting template`
+	adminHelpText = `* |/zoom admin users [page]| - List the users connected to Zoom and the health of their connection
+* |/zoom admin disconnect @username| - Disconnect a user from Zoom, or every user with |all|`
 	alreadyConnectedText   = "Already connected"
 	zoomPreferenceCategory = "plugin:zoom"
 	zoomPMISettingName     = "use-pmi"
@@ -37,6 +57,7 @@ const (
 const (
 	actionConnect             = "connect"
 	actionStart               = "start"
+	actionSchedule            = "schedule"
 	actionDisconnect          = "disconnect"
 	actionHelp                = "help"
 	actionSubscription        = "subscription"
@@ -46,6 +67,25 @@ const (
 	settings                  = "settings"
 	actionChannelSettings     = "channel-settings"
 	channelSettingsActionList = "list"
+	actionWebhooks            = "webhooks"
+	webhooksActionList        = "list"
+	webhooksActionShow        = "show"
+	webhooksActionReplay      = "replay"
+	actionSearch              = "search"
+	actionWebinar             = "webinar"
+	webinarActionCreate       = "create"
+	webinarActionRegistrants  = "registrants"
+	actionTemplate            = "template"
+	templateActionList        = "list"
+	templateActionCreate      = "create"
+	templateActionDelete      = "delete"
+	actionDelegate            = "delegate"
+	delegateActionAdd         = "add"
+	delegateActionRemove      = "remove"
+	delegateActionList        = "list"
+	actionAdmin               = "admin"
+	adminActionUsers          = "users"
+	adminActionDisconnect     = "disconnect"
 
 	actionUnknown = "Unknown Action"
 )
@@ -58,11 +98,11 @@ func (p *Plugin) getCommand() (*model.Command, error) {
 		return nil, errors.Wrap(err, "failed to get icon data")
 	}
 
-	canConnect := !p.configuration.AccountLevelApp
+	canConnect := !p.configuration.isAccountLevel()
 
-	autoCompleteDesc := "Available commands: start, help, subscription, settings, channel-settings"
+	autoCompleteDesc := "Available commands: start, schedule, help, subscription, settings, channel-settings, search, webinar, template, delegate"
 	if canConnect {
-		autoCompleteDesc = "Available commands: start, connect, disconnect, help, subscription, settings, channel-settings"
+		autoCompleteDesc = "Available commands: start, schedule, connect, disconnect, help, subscription, settings, channel-settings, search, webinar, template, delegate"
 	}
 
 	return &model.Command{
@@ -96,6 +136,29 @@ func (p *Plugin) parseCommand(rawCommand string) (cmd, action, topic string) {
 	return cmd, action, topic
 }
 
+// takeCommandFlag takes a flag given as "--flag value" or "--flag=value" off the arguments of a
+// command, and returns its value and the remaining arguments.
+func takeCommandFlag(args, flag string) (string, string, error) {
+	fields := strings.Fields(args)
+	var rest []string
+	value := ""
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case fields[i] == flag:
+			if i+1 >= len(fields) {
+				return "", "", errors.Errorf("the %s flag needs a value", flag)
+			}
+			value = fields[i+1]
+			i++
+		case strings.HasPrefix(fields[i], flag+"="):
+			value = strings.TrimPrefix(fields[i], flag+"=")
+		default:
+			rest = append(rest, fields[i])
+		}
+	}
+	return value, strings.Join(rest, " "), nil
+}
+
 func (p *Plugin) executeCommand(c *plugin.Context, args *model.CommandArgs) (string, error) {
 	command, action, topic := p.parseCommand(args.Command)
 
@@ -120,6 +183,8 @@ func (p *Plugin) executeCommand(c *plugin.Context, args *model.CommandArgs) (str
 		return p.runSubscriptionCommand(args, strings.Fields(args.Command)[2:], user)
 	case actionStart:
 		return p.runStartCommand(args, user, topic)
+	case actionSchedule:
+		return p.runScheduleCommand(args, user)
 	case actionDisconnect:
 		return p.runDisconnectCommand(user)
 	case actionHelp, "":
@@ -128,12 +193,27 @@ func (p *Plugin) executeCommand(c *plugin.Context, args *model.CommandArgs) (str
 		return p.runSettingCommand(args, strings.Fields(args.Command)[2:], user)
 	case actionChannelSettings:
 		return p.runChannelSettingsCommand(args, strings.Fields(args.Command)[2:], user)
+	case actionWebhooks:
+		return p.runWebhooksCommand(strings.Fields(args.Command)[2:], user)
+	case actionSearch:
+		return p.runSearchCommand(args.ChannelId, strings.Join(strings.Fields(args.Command)[2:], " "), user)
+	case actionWebinar:
+		return p.runWebinarCommand(args, strings.Fields(args.Command)[2:], user)
+	case actionTemplate:
+		return p.runTemplateCommand(args, strings.Fields(args.Command)[2:], user)
+	case actionDelegate:
+		return p.runDelegateCommand(strings.Fields(args.Command)[2:], user)
+	case actionAdmin:
+		return p.runAdminCommand(strings.Fields(args.Command)[2:], user)
 	default:
 		return fmt.Sprintf("%s %v", actionUnknown, action), nil
 	}
 }
 
 func (p *Plugin) canConnect(user *model.User) bool {
+	if p.configuration.ServerToServerApp {
+		return false // nobody connects Server-to-Server apps
+	}
 	return !p.configuration.AccountLevelApp || user.IsSystemAdmin() // admins can connect Account level apps
 }
 
@@ -150,6 +230,15 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 
 // runStartCommand runs command to start a Zoom meeting.
 func (p *Plugin) runStartCommand(args *model.CommandArgs, user *model.User, topic string) (string, error) {
+	templateName, topic, err := parseStartTemplateFlag(topic)
+	if err != nil {
+		return "Use `/zoom start --template [name] [topic]` to start a meeting with a template.", nil
+	}
+	hostUsername, topic, err := takeCommandFlag(topic, scheduleForFlag)
+	if err != nil {
+		return "Use `/zoom start --for @username [topic]` to start a meeting for another user.", nil
+	}
+
 	restrict, err := p.isChannelRestrictedForMeetings(args.ChannelId)
 	if err != nil {
 		p.client.Log.Error("Unable to check channel preference", "ChannelID", args.ChannelId, "Error", err.Error())
@@ -173,13 +262,30 @@ func (p *Plugin) runStartCommand(args *model.CommandArgs, user *model.User, topi
 		return authErr.Message, authErr.Err
 	}
 
+	template, err := p.meetingTemplateForStart(args.ChannelId, templateName)
+	if err != nil {
+		return fmt.Sprintf("There is no meeting template `%s` in this channel. Run `/zoom template list` to see the available templates.", templateName), nil
+	}
+
+	var delegation *meetingDelegation
+	if hostUsername != "" {
+		var message string
+		if delegation, message, err = p.resolveMeetingDelegation(user, hostUsername); message != "" {
+			return message, err
+		}
+	}
+
 	recentMeeting, recentMeetingLink, creatorName, provider, appErr := p.checkPreviousMessages(args.ChannelId)
 	if appErr != nil {
 		return "Error checking previous messages", nil
 	}
 
 	if recentMeeting {
-		p.postConfirm(recentMeetingLink, args.ChannelId, topic, user.Id, args.RootId, creatorName, provider)
+		scheduleFor := ""
+		if delegation != nil {
+			scheduleFor = delegation.Host.Username
+		}
+		p.postConfirm(recentMeetingLink, args.ChannelId, topic, templateName, scheduleFor, user.Id, args.RootId, creatorName, provider)
 		return "", nil
 	}
 
@@ -191,6 +297,18 @@ func (p *Plugin) runStartCommand(args *model.CommandArgs, user *model.User, topi
 	if err != nil {
 		return "", err
 	}
+	if template != nil {
+		// Templates only apply to new meetings, so they take precedence over the Personal Meeting ID.
+		userPMISettingPref = falseString
+	}
+	scheduleFor := ""
+	var props model.StringInterface
+	if delegation != nil {
+		// Meetings hosted by another user can't use the Personal Meeting ID of the delegate.
+		userPMISettingPref = falseString
+		scheduleFor = delegation.HostEmail
+		props = delegation.postProps(user)
+	}
 
 	switch userPMISettingPref {
 	case "", zoomPMISettingValueAsk:
@@ -200,20 +318,22 @@ func (p *Plugin) runStartCommand(args *model.CommandArgs, user *model.User, topi
 		meetingID = zoomUser.Pmi
 
 		if meetingID <= 0 {
-			meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, topic)
+			meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, args.ChannelId, args.RootId, topic, template, "")
 			if createMeetingErr != nil {
 				return "", errors.Wrap(createMeetingErr, "failed to create the meeting")
 			}
 			p.sendEnableZoomPMISettingMessage(user.Id, args.ChannelId, args.RootId)
+		} else if message := p.checkPMISecurityPolicy(user, args.ChannelId, meetingID); message != "" {
+			return message, nil
 		}
 	default:
-		meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, topic)
+		meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, args.ChannelId, args.RootId, topic, template, scheduleFor)
 		if createMeetingErr != nil {
 			return "", errors.Wrap(createMeetingErr, "failed to create the meeting")
 		}
 	}
 
-	if postMeetingErr := p.postMeeting(user, meetingID, meetingUUID, args.ChannelId, args.RootId, topic, ""); postMeetingErr != nil {
+	if _, postMeetingErr := p.postMeetingWithProps(user, meetingID, meetingUUID, args.ChannelId, args.RootId, topic, "", props); postMeetingErr != nil {
 		return "", postMeetingErr
 	}
 
@@ -232,7 +352,7 @@ func (p *Plugin) runConnectCommand(user *model.User, extra *model.CommandArgs) (
 	// OAuth Account Level
 	if p.configuration.AccountLevelApp {
 		token, err := p.getSuperuserToken()
-		if err == nil && token != nil {
+		if err == nil && token != nil && !p.isSuperUserTokenBroken() {
 			return alreadyConnectedText, nil
 		}
 
@@ -244,8 +364,8 @@ func (p *Plugin) runConnectCommand(user *model.User, extra *model.CommandArgs) (
 	}
 
 	// OAuth User Level
-	_, err := p.fetchOAuthUserInfo(zoomUserByMMID, user.Id)
-	if err == nil {
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, user.Id)
+	if err == nil && info.BrokenAt == 0 {
 		return alreadyConnectedText, nil
 	}
 
@@ -310,14 +430,16 @@ func (p *Plugin) runSubscriptionListCommand(args *model.CommandArgs) (string, er
 	sb.WriteString("| Meeting ID | Channel |\n")
 	sb.WriteString("| :--- | :--- |\n")
 
-	for meetingID, channelID := range subs {
-		channel, appErr := p.client.Channel.Get(channelID)
-		if appErr != nil {
-			p.client.Log.Error("Unable to get channel for subscription list", "ChannelID", channelID, "Error", appErr.Error())
-			sb.WriteString(fmt.Sprintf("| %s | (unknown channel %s) |\n", meetingID, channelID))
-			continue
+	for meetingID, channelIDs := range subs {
+		for _, channelID := range channelIDs {
+			channel, appErr := p.client.Channel.Get(channelID)
+			if appErr != nil {
+				p.client.Log.Error("Unable to get channel for subscription list", "ChannelID", channelID, "Error", appErr.Error())
+				sb.WriteString(fmt.Sprintf("| %s | (unknown channel %s) |\n", meetingID, channelID))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("| %s | ~%s |\n", meetingID, channel.Name))
 		}
-		sb.WriteString(fmt.Sprintf("| %s | ~%s |\n", meetingID, channel.Name))
 	}
 
 	return sb.String(), nil
@@ -350,10 +472,12 @@ func (p *Plugin) runSubscribeCommand(user *model.User, extra *model.CommandArgs,
 		}
 	}
 
-	if appErr := p.storeSubscriptionForMeeting(meetingID, extra.ChannelId, user.Id); appErr != nil {
-		return "", errors.Wrap(appErr, "cannot subscribe to meeting")
+	if err := p.storeSubscriptionForMeeting(meetingID, extra.ChannelId, user.Id); err != nil {
+		return "", errors.Wrap(err, "cannot subscribe to meeting")
 	}
 
+	p.scheduleMeetingReminders(meeting, extra.ChannelId, "")
+
 	return "Channel subscribed to meeting.", nil
 }
 
@@ -369,22 +493,29 @@ func (p *Plugin) runUnsubscribeCommand(user *model.User, extra *model.CommandArg
 	if entry == nil || !entry.IsSubscription {
 		return "No subscription found for this meeting.", nil
 	}
-	if entry.ChannelID != extra.ChannelId {
-		return "This meeting is subscribed to a different channel.", nil
+	subscription := entry.subscription(extra.ChannelId)
+	if subscription == nil {
+		return "This channel is not subscribed to the meeting.", nil
 	}
 
-	if entry.CreatedBy != user.Id && !user.IsSystemAdmin() {
+	if subscription.CreatedBy != user.Id && !user.IsSystemAdmin() {
 		return "You can only remove subscriptions you created.", nil
 	}
 
-	if appErr := p.deleteChannelForMeeting(meetingID); appErr != nil {
-		return "Unable to delete the meeting subscription.", errors.Wrap(appErr, "cannot unsubscribe from meeting")
+	removed, err := p.removeSubscriptionForMeeting(meetingID, extra.ChannelId)
+	if err != nil {
+		return "Unable to delete the meeting subscription.", errors.Wrap(err, "cannot unsubscribe from meeting")
 	}
 
-	if err := p.removeFromSubscriptionIndex(entry.CreatedBy, meetingID); err != nil {
-		p.API.LogWarn("failed to update subscription index on removal", "error", err.Error())
+	// The creator keeps the meeting in their index while they have it subscribed elsewhere.
+	if removed != nil && !p.hasMeetingSubscriptionBy(meetingID, removed.CreatedBy) {
+		if err := p.removeFromSubscriptionIndex(removed.CreatedBy, meetingID); err != nil {
+			p.API.LogWarn("failed to update subscription index on removal", "error", err.Error())
+		}
 	}
 
+	p.cancelMeetingReminders(meetingID, extra.ChannelId)
+
 	return "Channel unsubscribed from meeting.", nil
 }
 
@@ -412,9 +543,9 @@ func (p *Plugin) runDisconnectCommand(user *model.User) (string, error) {
 
 // runHelpCommand runs command to display help text.
 func (p *Plugin) runHelpCommand(user *model.User) (string, error) {
-	text := starterText + strings.ReplaceAll(helpText+"\n"+settingHelpText+"\n"+subscriptionHelpText, "|", "`")
+	text := starterText + strings.ReplaceAll(helpText+"\n"+settingHelpText+"\n"+subscriptionHelpText+"\n"+searchHelpText+"\n"+webinarHelpText+"\n"+templateHelpText+"\n"+delegateHelpText, "|", "`")
 	if p.API.HasPermissionTo(user.Id, model.PermissionManageSystem) {
-		text += "\n" + strings.ReplaceAll(channelPreferenceHelpText+"\n"+listChannelPreferenceHelpText, "|", "`")
+		text += "\n" + strings.ReplaceAll(channelPreferenceHelpText+"\n"+listChannelPreferenceHelpText+"\n"+webhooksHelpText+"\n"+adminHelpText, "|", "`")
 	}
 
 	if p.canConnect(user) {
@@ -467,6 +598,22 @@ func (p *Plugin) runEditChannelSettingsCommand(args *model.CommandArgs, user *mo
 		return "Preference not allowed to set for DM/GM.", nil
 	}
 
+	settings, err := p.listZoomChannelSettings()
+	if err != nil {
+		p.client.Log.Error("Unable to get channel settings", "ChannelID", args.ChannelId, "Error", err.Error())
+		return "Error occurred while fetching channel settings", nil
+	}
+
+	current := settings[args.ChannelId]
+	reminders := formatReminderMinutes(current.ReminderMinutes)
+	if current.RemindersDisabled {
+		reminders = "0"
+	}
+	defaultReminders := p.getConfiguration().ReminderMinutes
+	if defaultReminders == "" {
+		defaultReminders = "none"
+	}
+
 	urlStr := fmt.Sprintf("%s/plugins/%s%s", p.siteURL, url.PathEscape(manifest.Id), pathChannelPreference)
 
 	requestBody := model.OpenDialogRequest{
@@ -497,9 +644,45 @@ func (p *Plugin) runEditChannelSettingsCommand(args *model.CommandArgs, user *mo
 						},
 					},
 				},
+				{
+					DisplayName: "Meeting reminders",
+					HelpText:    fmt.Sprintf("Minutes before a meeting starts to post a reminder, comma separated. Leave blank to use the plugin-wide settings (%s), or enter 0 to disable reminders.", defaultReminders),
+					Name:        channelSettingsFieldReminders,
+					Type:        "text",
+					Optional:    true,
+					Default:     reminders,
+				},
+				{
+					DisplayName: "Remind members",
+					Placeholder: "Also send reminders to channel members by direct message",
+					Name:        channelSettingsFieldRemindMembers,
+					Type:        "bool",
+					Optional:    true,
+					Default:     strconv.FormatBool(current.RemindMembers),
+				},
+				{
+					DisplayName: "Security policy",
+					Placeholder: "Enforce the meeting security policy of the plugin settings",
+					HelpText:    "Meetings get the required passcode, waiting room and authenticated join, and Personal Meeting IDs without them are refused.",
+					Name:        channelSettingsFieldSecurityPolicy,
+					Type:        "bool",
+					Optional:    true,
+					Default:     strconv.FormatBool(current.EnforceSecurityPolicy),
+				},
 			},
 		},
 	}
+	requestBody.Dialog.Elements = append(requestBody.Dialog.Elements, p.recordingPolicyDialogElements(current.RecordingPolicy)...)
+	requestBody.Dialog.Elements = append(requestBody.Dialog.Elements, p.alternativeHostsDialogElements(current)...)
+
+	templates, err := p.listMeetingTemplates()
+	if err != nil {
+		p.client.Log.Error("Unable to get meeting templates", "Error", err.Error())
+		return "Error occurred while fetching meeting templates", nil
+	}
+	if available := templates.channelTemplates(args.ChannelId); len(available) > 0 {
+		requestBody.Dialog.Elements = append(requestBody.Dialog.Elements, defaultTemplateDialogElement(available, current.DefaultTemplate))
+	}
 
 	client, _, err := p.getActiveClient(user)
 	if err != nil {
@@ -515,6 +698,34 @@ func (p *Plugin) runEditChannelSettingsCommand(args *model.CommandArgs, user *mo
 	return "", nil
 }
 
+// recordingPolicyDialogElements returns a select per recording kind, defaulting to the channel's current choice.
+func (p *Plugin) recordingPolicyDialogElements(current recordingPolicy) []model.DialogElement {
+	defaults := p.defaultRecordingPolicy()
+	elements := make([]model.DialogElement, 0, len(recordingKinds))
+	for _, kind := range recordingKinds {
+		value := channelSettingsRecordingDefault
+		if action, ok := current[kind]; ok {
+			value = string(action)
+		}
+
+		elements = append(elements, model.DialogElement{
+			DisplayName: fmt.Sprintf("Recording %s", kind),
+			HelpText:    fmt.Sprintf("What to post of the %s files of cloud recordings.", kind),
+			Name:        channelSettingsFieldRecordingPrefix + string(kind),
+			Type:        "select",
+			Optional:    true,
+			Default:     value,
+			Options: []*model.PostActionOptions{
+				{Text: fmt.Sprintf("Default to plugin-wide settings (%s)", defaults[kind]), Value: channelSettingsRecordingDefault},
+				{Text: "Ignore", Value: string(recordingActionIgnore)},
+				{Text: "Post a link", Value: string(recordingActionLink)},
+				{Text: "Upload the file", Value: string(recordingActionUpload)},
+			},
+		})
+	}
+	return elements
+}
+
 func (p *Plugin) runChannelSettingsListCommand(args *model.CommandArgs) (string, error) {
 	if !p.client.User.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
 		return "Unable to execute the command, only system admins have access to execute this command.", nil
@@ -547,16 +758,162 @@ func (p *Plugin) runChannelSettingsListCommand(args *model.CommandArgs) (string,
 			p.client.Log.Error(channelPreferenceListErr, "Error", err.Error())
 			return channelPreferenceListErr, nil
 		}
-		if value.Preference == ZoomChannelPreferences[DefaultChannelRestrictionPreference] {
+		reminders := "default"
+		if value.RemindersDisabled {
+			reminders = "off"
+		} else if len(value.ReminderMinutes) > 0 {
+			reminders = formatReminderMinutes(value.ReminderMinutes) + " min"
+		}
+		if value.RemindMembers {
+			reminders += ", DM members"
+		}
+		recordings := "default"
+		if len(value.RecordingPolicy) > 0 {
+			recordings = value.RecordingPolicy.String()
+		}
+		security := "off"
+		if value.EnforceSecurityPolicy {
+			security = "enforced"
+		}
+		alternativeHosts := "none"
+		switch value.AlternativeHosts {
+		case alternativeHostsChannelAdmins:
+			alternativeHosts = "channel admins"
+		case alternativeHostsUsers:
+			alternativeHosts = p.formatUserMentions(value.AlternativeHostUserIDs)
+		}
+		defaultTemplate := "none"
+		if value.DefaultTemplate != "" {
+			defaultTemplate = value.DefaultTemplate
+		}
+		if value.Preference == ZoomChannelPreferences[DefaultChannelRestrictionPreference] && reminders == "default" && recordings == "default" && !value.EnforceSecurityPolicy && value.AlternativeHosts == "" && value.DefaultTemplate == "" {
 			continue
 		}
 
 		if listChannelHeading {
-			sb.WriteString("| Channel ID | Channel Name | Preference |\n| :---- | :-------- | :-------- |")
+			sb.WriteString("| Channel ID | Channel Name | Preference | Reminders | Recordings | Security Policy | Alternative Hosts | Default Template |\n| :---- | :-------- | :-------- | :-------- | :-------- | :-------- | :-------- | :-------- |")
 			listChannelHeading = false
 		}
 
-		sb.WriteString(fmt.Sprintf("\n|%s|%s|%s|", key, channel.DisplayName, preference))
+		sb.WriteString(fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|%s|%s|", key, channel.DisplayName, preference, reminders, recordings, security, alternativeHosts, defaultTemplate))
+	}
+
+	return sb.String(), nil
+}
+
+func (p *Plugin) runWebhooksCommand(params []string, user *model.User) (string, error) {
+	if !p.API.HasPermissionTo(user.Id, model.PermissionManageSystem) {
+		return "Unable to execute the command, only system admins have access to execute this command.", nil
+	}
+
+	switch {
+	case len(params) == 0 || params[0] == webhooksActionList:
+		return p.runWebhooksListCommand()
+	case params[0] == webhooksActionShow && len(params) == 2:
+		return p.runWebhooksShowCommand(params[1])
+	case params[0] == webhooksActionReplay && len(params) == 2:
+		return p.runWebhooksReplayCommand(params[1])
+	default:
+		return strings.ReplaceAll(webhooksHelpText, "|", "`"), nil
+	}
+}
+
+func (p *Plugin) runWebhooksListCommand() (string, error) {
+	pending, err := p.getCompleteWebhookIndex()
+	if err != nil {
+		return "Unable to list the pending webhook events.", errors.Wrap(err, "cannot list pending webhook events")
+	}
+	failed, err := p.listWebhookEvents(webhookDeadLetterKeyPrefix)
+	if err != nil {
+		return "Unable to list the failed webhook events.", errors.Wrap(err, "cannot list failed webhook events")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#### Zoom webhook events\n%d pending, %d failed\n", len(pending.Events), len(failed)))
+	if len(failed) == 0 {
+		return sb.String(), nil
+	}
+
+	sb.WriteString("\n| Event ID | Event | Received | Attempts | Error |\n| :---- | :---- | :---- | :---- | :---- |")
+	for _, event := range failed {
+		received := time.UnixMilli(event.ReceivedAt).UTC().Format(time.RFC3339)
+		lastError := strings.ReplaceAll(strings.ReplaceAll(event.LastError, "|", "\\|"), "\n", " ")
+		sb.WriteString(fmt.Sprintf("\n|%s|%s|%s|%d|%s|", event.ID, event.Event, received, event.Attempts, lastError))
+	}
+
+	return sb.String(), nil
+}
+
+func (p *Plugin) runWebhooksShowCommand(id string) (string, error) {
+	event, err := p.getWebhookEvent(webhookDeadLetterKeyPrefix, id)
+	if err != nil {
+		return "Unable to get the webhook event.", errors.Wrap(err, "cannot get failed webhook event")
+	}
+	if event == nil {
+		return fmt.Sprintf("No failed webhook event with ID %s.", id), nil
+	}
+
+	body, err := json.MarshalIndent(event.Body, "", "  ")
+	if err != nil {
+		body = event.Body
+	}
+
+	return fmt.Sprintf("#### Webhook event %s\nEvent: %s\nAttempts: %d\nError: %s\n```json\n%s\n```", event.ID, event.Event, event.Attempts, event.LastError, body), nil
+}
+
+func (p *Plugin) runWebhooksReplayCommand(id string) (string, error) {
+	if id != "all" {
+		if err := p.replayWebhookEvent(id); err != nil {
+			return fmt.Sprintf("Unable to replay the webhook event: %s", err.Error()), nil
+		}
+		return fmt.Sprintf("Webhook event %s queued again.", id), nil
+	}
+
+	failed, err := p.listWebhookEvents(webhookDeadLetterKeyPrefix)
+	if err != nil {
+		return "Unable to list the failed webhook events.", errors.Wrap(err, "cannot list failed webhook events")
+	}
+
+	replayed := 0
+	for _, event := range failed {
+		if err := p.replayWebhookEvent(event.ID); err != nil {
+			p.API.LogWarn("Could not replay the webhook event", "id", event.ID, "err", err.Error())
+			continue
+		}
+		replayed++
+	}
+
+	return fmt.Sprintf("%d of %d failed webhook event(s) queued again.", replayed, len(failed)), nil
+}
+
+func (p *Plugin) runSearchCommand(channelID, terms string, user *model.User) (string, error) {
+	if strings.TrimSpace(terms) == "" {
+		return strings.ReplaceAll(searchHelpText, "|", "`"), nil
+	}
+
+	results, err := p.searchTranscripts(user.Id, channelID, terms)
+	if errors.Is(err, errTranscriptChannelForbidden) {
+		return "You can't search the meeting transcripts of this channel.", nil
+	}
+	if err != nil {
+		return "Unable to search the meeting transcripts.", errors.Wrap(err, "cannot search transcripts")
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No meeting transcripts of this channel match %q.", terms), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#### Meeting transcripts of this channel matching %q\n", terms))
+	for _, result := range results {
+		topic := result.Topic
+		if topic == "" {
+			topic = strconv.Itoa(result.MeetingID)
+		}
+		speaker := result.Speaker
+		if speaker == "" {
+			speaker = "Unknown speaker"
+		}
+		sb.WriteString(fmt.Sprintf("* [%s](%s) `%s` **%s**: %s\n", topic, result.Permalink, result.Timestamp, transcriptSpeakerEscaper.Replace(speaker), result.Snippet))
 	}
 
 	return sb.String(), nil
@@ -564,16 +921,18 @@ func (p *Plugin) runChannelSettingsListCommand(args *model.CommandArgs) (string,
 
 // getAutocompleteData retrieves auto-complete data for the "/zoom" command
 func (p *Plugin) getAutocompleteData() *model.AutocompleteData {
-	canConnect := !p.configuration.AccountLevelApp
+	canConnect := !p.configuration.isAccountLevel()
 
-	available := "start, help, subscription, settings, channel-settings"
+	available := "start, schedule, help, subscription, settings, channel-settings, search, webinar, template, delegate"
 	if canConnect {
-		available = "start, connect, disconnect, help, subscription, settings, channel-settings"
+		available = "start, schedule, connect, disconnect, help, subscription, settings, channel-settings, search, webinar, template, delegate"
 	}
 
 	zoom := model.NewAutocompleteData("zoom", "[command]", fmt.Sprintf("Available commands: %s", available))
-	start := model.NewAutocompleteData("start", "[meeting topic]", "Starts a Zoom meeting with a topic (optional)")
+	start := model.NewAutocompleteData("start", "[--template name] [--for @username] [meeting topic]", "Starts a Zoom meeting with a topic, a template and a host (optional)")
 	zoom.AddCommand(start)
+	schedule := model.NewAutocompleteData("schedule", "[--for @username]", "Schedules a Zoom meeting, optionally repeating and for another host")
+	zoom.AddCommand(schedule)
 
 	// no point in showing the 'disconnect' option if OAuth is not enabled
 	if canConnect {
@@ -603,6 +962,52 @@ func (p *Plugin) getAutocompleteData() *model.AutocompleteData {
 	channelSettings.RoleID = model.SystemAdminRoleId
 	zoom.AddCommand(channelSettings)
 
+	webhooks := model.NewAutocompleteData("webhooks", "[action]", "Inspect and replay failed Zoom webhook events")
+	webhooksList := model.NewAutocompleteData("list", "", "List the pending and failed webhook events")
+	webhooksShow := model.NewAutocompleteData("show", "[event id]", "Show a failed webhook event")
+	webhooksReplay := model.NewAutocompleteData("replay", "[event id|all]", "Process failed webhook events again")
+	webhooks.AddCommand(webhooksList)
+	webhooks.AddCommand(webhooksShow)
+	webhooks.AddCommand(webhooksReplay)
+	webhooks.RoleID = model.SystemAdminRoleId
+	zoom.AddCommand(webhooks)
+
+	admin := model.NewAutocompleteData("admin", "[action]", "Manage the users connected to Zoom")
+	adminUsers := model.NewAutocompleteData("users", "[page]", "List the users connected to Zoom")
+	adminDisconnect := model.NewAutocompleteData("disconnect", "[@username|all]", "Disconnect users from Zoom")
+	admin.AddCommand(adminUsers)
+	admin.AddCommand(adminDisconnect)
+	admin.RoleID = model.SystemAdminRoleId
+	zoom.AddCommand(admin)
+
+	search := model.NewAutocompleteData("search", "[terms]", "Search the transcripts of the meetings in your channels")
+	zoom.AddCommand(search)
+
+	webinar := model.NewAutocompleteData("webinar", "[action]", "Create Zoom webinars and list their registrants")
+	webinarCreate := model.NewAutocompleteData("create", "", "Create a Zoom webinar in this channel")
+	webinarRegistrants := model.NewAutocompleteData("registrants", "[webinar id]", "List the registrants of a webinar you host")
+	webinar.AddCommand(webinarCreate)
+	webinar.AddCommand(webinarRegistrants)
+	zoom.AddCommand(webinar)
+
+	template := model.NewAutocompleteData("template", "[action]", "Manage reusable meeting settings")
+	templateList := model.NewAutocompleteData("list", "", "List the meeting templates of this channel")
+	templateCreate := model.NewAutocompleteData("create", "[name]", "Create or edit a meeting template")
+	templateDelete := model.NewAutocompleteData("delete", "[name]", "Delete a meeting template")
+	template.AddCommand(templateList)
+	template.AddCommand(templateCreate)
+	template.AddCommand(templateDelete)
+	zoom.AddCommand(template)
+
+	delegate := model.NewAutocompleteData("delegate", "[action]", "Manage who can schedule meetings for you")
+	delegateAdd := model.NewAutocompleteData("add", "[@username]", "Allow a user to schedule Zoom meetings for you")
+	delegateRemove := model.NewAutocompleteData("remove", "[@username]", "Stop a user from scheduling Zoom meetings for you")
+	delegateList := model.NewAutocompleteData("list", "", "List your scheduling delegates")
+	delegate.AddCommand(delegateAdd)
+	delegate.AddCommand(delegateRemove)
+	delegate.AddCommand(delegateList)
+	zoom.AddCommand(delegate)
+
 	help := model.NewAutocompleteData("help", "", "Display usage")
 	zoom.AddCommand(help)
 
@@ -34,6 +34,15 @@ type configuration struct {
 	OAuthClientSecret string
 	EncryptionKey     string
 
+	// PreviousEncryptionKey is the EncryptionKey replaced while the plugin was stopped. The plugin
+	// only learns the replaced key from configuration changes made while it runs.
+	PreviousEncryptionKey string
+
+	// ServerToServerApp authenticates with a Server-to-Server OAuth app of the account ZoomAccountID,
+	// using OAuthClientID and OAuthClientSecret, so nobody has to connect to Zoom.
+	ServerToServerApp bool
+	ZoomAccountID     string
+
 	// WebhookSecret is generated in the Mattermost system console
 	WebhookSecret string
 
@@ -46,6 +55,37 @@ type configuration struct {
 
 	// EnablePostingRecordingPassword allows the admin to enable posting the recording password to the channel when the recording is posted.
 	EnablePostingRecordingPassword bool
+
+	// ReminderMinutes is the default comma separated list of minutes before a scheduled meeting
+	// starts at which a reminder is posted. Channels can override it with `/zoom channel-settings`.
+	ReminderMinutes string
+
+	// UploadRecordings copies MP4 and M4A cloud recordings into Mattermost file storage and
+	// attaches them to the meeting thread, instead of only linking to them.
+	UploadRecordings bool
+
+	// MaxRecordingUploadSizeMB is the size in megabytes above which recordings are only linked.
+	MaxRecordingUploadSizeMB string
+
+	// RecordingPolicy is the default comma separated list of kind=action pairs choosing what is
+	// posted of a cloud recording. Channels can override it with `/zoom channel-settings`.
+	RecordingPolicy string
+
+	// EnableMeetingSummaries posts a summary, the decisions and the action items of a meeting
+	// to its thread once the transcript is available.
+	EnableMeetingSummaries bool
+
+	// SummarizerURL is the endpoint of an external summarizer. The built-in one is used when empty.
+	SummarizerURL string
+
+	// SummarizerToken is sent to the external summarizer as a bearer token.
+	SummarizerToken string
+
+	// RequireMeetingPasscode, RequireWaitingRoom and RequireAuthenticatedJoin make up the security
+	// policy of meetings created in channels that enforce it, see `/zoom channel-settings`.
+	RequireMeetingPasscode   bool
+	RequireWaitingRoom       bool
+	RequireAuthenticatedJoin bool
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -68,13 +108,44 @@ func (c *configuration) IsValid(isCloud bool) error {
 		return errors.New("please generate EncryptionKey from Zoom plugin settings")
 	}
 
+	if c.ServerToServerApp {
+		switch {
+		case c.AccountLevelApp:
+			return errors.New("please enable only one of AccountLevelApp and ServerToServerApp")
+		case len(c.ZoomAccountID) == 0:
+			return errors.New("please configure ZoomAccountID")
+		}
+	}
+
 	if len(c.WebhookSecret) == 0 {
 		return errors.New("please configure WebhookSecret")
 	}
 
+	if _, err := parseReminderMinutes(c.ReminderMinutes); err != nil {
+		return errors.Wrap(err, "please configure valid ReminderMinutes")
+	}
+
+	if _, err := parseMaxRecordingUploadSize(c.MaxRecordingUploadSizeMB); err != nil {
+		return errors.Wrap(err, "please configure a valid MaxRecordingUploadSizeMB")
+	}
+
+	if _, err := parseRecordingPolicy(c.RecordingPolicy); err != nil {
+		return errors.Wrap(err, "please configure a valid RecordingPolicy")
+	}
+
+	if err := validateSummarizerURL(c.SummarizerURL); err != nil {
+		return errors.Wrap(err, "please configure a valid SummarizerURL")
+	}
+
 	return nil
 }
 
+// isAccountLevel reports whether the plugin acts with an account-wide token, finding users in Zoom
+// by their Mattermost email instead of having each of them connect.
+func (c *configuration) isAccountLevel() bool {
+	return c.AccountLevelApp || c.ServerToServerApp
+}
+
 // getConfiguration retrieves the active configuration under lock, making it safe to use
 // concurrently. The active configuration may change underneath the client of this method, but
 // the struct returned by this API call is considered immutable.
@@ -129,8 +200,19 @@ func (p *Plugin) OnConfigurationChange() error {
 		return errors.Wrap(err, "could not register site URL")
 	}
 
+	previous := p.getConfiguration()
 	p.setConfiguration(configuration)
 
+	// Regenerating the encryption key retires the previous one, which opens the tokens stored with
+	// it until they are sealed with the new one. Changes made while the plugin was stopped are
+	// found on activation, and need the admin to set the previous key.
+	encryptionKeyChanged := previous.EncryptionKey != "" && previous.EncryptionKey != configuration.EncryptionKey
+	if encryptionKeyChanged || previous.PreviousEncryptionKey != configuration.PreviousEncryptionKey {
+		if err := p.syncEncryptionKey(previous.EncryptionKey); err != nil {
+			p.API.LogWarn("could not retire the previous encryption key", "error", err.Error())
+		}
+	}
+
 	// re-register the plugin command here as a configuration update might change the available commands
 	command, err := p.getCommand()
 	if err != nil {
@@ -0,0 +1,506 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	// connectedUsersKey indexes the users connected to Zoom, as the tokens can't be listed without
+	// listing every key of the plugin.
+	connectedUsersKey        = "zoomConnectedUsers"
+	connectedUsersMaxRetries = 5
+	connectedUsersPerPage    = 100
+
+	tokenHealthOK         = "ok"
+	tokenHealthBroken     = "broken"
+	tokenHealthUnreadable = "unreadable"
+
+	adminDisconnectMessage = "A system admin disconnected your Zoom account. [Click here to connect it again.](%s/plugins/zoom/oauth2/connect)"
+)
+
+// connectedUser is an entry of the index of connected users.
+type connectedUser struct {
+	ZoomID      string `json:"zoom_id"`
+	ZoomEmail   string `json:"zoom_email"`
+	ConnectedAt int64  `json:"connected_at,omitempty"`
+}
+
+type connectedUsersIndex struct {
+	Users map[string]connectedUser `json:"users"`
+
+	// Complete is set once the users connected before the index existed were added to it.
+	Complete bool `json:"complete"`
+}
+
+// connectedUserStatus describes the connection of a user to system admins.
+type connectedUserStatus struct {
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	ZoomID      string `json:"zoom_id"`
+	ZoomEmail   string `json:"zoom_email"`
+	ConnectedAt int64  `json:"connected_at,omitempty"`
+	RefreshedAt int64  `json:"refreshed_at,omitempty"`
+	Health      string `json:"health"`
+	BrokenAt    int64  `json:"broken_at,omitempty"`
+}
+
+type connectedUsersResponse struct {
+	Total int                    `json:"total"`
+	Users []*connectedUserStatus `json:"users"`
+}
+
+type adminDisconnectRequest struct {
+	UserID string `json:"user_id"`
+	All    bool   `json:"all"`
+}
+
+type adminDisconnectResponse struct {
+	Disconnected int `json:"disconnected"`
+}
+
+func (p *Plugin) getConnectedUsersIndex() (*connectedUsersIndex, []byte, error) {
+	raw, appErr := p.API.KVGet(connectedUsersKey)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	index := &connectedUsersIndex{}
+	if raw != nil {
+		if err := json.Unmarshal(raw, index); err != nil {
+			return nil, nil, errors.Wrap(err, "corrupted connected users index")
+		}
+	}
+	if index.Users == nil {
+		index.Users = map[string]connectedUser{}
+	}
+	return index, raw, nil
+}
+
+// updateConnectedUsers applies mutate to the index of connected users with a compare-and-set
+// write. mutate returns false to leave the index alone.
+func (p *Plugin) updateConnectedUsers(mutate func(*connectedUsersIndex) bool) error {
+	for i := 0; i < connectedUsersMaxRetries; i++ {
+		index, oldRaw, err := p.getConnectedUsersIndex()
+		if err != nil {
+			return err
+		}
+		if !mutate(index) {
+			return nil
+		}
+
+		newRaw, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(connectedUsersKey, newRaw, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldRaw,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("updateConnectedUsers: too many concurrent updates")
+}
+
+func (p *Plugin) addConnectedUser(info *zoom.OAuthUserInfo) error {
+	return p.updateConnectedUsers(func(index *connectedUsersIndex) bool {
+		index.Users[info.UserID] = connectedUser{
+			ZoomID:      info.ZoomID,
+			ZoomEmail:   info.ZoomEmail,
+			ConnectedAt: model.GetMillis(),
+		}
+		return true
+	})
+}
+
+func (p *Plugin) removeConnectedUsers(userIDs ...string) error {
+	return p.updateConnectedUsers(func(index *connectedUsersIndex) bool {
+		removed := false
+		for _, userID := range userIDs {
+			if _, ok := index.Users[userID]; ok {
+				delete(index.Users, userID)
+				removed = true
+			}
+		}
+		return removed
+	})
+}
+
+// completeConnectedUsers adds the users connected before the index existed to it, listing every
+// key of the plugin once.
+func (p *Plugin) completeConnectedUsers() error {
+	found := map[string]connectedUser{}
+	for page := 0; ; page++ {
+		keys, appErr := p.API.KVList(page, tokenRefreshKeysPerPage)
+		if appErr != nil {
+			return appErr
+		}
+
+		for _, key := range keys {
+			if !strings.HasPrefix(key, zoomUserByMMID) {
+				continue
+			}
+			userID := strings.TrimPrefix(key, zoomUserByMMID)
+			found[userID] = connectedUser{}
+			if info, err := p.fetchOAuthUserInfo(zoomUserByMMID, userID); err == nil {
+				found[userID] = connectedUser{ZoomID: info.ZoomID, ZoomEmail: info.ZoomEmail}
+			}
+		}
+
+		if len(keys) < tokenRefreshKeysPerPage {
+			break
+		}
+	}
+
+	return p.updateConnectedUsers(func(index *connectedUsersIndex) bool {
+		if index.Complete {
+			return false
+		}
+		for userID, user := range found {
+			if _, ok := index.Users[userID]; !ok {
+				index.Users[userID] = user
+			}
+		}
+		index.Complete = true
+		return true
+	})
+}
+
+// getCompleteConnectedUsersIndex returns the index of connected users, completing it first if needed.
+func (p *Plugin) getCompleteConnectedUsersIndex() (*connectedUsersIndex, error) {
+	index, _, err := p.getConnectedUsersIndex()
+	if err != nil || index.Complete {
+		return index, err
+	}
+
+	if err = p.completeConnectedUsers(); err != nil {
+		return nil, errors.Wrap(err, "could not index the connected users")
+	}
+	index, _, err = p.getConnectedUsersIndex()
+	return index, err
+}
+
+// listConnectedUsers returns a page of the users connected to Zoom, ordered by Zoom email, and
+// how many users are connected.
+func (p *Plugin) listConnectedUsers(page, perPage int) ([]*connectedUserStatus, int, error) {
+	index, err := p.getCompleteConnectedUsersIndex()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userIDs := make([]string, 0, len(index.Users))
+	for userID := range index.Users {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool {
+		a, b := index.Users[userIDs[i]], index.Users[userIDs[j]]
+		if a.ZoomEmail != b.ZoomEmail {
+			return a.ZoomEmail < b.ZoomEmail
+		}
+		return userIDs[i] < userIDs[j]
+	})
+
+	total := len(userIDs)
+	start := page * perPage
+	if start >= total {
+		return []*connectedUserStatus{}, total, nil
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	statuses := make([]*connectedUserStatus, 0, end-start)
+	var disconnected []string
+	for _, userID := range userIDs[start:end] {
+		status, err := p.getConnectedUserStatus(userID, index.Users[userID])
+		if err != nil {
+			return nil, 0, err
+		}
+		if status == nil {
+			disconnected = append(disconnected, userID)
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	// Users indexed while they disconnected are dropped from the index.
+	if len(disconnected) > 0 {
+		if err := p.removeConnectedUsers(disconnected...); err != nil {
+			p.API.LogWarn("could not update the connected users index", "error", err.Error())
+		}
+		total -= len(disconnected)
+	}
+
+	return statuses, total, nil
+}
+
+// getConnectedUserStatus returns the status of the connection of a user, or nil when the user
+// isn't connected anymore.
+func (p *Plugin) getConnectedUserStatus(userID string, entry connectedUser) (*connectedUserStatus, error) {
+	encoded, appErr := p.API.KVGet(zoomUserByMMID + userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if encoded == nil {
+		return nil, nil
+	}
+
+	status := &connectedUserStatus{
+		UserID:      userID,
+		ZoomID:      entry.ZoomID,
+		ZoomEmail:   entry.ZoomEmail,
+		ConnectedAt: entry.ConnectedAt,
+		Health:      tokenHealthOK,
+	}
+	if user, appErr := p.API.GetUser(userID); appErr == nil {
+		status.Username = user.Username
+	}
+
+	info, err := p.decodeOAuthUserInfo(encoded)
+	if err != nil {
+		status.Health = tokenHealthUnreadable
+		return status, nil
+	}
+
+	status.ZoomID = info.ZoomID
+	status.ZoomEmail = info.ZoomEmail
+	status.RefreshedAt = info.RefreshedAt
+	if info.BrokenAt != 0 {
+		status.Health = tokenHealthBroken
+		status.BrokenAt = info.BrokenAt
+	}
+	return status, nil
+}
+
+// forceDisconnectUser disconnects a user from Zoom on behalf of a system admin, and tells the user.
+func (p *Plugin) forceDisconnectUser(userID string) error {
+	if err := p.disconnectOAuthUser(userID); err != nil {
+		if removeErr := p.removeConnectedUsers(userID); removeErr != nil {
+			p.API.LogWarn("could not update the connected users index", "user_id", userID, "error", removeErr.Error())
+		}
+		return err
+	}
+
+	if err := p.sendDirectMessage(userID, fmt.Sprintf(adminDisconnectMessage, p.siteURL)); err != nil {
+		p.API.LogWarn("could not tell the user about being disconnected from Zoom", "user_id", userID, "error", err.Error())
+	}
+	return nil
+}
+
+// forceDisconnectAllUsers disconnects every user from Zoom and returns how many were connected.
+func (p *Plugin) forceDisconnectAllUsers() (int, error) {
+	index, err := p.getCompleteConnectedUsersIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	disconnected := 0
+	for userID := range index.Users {
+		if err := p.forceDisconnectUser(userID); err != nil {
+			p.API.LogWarn("could not disconnect the user from Zoom", "user_id", userID, "error", err.Error())
+			continue
+		}
+		disconnected++
+	}
+	return disconnected, nil
+}
+
+func (p *Plugin) handleAdminUsers(w http.ResponseWriter, r *http.Request) {
+	if !p.isSystemAdminRequest(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, err := queryInt(r, "page", 0)
+	if err != nil || page < 0 {
+		http.Error(w, "invalid page", http.StatusBadRequest)
+		return
+	}
+	perPage, err := queryInt(r, "per_page", connectedUsersPerPage)
+	if err != nil || perPage <= 0 || perPage > connectedUsersPerPage {
+		http.Error(w, "invalid per_page", http.StatusBadRequest)
+		return
+	}
+
+	users, total, err := p.listConnectedUsers(page, perPage)
+	if err != nil {
+		p.API.LogWarn("Could not list the connected users", "err", err.Error())
+		http.Error(w, "could not list the connected users", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(connectedUsersResponse{Total: total, Users: users}); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
+
+func (p *Plugin) handleAdminDisconnect(w http.ResponseWriter, r *http.Request) {
+	if !p.isSystemAdminRequest(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req adminDisconnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var response adminDisconnectResponse
+	switch {
+	case req.All && req.UserID == "":
+		disconnected, err := p.forceDisconnectAllUsers()
+		if err != nil {
+			p.API.LogWarn("Could not disconnect the users", "err", err.Error())
+			http.Error(w, "could not disconnect the users", http.StatusInternalServerError)
+			return
+		}
+		response.Disconnected = disconnected
+	case !req.All && req.UserID != "":
+		if err := p.forceDisconnectUser(req.UserID); err != nil {
+			http.Error(w, "could not disconnect the user: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		response.Disconnected = 1
+	default:
+		http.Error(w, "either user_id or all is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
+
+// isSystemAdminRequest reports whether the request comes from a system admin, answering it otherwise.
+func (p *Plugin) isSystemAdminRequest(w http.ResponseWriter, r *http.Request) bool {
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return false
+	}
+	if !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func (p *Plugin) runAdminCommand(params []string, user *model.User) (string, error) {
+	if !p.API.HasPermissionTo(user.Id, model.PermissionManageSystem) {
+		return "Unable to execute the command, only system admins have access to execute this command.", nil
+	}
+
+	switch {
+	case len(params) >= 1 && len(params) <= 2 && params[0] == adminActionUsers:
+		page := 1
+		if len(params) == 2 {
+			var err error
+			if page, err = strconv.Atoi(params[1]); err != nil || page < 1 {
+				return fmt.Sprintf("%s is not a page number.", params[1]), nil
+			}
+		}
+		return p.runAdminUsersCommand(page)
+	case len(params) == 2 && params[0] == adminActionDisconnect:
+		return p.runAdminDisconnectCommand(params[1])
+	default:
+		return strings.ReplaceAll(adminHelpText, "|", "`"), nil
+	}
+}
+
+func (p *Plugin) runAdminUsersCommand(page int) (string, error) {
+	if p.getConfiguration().isAccountLevel() {
+		return "Users don't connect to Zoom when the plugin uses an account level app.", nil
+	}
+
+	users, total, err := p.listConnectedUsers(page-1, connectedUsersPerPage)
+	if err != nil {
+		return "Unable to list the connected users.", errors.Wrap(err, "cannot list connected users")
+	}
+	if total == 0 {
+		return "No users are connected to Zoom.", nil
+	}
+	if len(users) == 0 {
+		return fmt.Sprintf("There are only %d connected user(s).", total), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#### Users connected to Zoom\n%d connected", total))
+	if total > connectedUsersPerPage {
+		sb.WriteString(fmt.Sprintf(", page %d of %d", page, (total+connectedUsersPerPage-1)/connectedUsersPerPage))
+	}
+	sb.WriteString("\n\n| User | Zoom email | Zoom ID | Last refresh | Health |\n| :---- | :---- | :---- | :---- | :---- |")
+	for _, user := range users {
+		username := user.UserID
+		if user.Username != "" {
+			username = "@" + user.Username
+		}
+		refreshed := "never"
+		if user.RefreshedAt != 0 {
+			refreshed = time.UnixMilli(user.RefreshedAt).UTC().Format(time.RFC3339)
+		}
+		health := user.Health
+		if user.BrokenAt != 0 {
+			health += " since " + time.UnixMilli(user.BrokenAt).UTC().Format(time.RFC3339)
+		}
+		sb.WriteString(fmt.Sprintf("\n|%s|%s|%s|%s|%s|", username, user.ZoomEmail, user.ZoomID, refreshed, health))
+	}
+
+	return sb.String(), nil
+}
+
+func (p *Plugin) runAdminDisconnectCommand(target string) (string, error) {
+	if target == "all" {
+		disconnected, err := p.forceDisconnectAllUsers()
+		if err != nil {
+			return "Unable to disconnect the users.", errors.Wrap(err, "cannot disconnect users")
+		}
+		return fmt.Sprintf("%d user(s) disconnected from Zoom.", disconnected), nil
+	}
+
+	user, appErr := p.API.GetUserByUsername(strings.TrimPrefix(target, "@"))
+	if appErr != nil {
+		return fmt.Sprintf("There is no user %s.", target), nil
+	}
+	if err := p.forceDisconnectUser(user.Id); err != nil {
+		return fmt.Sprintf("Could not disconnect @%s from Zoom, %s", user.Username, err.Error()), nil
+	}
+	return fmt.Sprintf("@%s disconnected from Zoom.", user.Username), nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// connectedUsersTestPlugin returns a plugin with users connected before the index existed: alice
+// with a working connection, bob with a broken one and carol with a token sealed with a forgotten
+// key. The index has a stale entry for dave, who disconnected.
+func connectedUsersTestPlugin(t *testing.T) (*Plugin, *memoryKVStore) {
+	p, kv := encryptionTestPlugin(oldEncryptionKey)
+	api := p.API.(*plugintest.API)
+	api.On("KVDelete", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		kv.set(args.String(0), nil, model.PluginKVSetOptions{})
+	}).Return(nil)
+	for _, username := range []string{"alice", "bob", "carol"} {
+		api.On("GetUser", username+"-id").Return(&model.User{Id: username + "-id", Username: username}, nil)
+	}
+
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:      "alice-id",
+		ZoomID:      "alice-zoom-id",
+		ZoomEmail:   "alice@example.com",
+		OAuthToken:  &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"},
+		RefreshedAt: 1700000000000,
+	}))
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:     "bob-id",
+		ZoomID:     "bob-zoom-id",
+		ZoomEmail:  "bob@example.com",
+		OAuthToken: &oauth2.Token{AccessToken: "access-token", RefreshToken: "revoked"},
+		BrokenAt:   1700000000000,
+	}))
+	forgotten, err := seal(newEncryptionKey, []byte("{}"))
+	require.NoError(t, err)
+	kv.values[zoomUserByMMID+"carol-id"] = forgotten
+
+	require.NoError(t, p.updateConnectedUsers(func(index *connectedUsersIndex) bool {
+		index.Users["dave-id"] = connectedUser{ZoomID: "dave-zoom-id", ZoomEmail: "dave@example.com"}
+		return true
+	}))
+	return p, kv
+}
+
+func TestListConnectedUsers(t *testing.T) {
+	p, kv := connectedUsersTestPlugin(t)
+
+	users, total, err := p.listConnectedUsers(0, connectedUsersPerPage)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []*connectedUserStatus{
+		{UserID: "carol-id", Username: "carol", Health: tokenHealthUnreadable},
+		{UserID: "alice-id", Username: "alice", ZoomID: "alice-zoom-id", ZoomEmail: "alice@example.com", RefreshedAt: 1700000000000, Health: tokenHealthOK},
+		{UserID: "bob-id", Username: "bob", ZoomID: "bob-zoom-id", ZoomEmail: "bob@example.com", Health: tokenHealthBroken, BrokenAt: 1700000000000},
+	}, users)
+
+	index, _, err := p.getConnectedUsersIndex()
+	require.NoError(t, err)
+	assert.True(t, index.Complete)
+	assert.NotContains(t, index.Users, "dave-id", "stale entries are dropped")
+
+	// Once complete, the index is read without listing the keys of the plugin.
+	kv.values[zoomUserByMMID+"erin-id"] = kv.values[zoomUserByMMID+"alice-id"]
+	users, total, err = p.listConnectedUsers(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, users, 1)
+	assert.Equal(t, "alice-id", users[0].UserID)
+}
+
+func TestRunAdminUsersCommand(t *testing.T) {
+	p, _ := connectedUsersTestPlugin(t)
+
+	message, err := p.runAdminUsersCommand(1)
+	require.NoError(t, err)
+	assert.Contains(t, message, "3 connected")
+	assert.Contains(t, message, "|@alice|alice@example.com|alice-zoom-id|2023-11-14T22:13:20Z|ok|")
+	assert.Contains(t, message, "|@bob|bob@example.com|bob-zoom-id|never|broken since 2023-11-14T22:13:20Z|")
+	assert.Contains(t, message, "|@carol|||never|unreadable|")
+
+	message, err = p.runAdminUsersCommand(2)
+	require.NoError(t, err)
+	assert.Equal(t, "There are only 3 connected user(s).", message)
+}
+
+func TestHandleAdminDisconnect(t *testing.T) {
+	p, kv := connectedUsersTestPlugin(t)
+	api := p.API.(*plugintest.API)
+	api.On("HasPermissionTo", "admin-id", model.PermissionManageSystem).Return(true)
+	api.On("HasPermissionTo", "user-id", model.PermissionManageSystem).Return(false)
+	api.On("GetDirectChannel", mock.AnythingOfType("string"), "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+	notified := 0
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return strings.Contains(post.Message, "A system admin disconnected your Zoom account")
+	})).Run(func(mock.Arguments) { notified++ }).Return(&model.Post{}, nil)
+
+	disconnect := func(userID string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, pathAdminDisconnect, strings.NewReader(body))
+		r.Header.Set(MattermostUserIDHeader, userID)
+		w := httptest.NewRecorder()
+		p.handleAdminDisconnect(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, disconnect("user-id", `{"all":true}`).Code)
+	assert.Equal(t, http.StatusBadRequest, disconnect("admin-id", `{}`).Code)
+
+	w := disconnect("admin-id", `{"user_id":"alice-id"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, kv.get(zoomUserByMMID+"alice-id"))
+	assert.Nil(t, kv.get(zoomUserByZoomID+"alice-zoom-id"))
+	assert.Equal(t, 1, notified)
+
+	w = disconnect("admin-id", `{"all":true}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response adminDisconnectResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 2, response.Disconnected, "bob and carol, but not dave who disconnected")
+	assert.Nil(t, kv.get(zoomUserByMMID+"bob-id"))
+	assert.Nil(t, kv.get(zoomUserByMMID+"carol-id"))
+
+	index, _, err := p.getConnectedUsersIndex()
+	require.NoError(t, err)
+	assert.Empty(t, index.Users)
+}
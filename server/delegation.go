@@ -0,0 +1,236 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	delegationsKey        = "zoom_delegations"
+	delegationsMaxRetries = 5
+
+	scheduleForFlag = "--for"
+)
+
+// delegations maps the ID of a user to the IDs of the users they allowed to schedule meetings
+// for them.
+type delegations map[string][]string
+
+func (p *Plugin) listDelegations() (delegations, error) {
+	all, _, err := p.getDelegations()
+	return all, err
+}
+
+func (p *Plugin) getDelegations() (delegations, []byte, error) {
+	b, appErr := p.API.KVGet(delegationsKey)
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Message)
+	}
+
+	all := delegations{}
+	if len(b) == 0 {
+		return all, b, nil
+	}
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, nil, err
+	}
+	return all, b, nil
+}
+
+// updateDelegations applies mutate to the delegations with a compare-and-set write, so concurrent
+// grants and revocations don't overwrite each other. mutate returns false to leave them alone.
+func (p *Plugin) updateDelegations(mutate func(delegations) bool) error {
+	for i := 0; i < delegationsMaxRetries; i++ {
+		all, oldB, err := p.getDelegations()
+		if err != nil {
+			return err
+		}
+		if !mutate(all) {
+			return nil
+		}
+
+		newB, err := json.Marshal(all)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(delegationsKey, newB, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldB,
+		})
+		if appErr != nil {
+			return errors.New(appErr.Message)
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("updateDelegations: too many concurrent updates")
+}
+
+// canScheduleFor reports whether the delegate may schedule meetings for the host.
+func (all delegations) canScheduleFor(delegateID, hostID string) bool {
+	for _, id := range all[hostID] {
+		if id == delegateID {
+			return true
+		}
+	}
+	return false
+}
+
+// meetingDelegation is a meeting created by a delegate under the Zoom account of its host.
+type meetingDelegation struct {
+	Host      *model.User
+	HostEmail string
+}
+
+// postProps records the host and the delegate on the meeting post.
+func (d *meetingDelegation) postProps(delegate *model.User) model.StringInterface {
+	return model.StringInterface{
+		"meeting_creator_username":      d.Host.Username,
+		"meeting_scheduled_by_username": delegate.Username,
+	}
+}
+
+// resolveMeetingDelegation checks that a user may create meetings for the user of the --for flag.
+// The returned message tells the user why they can't.
+func (p *Plugin) resolveMeetingDelegation(delegate *model.User, username string) (*meetingDelegation, string, error) {
+	username = strings.TrimPrefix(username, "@")
+	host, appErr := p.API.GetUserByUsername(username)
+	if appErr != nil {
+		return nil, fmt.Sprintf("There is no user @%s.", username), nil
+	}
+	if host.Id == delegate.Id {
+		return nil, "", nil
+	}
+
+	all, err := p.listDelegations()
+	if err != nil {
+		return nil, "Unable to check your scheduling rights.", errors.Wrap(err, "cannot list delegations")
+	}
+	if !all.canScheduleFor(delegate.Id, host.Id) {
+		return nil, fmt.Sprintf("@%s hasn't allowed you to schedule meetings for them. They can run `/zoom delegate add @%s`.", host.Username, delegate.Username), nil
+	}
+
+	hostEmail, err := p.getZoomEmail(host.Id)
+	if err != nil || hostEmail == "" {
+		return nil, fmt.Sprintf("@%s isn't connected to Zoom.", host.Username), nil
+	}
+
+	return &meetingDelegation{Host: host, HostEmail: hostEmail}, "", nil
+}
+
+func (p *Plugin) runDelegateCommand(params []string, user *model.User) (string, error) {
+	switch {
+	case len(params) == 1 && params[0] == delegateActionList:
+		return p.runDelegateListCommand(user)
+	case len(params) == 2 && params[0] == delegateActionAdd:
+		return p.runDelegateAddCommand(user, params[1])
+	case len(params) == 2 && params[0] == delegateActionRemove:
+		return p.runDelegateRemoveCommand(user, params[1])
+	default:
+		return strings.ReplaceAll(delegateHelpText, "|", "`"), nil
+	}
+}
+
+func (p *Plugin) runDelegateAddCommand(user *model.User, username string) (string, error) {
+	delegate, appErr := p.API.GetUserByUsername(strings.TrimPrefix(username, "@"))
+	if appErr != nil {
+		return fmt.Sprintf("There is no user %s.", username), nil
+	}
+	if delegate.Id == user.Id || delegate.IsBot {
+		return "You can only allow other people to schedule meetings for you.", nil
+	}
+
+	added := false
+	err := p.updateDelegations(func(all delegations) bool {
+		added = !all.canScheduleFor(delegate.Id, user.Id)
+		if added {
+			all[user.Id] = append(all[user.Id], delegate.Id)
+		}
+		return added
+	})
+	if err != nil {
+		return "Unable to update your delegates.", errors.Wrap(err, "cannot store delegations")
+	}
+	if !added {
+		return fmt.Sprintf("@%s can already schedule meetings for you.", delegate.Username), nil
+	}
+
+	if err := p.sendDirectMessage(delegate.Id, fmt.Sprintf("@%s allowed you to schedule Zoom meetings for them with `/zoom start --for @%s` and `/zoom schedule --for @%s`.", user.Username, user.Username, user.Username)); err != nil {
+		p.API.LogWarn("Could not notify the delegate", "user_id", delegate.Id, "error", err.Error())
+	}
+
+	return fmt.Sprintf("@%s can now schedule meetings for you. Zoom also requires you to give them the scheduling privilege in your [Zoom settings](%s/profile/setting).", delegate.Username, p.getZoomURL()), nil
+}
+
+func (p *Plugin) runDelegateRemoveCommand(user *model.User, username string) (string, error) {
+	delegate, appErr := p.API.GetUserByUsername(strings.TrimPrefix(username, "@"))
+	if appErr != nil {
+		return fmt.Sprintf("There is no user %s.", username), nil
+	}
+
+	removed := false
+	err := p.updateDelegations(func(all delegations) bool {
+		removed = all.canScheduleFor(delegate.Id, user.Id)
+		if !removed {
+			return false
+		}
+
+		remaining := make([]string, 0, len(all[user.Id]))
+		for _, id := range all[user.Id] {
+			if id != delegate.Id {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(all, user.Id)
+		} else {
+			all[user.Id] = remaining
+		}
+		return true
+	})
+	if err != nil {
+		return "Unable to update your delegates.", errors.Wrap(err, "cannot store delegations")
+	}
+	if !removed {
+		return fmt.Sprintf("@%s can't schedule meetings for you.", delegate.Username), nil
+	}
+	return fmt.Sprintf("@%s can no longer schedule meetings for you.", delegate.Username), nil
+}
+
+func (p *Plugin) runDelegateListCommand(user *model.User) (string, error) {
+	all, err := p.listDelegations()
+	if err != nil {
+		return "Unable to list your delegates.", errors.Wrap(err, "cannot list delegations")
+	}
+
+	var hosts []string
+	for hostID := range all {
+		if all.canScheduleFor(user.Id, hostID) {
+			hosts = append(hosts, hostID)
+		}
+	}
+	sort.Strings(hosts)
+
+	var sb strings.Builder
+	sb.WriteString("#### Scheduling delegates\n")
+	if delegates := p.formatUserMentions(all[user.Id]); delegates != "" {
+		sb.WriteString("Can schedule meetings for you: " + delegates + "\n")
+	} else {
+		sb.WriteString("Nobody can schedule meetings for you.\n")
+	}
+	if len(hosts) > 0 {
+		sb.WriteString("You can schedule meetings for: " + p.formatUserMentions(hosts) + "\n")
+	}
+	return sb.String(), nil
+}
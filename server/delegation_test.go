@@ -0,0 +1,133 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+)
+
+func TestTakeCommandFlag(t *testing.T) {
+	value, rest, err := takeCommandFlag("Budget review --for @manager", scheduleForFlag)
+	require.NoError(t, err)
+	assert.Equal(t, "@manager", value)
+	assert.Equal(t, "Budget review", rest)
+
+	value, rest, err = takeCommandFlag("Budget review", scheduleForFlag)
+	require.NoError(t, err)
+	assert.Empty(t, value)
+	assert.Equal(t, "Budget review", rest)
+
+	_, _, err = takeCommandFlag("Budget review --for", scheduleForFlag)
+	assert.Error(t, err)
+}
+
+func TestDelegateCommands(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	api.On("GetUserByUsername", "assistant").Return(&model.User{Id: "assistant-id", Username: "assistant"}, nil)
+	api.On("GetDirectChannel", "assistant-id", "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+	p := webinarTestPlugin(api)
+	manager := &model.User{Id: "manager-id", Username: "manager"}
+
+	message, err := p.runDelegateCommand([]string{delegateActionAdd, "@assistant"}, manager)
+	require.NoError(t, err)
+	assert.Contains(t, message, "@assistant can now schedule meetings for you.")
+
+	var all delegations
+	require.NoError(t, json.Unmarshal(kv.get(delegationsKey), &all))
+	assert.True(t, all.canScheduleFor("assistant-id", "manager-id"))
+	assert.False(t, all.canScheduleFor("manager-id", "assistant-id"))
+
+	message, err = p.runDelegateCommand([]string{delegateActionRemove, "assistant"}, manager)
+	require.NoError(t, err)
+	assert.Equal(t, "@assistant can no longer schedule meetings for you.", message)
+
+	all = nil
+	require.NoError(t, json.Unmarshal(kv.get(delegationsKey), &all))
+	assert.Empty(t, all)
+}
+
+func TestUpdateDelegationsConcurrently(t *testing.T) {
+	api := &plugintest.API{}
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := webinarTestPlugin(api)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(hostID string) {
+			defer wg.Done()
+			assert.NoError(t, p.updateDelegations(func(all delegations) bool {
+				all[hostID] = append(all[hostID], "assistant-id")
+				return true
+			}))
+		}(fmt.Sprintf("host-%d", i))
+	}
+	wg.Wait()
+
+	all, err := p.listDelegations()
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "no grant is lost")
+}
+
+func TestResolveMeetingDelegation(t *testing.T) {
+	stored, err := json.Marshal(delegations{"manager-id": {"assistant-id"}})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", delegationsKey).Return(stored, nil)
+	api.On("GetUserByUsername", "manager").Return(&model.User{Id: "manager-id", Username: "manager"}, nil)
+	api.On("GetUserByUsername", "assistant").Return(&model.User{Id: "assistant-id", Username: "assistant"}, nil)
+	api.On("GetUser", "manager-id").Return(&model.User{Id: "manager-id", Email: "manager@example.com"}, nil)
+
+	p := webinarTestPlugin(api)
+	config := *testConfig
+	config.AccountLevelApp = true
+	p.setConfiguration(&config)
+	assistant := &model.User{Id: "assistant-id", Username: "assistant"}
+	manager := &model.User{Id: "manager-id", Username: "manager"}
+
+	delegation, message, err := p.resolveMeetingDelegation(assistant, "@manager")
+	require.NoError(t, err)
+	require.Empty(t, message)
+	assert.Equal(t, "manager@example.com", delegation.HostEmail)
+	assert.Equal(t, model.StringInterface{
+		"meeting_creator_username":      "manager",
+		"meeting_scheduled_by_username": "assistant",
+	}, delegation.postProps(assistant))
+
+	meeting := &scheduledMeeting{Topic: "Budget review", Delegation: delegation}
+	assert.Equal(t, "manager@example.com", meeting.createMeetingRequest().ScheduleFor)
+
+	delegation, message, err = p.resolveMeetingDelegation(manager, "assistant")
+	require.NoError(t, err)
+	assert.Nil(t, delegation)
+	assert.Equal(t, "@assistant hasn't allowed you to schedule meetings for them. They can run `/zoom delegate add @manager`.", message)
+}
+
+func TestPostConfirmKeepsDelegatedHost(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("SendEphemeralPost", "assistant-id", mock.MatchedBy(func(post *model.Post) bool {
+		return post.GetProp("meeting_schedule_for") == "manager" && post.GetProp("meeting_template") == "standup"
+	})).Return(nil).Once()
+	p := webinarTestPlugin(api)
+
+	p.postConfirm("https://zoom.us/j/123", "channel-id", "Budget review", "standup", "manager", "assistant-id", "", "creator", zoomProviderName)
+	api.AssertExpectations(t)
+}
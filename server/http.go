@@ -34,6 +34,16 @@ const (
 	pathUpdatePMI            = "/api/v1/updatePMI"
 	pathAskPMI               = "/api/v1/askPMI"
 	pathChannelPreference    = "/api/v1/channel-preference"
+	pathScheduleMeeting      = "/api/v1/schedule-meeting"
+	pathSearchTranscripts    = "/api/v1/transcripts/search"
+	pathCreateWebinar        = "/api/v1/create-webinar"
+	pathWebinarHostLinks     = "/api/v1/webinar-host-links"
+	pathRegisterForMeeting   = "/api/v1/meeting-registration"
+	pathRegistrantAction     = "/api/v1/registrant-action"
+	pathMeetingTemplate      = "/api/v1/meeting-template"
+	pathInvitationAction     = "/api/v1/invitation-action"
+	pathAdminUsers           = "/api/v1/admin/users"
+	pathAdminDisconnect      = "/api/v1/admin/disconnect"
 	yes                      = "Yes"
 	no                       = "No"
 	ask                      = "Ask"
@@ -52,6 +62,17 @@ const (
 	zoomSettingsCommandMessage   = "You can set a default value for this in your user settings via `/zoom settings` command."
 	askForMeetingType            = "Which meeting ID would you like to use for creating this meeting?"
 	WebsocketEventMeetingStarted = "meeting_started"
+
+	channelSettingsFieldReminders     = "reminders"
+	channelSettingsFieldRemindMembers = "remind_members"
+
+	// channelSettingsFieldRecordingPrefix is followed by a recording kind, e.g. recording_video.
+	channelSettingsFieldRecordingPrefix = "recording_"
+	channelSettingsRecordingDefault     = "default"
+	channelSettingsFieldDefaultTemplate = "default_template"
+	channelSettingsFieldSecurityPolicy  = "security_policy"
+	// channelSettingsNoTemplate can't collide with a template name, which starts with a letter or digit.
+	channelSettingsNoTemplate = "_none"
 )
 
 var ZoomChannelPreferences = map[string]string{
@@ -66,6 +87,9 @@ type startMeetingRequest struct {
 	Topic        string `json:"topic"`
 	UsePMI       string `json:"use_pmi"`
 	ConnectionID string `json:"connection_id"`
+	Template     string `json:"template"`
+	// ScheduleFor is the username of the user the meeting is created for by their delegate.
+	ScheduleFor string `json:"schedule_for"`
 }
 
 type ErrorResponse struct {
@@ -132,6 +156,26 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		p.submitFormPMIForMeeting(rw, r)
 	case pathChannelPreference:
 		p.handleChannelPreference(rw, r)
+	case pathScheduleMeeting:
+		p.handleScheduleMeeting(rw, r)
+	case pathSearchTranscripts:
+		p.handleSearchTranscripts(rw, r)
+	case pathCreateWebinar:
+		p.handleCreateWebinar(rw, r)
+	case pathWebinarHostLinks:
+		p.handleWebinarHostLinks(rw, r)
+	case pathRegisterForMeeting:
+		p.handleRegisterForMeeting(rw, r)
+	case pathRegistrantAction:
+		p.handleRegistrantAction(rw, r)
+	case pathMeetingTemplate:
+		p.handleMeetingTemplate(rw, r)
+	case pathInvitationAction:
+		p.handleInvitationAction(rw, r)
+	case pathAdminUsers:
+		p.handleAdminUsers(rw, r)
+	case pathAdminDisconnect:
+		p.handleAdminDisconnect(rw, r)
 	default:
 		http.NotFound(rw, r)
 	}
@@ -265,22 +309,25 @@ func (p *Plugin) startMeeting(action, userID, channelID, rootID string) {
 		meetingID = zoomUser.Pmi
 
 		if meetingID <= 0 {
-			meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, defaultMeetingTopic)
+			meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, channelID, rootID, defaultMeetingTopic, nil, "")
 			if createMeetingErr != nil {
 				p.API.LogWarn("failed to create the meeting", "Error", createMeetingErr.Error())
 				return
 			}
 			p.sendEnableZoomPMISettingMessage(userID, channelID, rootID)
+		} else if message := p.checkPMISecurityPolicy(user, channelID, meetingID); message != "" {
+			p.postEphemeral(userID, channelID, rootID, message)
+			return
 		}
 	} else {
-		meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, defaultMeetingTopic)
+		meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, channelID, rootID, defaultMeetingTopic, nil, "")
 		if createMeetingErr != nil {
 			p.API.LogWarn("failed to create the meeting", "Error", createMeetingErr.Error())
 			return
 		}
 	}
 
-	if postMeetingErr := p.postMeeting(user, meetingID, meetingUUID, channelID, rootID, defaultMeetingTopic, ""); postMeetingErr != nil {
+	if _, postMeetingErr := p.postMeeting(user, meetingID, meetingUUID, channelID, rootID, defaultMeetingTopic, ""); postMeetingErr != nil {
 		p.API.LogWarn("failed to post the meeting", "Error", postMeetingErr.Error())
 		return
 	}
@@ -374,6 +421,21 @@ func (p *Plugin) connectUserToZoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The reconnect links sent when a connection stops working outlive the state.
+	if state == "" {
+		user, appErr := p.API.GetUser(userID)
+		if appErr != nil || !p.canConnect(user) {
+			http.Error(w, "missing stored state", http.StatusNotFound)
+			return
+		}
+
+		var err error
+		if state, err = p.newReconnectState(userID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	cfg := p.getOAuthConfig()
 	urlStr := cfg.AuthCodeURL(state, oauth2.AccessTypeOffline)
 	http.Redirect(w, r, urlStr, http.StatusFound)
@@ -428,6 +490,7 @@ func (p *Plugin) completeUserOAuthToZoom(w http.ResponseWriter, r *http.Request)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		p.clearSuperUserTokenBroken()
 	}
 
 	client := zoom.NewOAuthClient(token, conf, p.siteURL, p.getZoomAPIURL(), p.configuration.AccountLevelApp, p)
@@ -452,10 +515,11 @@ func (p *Plugin) completeUserOAuthToZoom(w http.ResponseWriter, r *http.Request)
 
 	if !p.configuration.AccountLevelApp {
 		info := &zoom.OAuthUserInfo{
-			ZoomEmail:  zoomUser.Email,
-			ZoomID:     zoomUser.ID,
-			UserID:     userID,
-			OAuthToken: token,
+			ZoomEmail:   zoomUser.Email,
+			ZoomID:      zoomUser.ID,
+			UserID:      userID,
+			OAuthToken:  token,
+			RefreshedAt: model.GetMillis(),
 		}
 
 		if err = p.storeOAuthUserInfo(info); err != nil {
@@ -464,13 +528,17 @@ func (p *Plugin) completeUserOAuthToZoom(w http.ResponseWriter, r *http.Request)
 			http.Error(w, msg, http.StatusInternalServerError)
 			return
 		}
+		if err = p.addConnectedUser(info); err != nil {
+			p.API.LogWarn("could not update the connected users index", "user_id", userID, "error", err.Error())
+		}
 	}
 
 	if justConnect {
 		p.postEphemeral(userID, channelID, "", "Successfully connected to Zoom")
 	} else {
 		// Returning error might not be appropriate here as the main logic for this API is to connect users.
-		if _, err := p.handleMeetingCreation(channelID, "", defaultMeetingTopic, "", user, zoomUser); err != nil {
+		template, _ := p.meetingTemplateForStart(channelID, "")
+		if _, err := p.handleMeetingCreation(channelID, "", defaultMeetingTopic, "", template, nil, user, zoomUser); err != nil {
 			p.API.LogWarn("Error in creating meeting", "Error", err.Error())
 		}
 	}
@@ -495,15 +563,20 @@ func (p *Plugin) completeUserOAuthToZoom(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-func (p *Plugin) postMeeting(creator *model.User, meetingID int, meetingUUID string, channelID string, rootID string, topic string, connectionID string) error {
-	meetingURL := p.getMeetingURL(creator, meetingID)
+func (p *Plugin) postMeeting(creator *model.User, meetingID int, meetingUUID string, channelID string, rootID string, topic string, connectionID string) (*model.Post, error) {
+	return p.postMeetingWithProps(creator, meetingID, meetingUUID, channelID, rootID, topic, connectionID, nil)
+}
+
+// postMeetingWithProps posts a started meeting card carrying additional props.
+func (p *Plugin) postMeetingWithProps(creator *model.User, meetingID int, meetingUUID string, channelID string, rootID string, topic string, connectionID string, props model.StringInterface) (*model.Post, error) {
+	meetingURL, alternativeHosts := p.getMeetingJoinDetails(creator, meetingID)
 
 	if topic == "" {
 		topic = defaultMeetingTopic
 	}
 
 	if p.botUserID != creator.Id && !p.API.HasPermissionToChannel(creator.Id, channelID, model.PermissionCreatePost) {
-		return errors.New("this channel is not accessible, you might not have permissions to write in this channel. Contact the administrator of this channel to find out if you have access permissions")
+		return nil, errors.New("this channel is not accessible, you might not have permissions to write in this channel. Contact the administrator of this channel to find out if you have access permissions")
 	}
 
 	slackAttachment := model.SlackAttachment{
@@ -530,10 +603,41 @@ func (p *Plugin) postMeeting(creator *model.User, meetingID int, meetingUUID str
 			"meeting_provider":         zoomProviderName,
 		},
 	}
+	if hosts := p.alternativeHostNames(alternativeHosts); len(hosts) > 0 {
+		post.Props["meeting_alternative_hosts"] = hosts
+		slackAttachment.Text += "\n\nAlternative hosts: " + strings.Join(hosts, ", ")
+	}
+	for key, value := range props {
+		post.Props[key] = value
+	}
+
+	// Only meetings people start ring the other members; the bot posts the ones started in Zoom.
+	var invitees []meetingInvitee
+	if creator.Id != p.botUserID {
+		var err error
+		if invitees, err = p.listMeetingInvitees(channelID, creator); err != nil {
+			p.API.LogWarn("failed to list the members to invite to the meeting", "error", err.Error())
+		}
+	}
+	invitations := &meetingInvitations{
+		ChannelID:  channelID,
+		Topic:      topic,
+		MeetingURL: meetingURL,
+		Creator:    creator.Username,
+		Invitees:   invitees,
+	}
+	if len(invitees) > 0 {
+		post.Props["meeting_invitees"] = invitations.postProp()
+	}
 
 	createdPost, appErr := p.API.CreatePost(post)
 	if appErr != nil {
-		return appErr
+		return nil, appErr
+	}
+
+	if len(invitees) > 0 {
+		invitations.PostID = createdPost.Id
+		p.sendMeetingInvitations(creator, createdPost, invitations)
 	}
 
 	if meetingUUID != "" {
@@ -559,7 +663,7 @@ func (p *Plugin) postMeeting(creator *model.User, meetingID int, meetingUUID str
 		broadcast,
 	)
 
-	return nil
+	return createdPost, nil
 }
 
 func (p *Plugin) askPreferenceForMeeting(userID, channelID, rootID string) {
@@ -631,6 +735,44 @@ func (p *Plugin) getPMISettingData(userID string) (string, error) {
 	return preference, nil
 }
 
+// handleSearchTranscripts returns the transcript segments matching the terms query parameter
+// in the meetings of the channel of the channel_id query parameter.
+func (p *Plugin) handleSearchTranscripts(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	terms := strings.TrimSpace(r.URL.Query().Get("terms"))
+	if terms == "" {
+		http.Error(w, "terms are required", http.StatusBadRequest)
+		return
+	}
+
+	channelID := r.URL.Query().Get("channel_id")
+	if channelID == "" {
+		http.Error(w, "channel_id is required", http.StatusBadRequest)
+		return
+	}
+
+	results, err := p.searchTranscripts(userID, channelID, terms)
+	if errors.Is(err, errTranscriptChannelForbidden) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		p.API.LogWarn("Could not search the transcripts", "err", err.Error())
+		http.Error(w, "could not search the transcripts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
+
 func (p *Plugin) handleStartMeeting(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("Mattermost-User-Id")
 	if userID == "" {
@@ -684,6 +826,28 @@ func (p *Plugin) handleStartMeeting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	template, err := p.meetingTemplateForStart(req.ChannelID, req.Template)
+	if err != nil {
+		if err = json.NewEncoder(w).Encode(ErrorResponse{fmt.Sprintf("There is no meeting template %q in this channel.", req.Template)}); err != nil {
+			p.API.LogWarn("failed to write the response", "error", err.Error())
+		}
+		return
+	}
+
+	var delegation *meetingDelegation
+	if req.ScheduleFor != "" {
+		var message string
+		if delegation, message, err = p.resolveMeetingDelegation(user, req.ScheduleFor); message != "" {
+			if err != nil {
+				p.API.LogWarn("failed to resolve the meeting delegation", "error", err.Error())
+			}
+			if err = json.NewEncoder(w).Encode(ErrorResponse{message}); err != nil {
+				p.API.LogWarn("failed to write the response", "error", err.Error())
+			}
+			return
+		}
+	}
+
 	if r.URL.Query().Get("force") == "" {
 		recentMeeting, recentMeetingLink, creatorName, provider, cpmErr := p.checkPreviousMessages(req.ChannelID)
 		if cpmErr != nil {
@@ -695,7 +859,7 @@ func (p *Plugin) handleStartMeeting(w http.ResponseWriter, r *http.Request) {
 			if err = json.NewEncoder(w).Encode(MeetingURLResponse{MeetingURL: ""}); err != nil {
 				p.API.LogWarn("failed to write the response", "error", err.Error())
 			}
-			p.postConfirm(recentMeetingLink, req.ChannelID, req.Topic, userID, req.RootID, creatorName, provider)
+			p.postConfirm(recentMeetingLink, req.ChannelID, req.Topic, req.Template, req.ScheduleFor, userID, req.RootID, creatorName, provider)
 			return
 		}
 	}
@@ -705,7 +869,7 @@ func (p *Plugin) handleStartMeeting(w http.ResponseWriter, r *http.Request) {
 		topic = defaultMeetingTopic
 	}
 
-	meetingURL, err := p.handleMeetingCreation(req.ChannelID, req.RootID, topic, req.ConnectionID, user, zoomUser)
+	meetingURL, err := p.handleMeetingCreation(req.ChannelID, req.RootID, topic, req.ConnectionID, template, delegation, user, zoomUser)
 	if err != nil {
 		p.API.LogWarn("Error in creating meeting", "Error", err.Error())
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -762,6 +926,77 @@ func (p *Plugin) handleChannelPreference(w http.ResponseWriter, r *http.Request)
 		Preference: fmt.Sprint(submitRequest.Submission["preference"]),
 	}
 
+	reminders, _ := submitRequest.Submission[channelSettingsFieldReminders].(string)
+	if strings.TrimSpace(reminders) == "0" {
+		zoomChannelSettingsMapValue.RemindersDisabled = true
+	} else {
+		minutes, err := parseReminderMinutes(reminders)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(&model.SubmitDialogResponse{
+				Errors: map[string]string{channelSettingsFieldReminders: err.Error()},
+			}); err != nil {
+				p.API.LogWarn("failed to write the response", "error", err.Error())
+			}
+			return
+		}
+		zoomChannelSettingsMapValue.ReminderMinutes = minutes
+	}
+	zoomChannelSettingsMapValue.RemindMembers, _ = submitRequest.Submission[channelSettingsFieldRemindMembers].(bool)
+	zoomChannelSettingsMapValue.EnforceSecurityPolicy, _ = submitRequest.Submission[channelSettingsFieldSecurityPolicy].(bool)
+
+	switch alternativeHosts, _ := submitRequest.Submission[channelSettingsFieldAlternativeHosts].(string); alternativeHosts {
+	case "", alternativeHostsNone:
+	case alternativeHostsChannelAdmins:
+		zoomChannelSettingsMapValue.AlternativeHosts = alternativeHosts
+	case alternativeHostsUsers:
+		users, _ := submitRequest.Submission[channelSettingsFieldAlternativeHostUsers].(string)
+		userIDs, err := p.parseAlternativeHostUsers(users)
+		if err == nil && len(userIDs) == 0 {
+			err = errors.New("enter at least one username")
+		}
+		if err != nil {
+			p.writeDialogResponse(w, &model.SubmitDialogResponse{
+				Errors: map[string]string{channelSettingsFieldAlternativeHostUsers: err.Error()},
+			})
+			return
+		}
+		zoomChannelSettingsMapValue.AlternativeHosts = alternativeHosts
+		zoomChannelSettingsMapValue.AlternativeHostUserIDs = userIDs
+	default:
+		http.Error(w, "invalid alternative hosts", http.StatusBadRequest)
+		return
+	}
+
+	for _, kind := range recordingKinds {
+		action, _ := submitRequest.Submission[channelSettingsFieldRecordingPrefix+string(kind)].(string)
+		if action == "" || action == channelSettingsRecordingDefault {
+			continue
+		}
+		if !isRecordingAction(action) {
+			http.Error(w, "invalid recording policy", http.StatusBadRequest)
+			return
+		}
+		if zoomChannelSettingsMapValue.RecordingPolicy == nil {
+			zoomChannelSettingsMapValue.RecordingPolicy = recordingPolicy{}
+		}
+		zoomChannelSettingsMapValue.RecordingPolicy[kind] = recordingAction(action)
+	}
+
+	if name, _ := submitRequest.Submission[channelSettingsFieldDefaultTemplate].(string); name != "" && name != channelSettingsNoTemplate {
+		templates, err := p.listMeetingTemplates()
+		if err != nil {
+			p.API.LogError("Unable to list the meeting templates", "Error", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, ok := templates.find(submitRequest.ChannelId, name); !ok {
+			http.Error(w, "unknown meeting template", http.StatusBadRequest)
+			return
+		}
+		zoomChannelSettingsMapValue.DefaultTemplate = name
+	}
+
 	if err := zoomChannelSettingsMapValue.IsValid(); err != nil {
 		p.API.LogError("Invalid request body", "Error", err.Error())
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -777,18 +1012,40 @@ func (p *Plugin) handleChannelPreference(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
-func (p *Plugin) createMeetingWithoutPMI(user *model.User, zoomUser *zoom.User, topic string) (int, string, error) {
+// createMeetingWithoutPMI creates an instant meeting, with the settings of the template when one is
+// given, and then of the security policy of the channel. The meeting is hosted by the Zoom user
+// of scheduleFor when it is set.
+func (p *Plugin) createMeetingWithoutPMI(user *model.User, zoomUser *zoom.User, channelID, rootID, topic string, template *meetingTemplate, scheduleFor string) (int, string, error) {
 	client, _, err := p.getActiveClient(user)
 	if err != nil {
 		p.API.LogWarn("Error getting the client", "Error", err.Error())
 		return -1, "", err
 	}
 
-	meeting, err := client.CreateMeeting(zoomUser, topic)
+	request := &zoom.CreateMeetingRequest{
+		Topic:       topic,
+		Type:        zoom.MeetingTypeInstant,
+		ScheduleFor: scheduleFor,
+	}
+	if template != nil {
+		template.apply(request)
+	}
+	if err = p.addAlternativeHosts(zoomUser, channelID, request); err != nil {
+		p.API.LogWarn("Error adding the alternative hosts", "Error", err.Error())
+		return -1, "", err
+	}
+	violations, err := p.enforceSecurityPolicy(channelID, request)
+	if err != nil {
+		p.API.LogWarn("Error enforcing the security policy", "Error", err.Error())
+		return -1, "", err
+	}
+
+	meeting, err := client.CreateMeeting(zoomUser, request)
 	if err != nil {
 		p.API.LogWarn("Error creating the meeting", "Error", err.Error())
 		return -1, "", err
 	}
+	p.postSecurityPolicyNote(user.Id, channelID, rootID, "meeting", violations)
 
 	return meeting.ID, meeting.UUID, nil
 }
@@ -808,22 +1065,32 @@ func (p *Plugin) getMeeting(user *model.User, meetingID int) (*zoom.Meeting, err
 }
 
 func (p *Plugin) getMeetingURL(user *model.User, meetingID int) string {
+	meetingURL, _ := p.getMeetingJoinDetails(user, meetingID)
+	return meetingURL
+}
+
+// getMeetingJoinDetails returns the join URL and the comma separated alternative hosts of a
+// meeting, falling back to the default join URL when Zoom can't be reached.
+func (p *Plugin) getMeetingJoinDetails(user *model.User, meetingID int) (string, string) {
 	defaultURL := fmt.Sprintf("%s/j/%v", p.getZoomURL(), meetingID)
 	client, _, err := p.getActiveClient(user)
 	if err != nil {
 		p.API.LogWarn("could not get the active Zoom client", "error", err.Error())
-		return defaultURL
+		return defaultURL, ""
 	}
 
 	meeting, err := client.GetMeeting(meetingID)
 	if err != nil {
 		p.API.LogDebug("failed to get meeting")
-		return defaultURL
+		return defaultURL, ""
 	}
-	return meeting.JoinURL
+	return meeting.JoinURL, meeting.Settings.AlternativeHosts
 }
 
-func (p *Plugin) postConfirm(meetingLink string, channelID string, topic string, userID string, rootID string, creatorName string, provider string) *model.Post {
+// postConfirm asks a user whether to create a meeting though another one was created recently.
+// The template and the user the meeting is created for, if any, are kept in the props, so the
+// meeting created from the confirmation gets them too.
+func (p *Plugin) postConfirm(meetingLink string, channelID string, topic string, templateName string, scheduleFor string, userID string, rootID string, creatorName string, provider string) *model.Post {
 	message := "There is another recent meeting created on this channel."
 	if provider != zoomProviderName {
 		message = fmt.Sprintf("There is another recent meeting created on this channel with %s.", provider)
@@ -845,6 +1112,12 @@ func (p *Plugin) postConfirm(meetingLink string, channelID string, topic string,
 			"meeting_provider":         provider,
 		},
 	}
+	if templateName != "" {
+		post.Props["meeting_template"] = templateName
+	}
+	if scheduleFor != "" {
+		post.Props["meeting_schedule_for"] = scheduleFor
+	}
 
 	return p.API.SendEphemeralPost(userID, post)
 }
@@ -898,7 +1171,7 @@ func (p *Plugin) checkPreviousMessages(channelID string) (recentMeeting bool, me
 		}
 
 		meetingStatus := getString("meeting_status", post.Props)
-		if meetingStatus == zoom.WebhookStatusEnded {
+		if meetingStatus == zoom.WebhookStatusEnded || meetingStatus == zoom.MeetingStatusScheduled {
 			continue
 		}
 
@@ -1132,7 +1405,9 @@ func (mv ZoomChannelSettingsMapValue) IsValid() error {
 	return nil
 }
 
-func (p *Plugin) handleMeetingCreation(channelID, rootID, topic, connectionID string, user *model.User, zoomUser *zoom.User) (string, error) {
+// handleMeetingCreation creates a meeting and posts it. The meeting is hosted by the Zoom user of
+// the delegation when it is set.
+func (p *Plugin) handleMeetingCreation(channelID, rootID, topic, connectionID string, template *meetingTemplate, delegation *meetingDelegation, user *model.User, zoomUser *zoom.User) (string, error) {
 	var meetingID int
 	var meetingUUID string
 	var createMeetingErr error
@@ -1140,6 +1415,18 @@ func (p *Plugin) handleMeetingCreation(channelID, rootID, topic, connectionID st
 	if err != nil {
 		return "", errors.Wrap(err, "error fetching PMI setting data")
 	}
+	if template != nil {
+		// Templates only apply to new meetings, so they take precedence over the Personal Meeting ID.
+		userPMISettingPref = falseString
+	}
+	scheduleFor := ""
+	var props model.StringInterface
+	if delegation != nil {
+		// Meetings hosted by another user can't use the Personal Meeting ID of the delegate.
+		userPMISettingPref = falseString
+		scheduleFor = delegation.HostEmail
+		props = delegation.postProps(user)
+	}
 
 	switch userPMISettingPref {
 	case "", zoomPMISettingValueAsk:
@@ -1149,20 +1436,23 @@ func (p *Plugin) handleMeetingCreation(channelID, rootID, topic, connectionID st
 		meetingID = zoomUser.Pmi
 
 		if meetingID <= 0 {
-			meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, topic)
+			meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, channelID, rootID, topic, template, "")
 			if createMeetingErr != nil {
 				return "", createMeetingErr
 			}
 			p.sendEnableZoomPMISettingMessage(user.Id, channelID, rootID)
+		} else if message := p.checkPMISecurityPolicy(user, channelID, meetingID); message != "" {
+			p.postEphemeral(user.Id, channelID, rootID, message)
+			return "", nil
 		}
 	default:
-		meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, topic)
+		meetingID, meetingUUID, createMeetingErr = p.createMeetingWithoutPMI(user, zoomUser, channelID, rootID, topic, template, scheduleFor)
 		if createMeetingErr != nil {
 			return "", createMeetingErr
 		}
 	}
 
-	if postMeetingErr := p.postMeeting(user, meetingID, meetingUUID, channelID, rootID, topic, connectionID); postMeetingErr != nil {
+	if _, postMeetingErr := p.postMeetingWithProps(user, meetingID, meetingUUID, channelID, rootID, topic, connectionID, props); postMeetingErr != nil {
 		return "", postMeetingErr
 	}
 
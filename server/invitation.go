@@ -0,0 +1,369 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// meetingInvitationsKey is followed by the ID of the meeting card, as a Personal Meeting ID
+	// can be started in several DMs at once.
+	meetingInvitationsKey        = "meeting_post_invitations_"
+	meetingInvitationsMaxRetries = 5
+
+	meetingPostIDForContext = "meetingPostID"
+
+	// maxInvitees covers every member of a group message.
+	maxInvitees = model.ChannelGroupMaxUsers
+
+	invitationStatusPending  = "pending"
+	invitationStatusJoined   = "joined"
+	invitationStatusDeclined = "declined"
+	invitationStatusMissed   = "missed"
+
+	invitationActionJoin    = "join"
+	invitationActionDecline = "decline"
+
+	WebsocketEventMeetingInvitation = "meeting_invitation"
+)
+
+// meetingInvitee is a member of a DM or GM invited to a meeting started in it.
+type meetingInvitee struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	// PostID is the invitation sent to the invitee by the bot.
+	PostID string `json:"post_id,omitempty"`
+	Status string `json:"status"`
+}
+
+// meetingInvitations tracks the invitations of a meeting card until the meeting ends.
+type meetingInvitations struct {
+	PostID     string           `json:"post_id"`
+	ChannelID  string           `json:"channel_id"`
+	Topic      string           `json:"topic"`
+	MeetingURL string           `json:"meeting_url"`
+	Creator    string           `json:"creator"`
+	Invitees   []meetingInvitee `json:"invitees"`
+}
+
+func meetingInvitationsKVKey(postID string) string {
+	return meetingInvitationsKey + postID
+}
+
+// invitee returns the invitee of userID, or nil if the user wasn't invited.
+func (m *meetingInvitations) invitee(userID string) *meetingInvitee {
+	for i := range m.Invitees {
+		if m.Invitees[i].UserID == userID {
+			return &m.Invitees[i]
+		}
+	}
+	return nil
+}
+
+// postProp is the list of invitees shown on the meeting card, so the poster sees who declined
+// or hasn't answered.
+func (m *meetingInvitations) postProp() []map[string]string {
+	invitees := make([]map[string]string, 0, len(m.Invitees))
+	for _, invitee := range m.Invitees {
+		invitees = append(invitees, map[string]string{"username": invitee.Username, "status": invitee.Status})
+	}
+	return invitees
+}
+
+// listMeetingInvitees returns the members of a DM or GM to invite to a meeting started in it,
+// or nil for any other channel.
+func (p *Plugin) listMeetingInvitees(channelID string, creator *model.User) ([]meetingInvitee, error) {
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if channel.Type != model.ChannelTypeDirect && channel.Type != model.ChannelTypeGroup {
+		return nil, nil
+	}
+
+	members, appErr := p.API.GetChannelMembers(channelID, 0, maxInvitees)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	var invitees []meetingInvitee
+	for _, member := range members {
+		if member.UserId == creator.Id || member.UserId == p.botUserID {
+			continue
+		}
+		user, appErr := p.API.GetUser(member.UserId)
+		if appErr != nil || user.IsBot || user.DeleteAt != 0 {
+			continue
+		}
+		invitees = append(invitees, meetingInvitee{UserID: user.Id, Username: user.Username, Status: invitationStatusPending})
+	}
+	return invitees, nil
+}
+
+// sendMeetingInvitations rings the invitees of a meeting card with a websocket event and a bot
+// DM to join or decline it.
+func (p *Plugin) sendMeetingInvitations(creator *model.User, post *model.Post, invitations *meetingInvitations) {
+	for i := range invitations.Invitees {
+		invitee := &invitations.Invitees[i]
+
+		p.client.Frontend.PublishWebSocketEvent(
+			WebsocketEventMeetingInvitation,
+			map[string]interface{}{
+				"meeting_url":      invitations.MeetingURL,
+				"post_id":          post.Id,
+				"channel_id":       post.ChannelId,
+				"topic":            invitations.Topic,
+				"creator_username": creator.Username,
+			},
+			&model.WebsocketBroadcast{UserId: invitee.UserID},
+		)
+
+		dm, err := p.sendInvitationPost(invitee.UserID, invitations)
+		if err != nil {
+			p.API.LogWarn("Could not send the meeting invitation", "user_id", invitee.UserID, "error", err.Error())
+			continue
+		}
+		invitee.PostID = dm.Id
+	}
+
+	b, err := json.Marshal(invitations)
+	if err != nil {
+		p.API.LogWarn("Could not encode the meeting invitations", "error", err.Error())
+		return
+	}
+	if appErr := p.API.KVSetWithExpiry(meetingInvitationsKVKey(post.Id), b, adHocMeetingChannelTTL); appErr != nil {
+		p.API.LogWarn("Could not store the meeting invitations", "post_id", post.Id, "error", appErr.Error())
+	}
+}
+
+func (p *Plugin) sendInvitationPost(userID string, invitations *meetingInvitations) (*model.Post, error) {
+	channel, appErr := p.API.GetDirectChannel(userID, p.botUserID)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "could not get the direct channel of the invitee")
+	}
+
+	apiEndPoint := fmt.Sprintf("/plugins/%s%s", url.PathEscape(manifest.Id), pathInvitationAction)
+	action := func(id, name, style, invitationAction string) *model.PostAction {
+		return &model.PostAction{
+			Id:    id,
+			Name:  name,
+			Type:  model.PostActionTypeButton,
+			Style: style,
+			Integration: &model.PostActionIntegration{
+				URL: apiEndPoint,
+				Context: map[string]interface{}{
+					actionForContext:        invitationAction,
+					meetingPostIDForContext: invitations.PostID,
+				},
+			},
+		}
+	}
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		UserId:    p.botUserID,
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{{
+		Title:     invitations.Topic,
+		TitleLink: invitations.MeetingURL,
+		Text:      fmt.Sprintf("@%s is inviting you to a Zoom meeting in [this conversation](%s).", invitations.Creator, p.permalink(invitations.PostID)),
+		Actions: []*model.PostAction{
+			action("join", "Join", "primary", invitationActionJoin),
+			action("decline", "Decline", "danger", invitationActionDecline),
+		},
+	}})
+	created, appErr := p.API.CreatePost(post)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return created, nil
+}
+
+// updateMeetingInvitations applies mutate to the invitations of a meeting card with a
+// compare-and-set write, so concurrent answers of the invitees aren't lost. It returns the
+// updated invitations, or nil when the card has none.
+func (p *Plugin) updateMeetingInvitations(postID string, mutate func(*meetingInvitations) error) (*meetingInvitations, error) {
+	key := meetingInvitationsKVKey(postID)
+
+	for i := 0; i < meetingInvitationsMaxRetries; i++ {
+		oldRaw, appErr := p.API.KVGet(key)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if oldRaw == nil {
+			return nil, nil
+		}
+
+		var invitations meetingInvitations
+		if err := json.Unmarshal(oldRaw, &invitations); err != nil {
+			return nil, errors.Wrap(err, "corrupted meeting invitations")
+		}
+		if err := mutate(&invitations); err != nil {
+			return nil, err
+		}
+
+		newRaw, err := json.Marshal(invitations)
+		if err != nil {
+			return nil, err
+		}
+		ok, appErr := p.API.KVSetWithOptions(key, newRaw, model.PluginKVSetOptions{
+			Atomic:          true,
+			OldValue:        oldRaw,
+			ExpireInSeconds: adHocMeetingChannelTTL,
+		})
+		if appErr != nil {
+			return nil, appErr
+		}
+		if ok {
+			return &invitations, nil
+		}
+	}
+
+	return nil, errors.New("updateMeetingInvitations: too many concurrent updates")
+}
+
+// updateInvitationsPost shows the answers of the invitees on the meeting card.
+func (p *Plugin) updateInvitationsPost(invitations *meetingInvitations) {
+	post, appErr := p.API.GetPost(invitations.PostID)
+	if appErr != nil {
+		p.API.LogWarn("Could not get the meeting post", "post_id", invitations.PostID, "error", appErr.Error())
+		return
+	}
+	post.AddProp("meeting_invitees", invitations.postProp())
+	if _, appErr := p.API.UpdatePost(post); appErr != nil {
+		p.API.LogWarn("Could not update the meeting post", "post_id", post.Id, "error", appErr.Error())
+	}
+}
+
+// handleInvitationAction records the answer of an invitee from the buttons of the invitation.
+func (p *Plugin) handleInvitationAction(w http.ResponseWriter, r *http.Request) {
+	var request model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" || userID != request.UserId {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	action, _ := request.Context[actionForContext].(string)
+	meetingPostID, _ := request.Context[meetingPostIDForContext].(string)
+	if !model.IsValidId(meetingPostID) || (action != invitationActionJoin && action != invitationActionDecline) {
+		http.Error(w, "invalid request context", http.StatusBadRequest)
+		return
+	}
+
+	status := invitationStatusJoined
+	if action == invitationActionDecline {
+		status = invitationStatusDeclined
+	}
+
+	errNotInvited := errors.New("not invited")
+	invitations, err := p.updateMeetingInvitations(meetingPostID, func(invitations *meetingInvitations) error {
+		invitee := invitations.invitee(userID)
+		if invitee == nil {
+			return errNotInvited
+		}
+		invitee.Status = status
+		return nil
+	})
+	if err == errNotInvited {
+		p.writePostActionResponse(w, &model.PostActionIntegrationResponse{EphemeralText: "You weren't invited to this meeting."})
+		return
+	}
+	if err != nil {
+		p.API.LogWarn("Could not record the invitation answer", "post_id", meetingPostID, "error", err.Error())
+		p.writePostActionResponse(w, &model.PostActionIntegrationResponse{EphemeralText: "Unable to record your answer. Please try again."})
+		return
+	}
+	if invitations == nil {
+		p.writePostActionResponse(w, &model.PostActionIntegrationResponse{
+			Update:        p.answeredInvitationPost(request.PostId, request.ChannelId, "This meeting has ended.", ""),
+			EphemeralText: "This meeting has ended.",
+		})
+		return
+	}
+
+	p.updateInvitationsPost(invitations)
+
+	text := "You declined the meeting."
+	if status == invitationStatusJoined {
+		text = fmt.Sprintf("You joined the meeting. [Open it in Zoom](%s) if it didn't open.", invitations.MeetingURL)
+		p.client.Frontend.PublishWebSocketEvent(
+			WebsocketEventMeetingStarted,
+			map[string]interface{}{"meeting_url": invitations.MeetingURL},
+			&model.WebsocketBroadcast{UserId: userID},
+		)
+	}
+	p.writePostActionResponse(w, &model.PostActionIntegrationResponse{
+		Update: p.answeredInvitationPost(request.PostId, request.ChannelId, text, invitations.Topic),
+	})
+}
+
+// answeredInvitationPost replaces an invitation with the outcome, without the buttons.
+func (p *Plugin) answeredInvitationPost(postID, channelID, text, topic string) *model.Post {
+	post := &model.Post{
+		Id:        postID,
+		ChannelId: channelID,
+		UserId:    p.botUserID,
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{{Title: topic, Text: text}})
+	return post
+}
+
+// expireMeetingInvitations marks the unanswered invitations of the card of an ended meeting as
+// missed.
+func (p *Plugin) expireMeetingInvitations(postID string) {
+	var expired []meetingInvitee
+	invitations, err := p.updateMeetingInvitations(postID, func(invitations *meetingInvitations) error {
+		expired = nil
+		for i := range invitations.Invitees {
+			if invitations.Invitees[i].Status == invitationStatusPending {
+				invitations.Invitees[i].Status = invitationStatusMissed
+				expired = append(expired, invitations.Invitees[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		p.API.LogWarn("Could not expire the meeting invitations", "post_id", postID, "error", err.Error())
+		return
+	}
+	if invitations == nil {
+		return
+	}
+
+	for _, invitee := range expired {
+		if invitee.PostID == "" {
+			continue
+		}
+		dm, appErr := p.API.GetPost(invitee.PostID)
+		if appErr != nil {
+			p.API.LogWarn("Could not get the meeting invitation", "post_id", invitee.PostID, "error", appErr.Error())
+			continue
+		}
+		update := p.answeredInvitationPost(dm.Id, dm.ChannelId, fmt.Sprintf("You missed a Zoom meeting with @%s.", invitations.Creator), invitations.Topic)
+		dm.Props = update.Props
+		if _, appErr := p.API.UpdatePost(dm); appErr != nil {
+			p.API.LogWarn("Could not update the meeting invitation", "post_id", dm.Id, "error", appErr.Error())
+		}
+	}
+
+	if len(expired) > 0 {
+		p.updateInvitationsPost(invitations)
+	}
+	if appErr := p.API.KVDelete(meetingInvitationsKVKey(postID)); appErr != nil {
+		p.API.LogWarn("Could not delete the meeting invitations", "post_id", postID, "error", appErr.Error())
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+)
+
+func TestListMeetingInvitees(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("GetChannel", "gm-id").Return(&model.Channel{Id: "gm-id", Type: model.ChannelTypeGroup}, nil)
+	api.On("GetChannel", "channel-id").Return(&model.Channel{Id: "channel-id", Type: model.ChannelTypeOpen}, nil)
+	api.On("GetChannelMembers", "gm-id", 0, maxInvitees).Return(model.ChannelMembers{
+		{UserId: "creator-id"}, {UserId: "alice-id"}, {UserId: "robot-id"}, {UserId: "bob-id"},
+	}, nil)
+	api.On("GetUser", "alice-id").Return(&model.User{Id: "alice-id", Username: "alice"}, nil)
+	api.On("GetUser", "robot-id").Return(&model.User{Id: "robot-id", Username: "robot", IsBot: true}, nil)
+	api.On("GetUser", "bob-id").Return(&model.User{Id: "bob-id", Username: "bob"}, nil)
+	p := webinarTestPlugin(api)
+	creator := &model.User{Id: "creator-id", Username: "creator"}
+
+	invitees, err := p.listMeetingInvitees("gm-id", creator)
+	require.NoError(t, err)
+	assert.Equal(t, []meetingInvitee{
+		{UserID: "alice-id", Username: "alice", Status: invitationStatusPending},
+		{UserID: "bob-id", Username: "bob", Status: invitationStatusPending},
+	}, invitees)
+
+	invitees, err = p.listMeetingInvitees("channel-id", creator)
+	require.NoError(t, err)
+	assert.Nil(t, invitees)
+}
+
+func TestHandleInvitationAction(t *testing.T) {
+	meetingPostID := model.NewId()
+	stored, err := json.Marshal(meetingInvitations{
+		PostID:     meetingPostID,
+		MeetingURL: "https://zoom.us/j/123",
+		Topic:      "Standup",
+		Creator:    "creator",
+		Invitees: []meetingInvitee{
+			{UserID: "alice-id", Username: "alice", PostID: "dm-post-id", Status: invitationStatusPending},
+			{UserID: "bob-id", Username: "bob", Status: invitationStatusPending},
+		},
+	})
+	require.NoError(t, err)
+
+	var updated *model.Post
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("GetLicense").Return(nil)
+	api.On("KVGet", meetingInvitationsKVKey(meetingPostID)).Return(func(string) []byte { return stored }, nil)
+	api.On("KVSetWithOptions", meetingInvitationsKVKey(meetingPostID), mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]byte)
+	}).Return(true, nil)
+	api.On("GetPost", meetingPostID).Return(&model.Post{Id: meetingPostID, Props: model.StringInterface{}}, nil)
+	api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		updated = args.Get(0).(*model.Post)
+	}).Return(&model.Post{}, nil)
+	p := webinarTestPlugin(api)
+
+	send := func(userID, action string) model.PostActionIntegrationResponse {
+		body, err := json.Marshal(model.PostActionIntegrationRequest{
+			UserId:    userID,
+			PostId:    "dm-post-id",
+			ChannelId: "dm-id",
+			Context:   map[string]any{actionForContext: action, meetingPostIDForContext: meetingPostID},
+		})
+		require.NoError(t, err)
+
+		request := httptest.NewRequest(http.MethodPost, pathInvitationAction, bytes.NewReader(body))
+		request.Header.Set(MattermostUserIDHeader, userID)
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, request)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response model.PostActionIntegrationResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		return response
+	}
+
+	response := send("alice-id", invitationActionDecline)
+	require.NotNil(t, response.Update)
+	assert.Equal(t, "dm-post-id", response.Update.Id)
+	assert.Equal(t, []map[string]string{
+		{"username": "alice", "status": invitationStatusDeclined},
+		{"username": "bob", "status": invitationStatusPending},
+	}, updated.GetProp("meeting_invitees"))
+
+	response = send("carol-id", invitationActionJoin)
+	assert.Equal(t, "You weren't invited to this meeting.", response.EphemeralText)
+	assert.Nil(t, response.Update)
+
+	var invitations meetingInvitations
+	require.NoError(t, json.Unmarshal(stored, &invitations))
+	assert.Equal(t, invitationStatusDeclined, invitations.invitee("alice-id").Status)
+	assert.Nil(t, invitations.invitee("carol-id"))
+}
+
+func TestExpireMeetingInvitations(t *testing.T) {
+	stored, err := json.Marshal(meetingInvitations{
+		PostID:  "meeting-post-id",
+		Creator: "creator",
+		Invitees: []meetingInvitee{
+			{UserID: "alice-id", Username: "alice", PostID: "alice-dm-id", Status: invitationStatusJoined},
+			{UserID: "bob-id", Username: "bob", PostID: "bob-dm-id", Status: invitationStatusPending},
+		},
+	})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", meetingInvitationsKVKey("meeting-post-id")).Return(stored, nil)
+	api.On("KVSetWithOptions", meetingInvitationsKVKey("meeting-post-id"), mock.Anything, mock.Anything).Return(true, nil)
+	api.On("KVDelete", meetingInvitationsKVKey("meeting-post-id")).Return(nil)
+	api.On("GetPost", "bob-dm-id").Return(&model.Post{Id: "bob-dm-id", ChannelId: "bob-dm-channel-id"}, nil)
+	api.On("GetPost", "meeting-post-id").Return(&model.Post{Id: "meeting-post-id", Props: model.StringInterface{}}, nil)
+	api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+		attachments := post.Attachments()
+		return post.Id == "bob-dm-id" && len(attachments) == 1 && len(attachments[0].Actions) == 0
+	})).Return(&model.Post{}, nil).Once()
+	api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+		invitees, ok := post.GetProp("meeting_invitees").([]map[string]string)
+		return post.Id == "meeting-post-id" && ok && invitees[1]["status"] == invitationStatusMissed
+	})).Return(&model.Post{}, nil).Once()
+	p := webinarTestPlugin(api)
+
+	p.expireMeetingInvitations("meeting-post-id")
+
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "GetPost", "alice-dm-id")
+}
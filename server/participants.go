@@ -0,0 +1,169 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	meetingParticipantsProp     = "meeting_participants"
+	meetingParticipantCountProp = "meeting_participant_count"
+
+	participantsMutexKeyPrefix = "meeting_participants_"
+)
+
+// meetingParticipant is someone currently in the meeting, as listed on the meeting post.
+// UserID and Username are set when the participant's Zoom email matches a Mattermost user.
+type meetingParticipant struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	UserID   string `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	JoinTime int64  `json:"join_time"`
+}
+
+// participantID identifies a participant across their joined and left events.
+func participantID(participant zoom.WebhookParticipant) string {
+	switch {
+	case participant.ParticipantUUID != "":
+		return participant.ParticipantUUID
+	case participant.UserID != "":
+		return participant.UserID
+	default:
+		return participant.ID
+	}
+}
+
+// handleParticipantEvent keeps the live participant list on the meeting posts up to date.
+// Updates are serialized per meeting with a cluster mutex, so concurrent joins aren't lost.
+func (p *Plugin) handleParticipantEvent(w http.ResponseWriter, _ *http.Request, body []byte) {
+	var webhook zoom.ParticipantWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		p.API.LogError("Error unmarshaling participant webhook", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	meetingID, err := strconv.Atoi(webhook.Payload.Object.ID)
+	if err != nil {
+		p.API.LogError("Failed to get meeting ID", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	participant := webhook.Payload.Object.Participant
+	id := participantID(participant)
+	if id == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	mutex, err := cluster.NewMutex(p.API, participantsMutexKeyPrefix+webhook.Payload.Object.ID)
+	if err != nil {
+		p.API.LogError("Failed to create the participants mutex", "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Zoom sends participant events for every meeting on the account, most of which
+	// were never posted to Mattermost.
+	posts, err := p.resolveMeetingPosts(webhook.Payload.Object.UUID, meetingID, true)
+	if err != nil {
+		p.API.LogDebug("No meeting post for participant event", "meeting_id", meetingID, "error", err.Error())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var joined *meetingParticipant
+	if webhook.Event == zoom.EventTypeParticipantJoined {
+		joined = p.newMeetingParticipant(id, participant)
+	}
+
+	for _, post := range posts {
+		// Zoom sends participant_left events after the meeting ended, which leaves nobody to list.
+		if post.Props["meeting_status"] == zoom.WebhookStatusEnded {
+			continue
+		}
+
+		participants := removeMeetingParticipant(meetingParticipantsFromPost(post), id)
+		if joined != nil {
+			participants = append(participants, *joined)
+		}
+
+		post.Props[meetingParticipantsProp] = participants
+		post.Props[meetingParticipantCountProp] = len(participants)
+		if err := p.client.Post.UpdatePost(post); err != nil {
+			p.API.LogWarn("Could not update the meeting participants", "post_id", post.Id, "err", err.Error())
+			http.Error(w, "failed to update the meeting post", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// newMeetingParticipant maps a Zoom participant to a Mattermost user by email where possible.
+func (p *Plugin) newMeetingParticipant(id string, participant zoom.WebhookParticipant) *meetingParticipant {
+	joined := &meetingParticipant{
+		ID:       id,
+		Name:     participant.UserName,
+		JoinTime: participant.JoinTime.UnixMilli(),
+	}
+	if participant.JoinTime.IsZero() {
+		joined.JoinTime = model.GetMillis()
+	}
+
+	if participant.Email == "" {
+		return joined
+	}
+
+	user, appErr := p.API.GetUserByEmail(participant.Email)
+	if appErr != nil || user.DeleteAt != 0 {
+		return joined
+	}
+
+	joined.UserID = user.Id
+	joined.Username = user.Username
+	return joined
+}
+
+// meetingParticipantsFromPost reads the participant list back from the post props, where it
+// is stored as generic JSON once the post has been saved.
+func meetingParticipantsFromPost(post *model.Post) []meetingParticipant {
+	raw, ok := post.Props[meetingParticipantsProp]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	var participants []meetingParticipant
+	if err := json.Unmarshal(data, &participants); err != nil {
+		return nil
+	}
+	return participants
+}
+
+func removeMeetingParticipant(participants []meetingParticipant, id string) []meetingParticipant {
+	remaining := make([]meetingParticipant, 0, len(participants))
+	for _, participant := range participants {
+		if participant.ID != id {
+			remaining = append(remaining, participant)
+		}
+	}
+	return remaining
+}
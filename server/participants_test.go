@@ -0,0 +1,129 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestHandleParticipantEvents(t *testing.T) {
+	setupAPI := func(post *model.Post) *plugintest.API {
+		api := &plugintest.API{}
+		api.On("GetLicense").Return(nil)
+		api.On("KVSetWithOptions", "mutex_meeting_participants_123", mock.Anything, mock.Anything).Return(true, nil)
+		api.On("KVGet", "meeting_channel_123").Return(nil, (*model.AppError)(nil))
+		api.On("KVGet", "post_meeting_abc").Return([]byte("post-id"), nil)
+		api.On("GetPost", "post-id").Return(post, nil)
+		allowFlexibleLogging(api)
+		return api
+	}
+
+	t.Run("joined participant is added and mapped by email", func(t *testing.T) {
+		post := &model.Post{Id: "post-id", Props: model.StringInterface{
+			"meeting_status": zoom.WebhookStatusStarted,
+			meetingParticipantsProp: []any{
+				map[string]any{"id": "guest-uuid", "name": "Guest", "join_time": float64(1)},
+			},
+		}}
+		api := setupAPI(post)
+		api.On("GetUserByEmail", "alice@example.com").Return(&model.User{Id: "alice-id", Username: "alice"}, nil)
+
+		var updated *model.Post
+		api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+			updated = args.Get(0).(*model.Post).Clone()
+		}).Return(&model.Post{}, nil).Once()
+
+		p := Plugin{}
+		p.setConfiguration(testConfig)
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"event":"meeting.participant_joined","payload":{"object":{"id":"123","uuid":"abc","participant":{"participant_uuid":"alice-uuid","user_name":"Alice","email":"alice@example.com","join_time":"2026-03-04T14:01:00Z"}}}}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.NotNil(t, updated)
+
+		assert.Equal(t, 2, updated.Props[meetingParticipantCountProp])
+		assert.Equal(t, []meetingParticipant{
+			{ID: "guest-uuid", Name: "Guest", JoinTime: 1},
+			{ID: "alice-uuid", Name: "Alice", UserID: "alice-id", Username: "alice", JoinTime: 1772632860000},
+		}, updated.Props[meetingParticipantsProp])
+	})
+
+	t.Run("left participant is removed", func(t *testing.T) {
+		post := &model.Post{Id: "post-id", Props: model.StringInterface{
+			"meeting_status": zoom.WebhookStatusStarted,
+			meetingParticipantsProp: []any{
+				map[string]any{"id": "guest-uuid", "name": "Guest", "join_time": float64(1)},
+			},
+		}}
+		api := setupAPI(post)
+		api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+			participants, ok := post.Props[meetingParticipantsProp].([]meetingParticipant)
+			return ok && len(participants) == 0 && post.Props[meetingParticipantCountProp] == 0
+		})).Return(&model.Post{}, nil).Once()
+
+		p := Plugin{}
+		p.setConfiguration(testConfig)
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"event":"meeting.participant_left","payload":{"object":{"id":"123","uuid":"abc","participant":{"participant_uuid":"guest-uuid","user_name":"Guest"}}}}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("events after the meeting ended are ignored", func(t *testing.T) {
+		api := setupAPI(&model.Post{Id: "post-id", Props: model.StringInterface{"meeting_status": zoom.WebhookStatusEnded}})
+
+		p := Plugin{}
+		p.setConfiguration(testConfig)
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"event":"meeting.participant_joined","payload":{"object":{"id":"123","uuid":"abc","participant":{"participant_uuid":"alice-uuid"}}}}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		api.AssertNotCalled(t, "UpdatePost", mock.Anything)
+	})
+
+	t.Run("meeting without a post is ignored", func(t *testing.T) {
+		api := &plugintest.API{}
+		api.On("GetLicense").Return(nil)
+		api.On("KVSetWithOptions", "mutex_meeting_participants_123", mock.Anything, mock.Anything).Return(true, nil)
+		api.On("KVGet", "meeting_channel_123").Return(nil, (*model.AppError)(nil))
+		api.On("KVGet", "post_meeting_abc").Return(nil, (*model.AppError)(nil))
+		allowFlexibleLogging(api)
+
+		p := Plugin{}
+		p.setConfiguration(testConfig)
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"event":"meeting.participant_joined","payload":{"object":{"id":"123","uuid":"abc","participant":{"participant_uuid":"alice-uuid"}}}}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		api.AssertNotCalled(t, "UpdatePost", mock.Anything)
+	})
+}
+
+func TestMeetingParticipantsFromPost(t *testing.T) {
+	stored, err := json.Marshal([]meetingParticipant{{ID: "a", Name: "A", JoinTime: 5}})
+	require.NoError(t, err)
+
+	var props any
+	require.NoError(t, json.Unmarshal(stored, &props))
+
+	assert.Equal(t, []meetingParticipant{{ID: "a", Name: "A", JoinTime: 5}}, meetingParticipantsFromPost(&model.Post{Props: model.StringInterface{meetingParticipantsProp: props}}))
+	assert.Empty(t, meetingParticipantsFromPost(&model.Post{Props: model.StringInterface{}}))
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 
 	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
 )
@@ -58,6 +60,15 @@ type Plugin struct {
 	// downloadClient is the HTTP client used for downloading files from Zoom.
 	// Initialized in OnActivate; tests may override it before exercising handlers.
 	downloadClient *http.Client
+
+	// jobScheduler runs one-off jobs such as meeting reminders. Initialized in OnActivate.
+	jobScheduler jobScheduler
+
+	// webhookQueue processes Zoom webhooks after they have been acknowledged. Initialized in OnActivate.
+	webhookQueue webhookQueuer
+
+	// accountTokens caches the token of the Server-to-Server OAuth app.
+	accountTokens accountTokenCache
 }
 
 // OnActivate checks if the configurations is valid and ensures the bot account exists
@@ -108,10 +119,51 @@ func (p *Plugin) OnActivate() error {
 		return errors.Wrap(appErr, "couldn't set profile image")
 	}
 
+	// The job scheduler is a process-wide singleton; tests may provide their own before activating.
+	if p.jobScheduler == nil {
+		scheduler := cluster.GetJobOnceScheduler(p.API)
+		if err := scheduler.SetCallback(p.runScheduledJob); err != nil {
+			return errors.Wrap(err, "couldn't set the job scheduler callback")
+		}
+		if err := scheduler.Start(); err != nil {
+			return errors.Wrap(err, "couldn't start the job scheduler")
+		}
+		p.jobScheduler = scheduler
+	}
+	p.scheduleTokenRefresh(time.Now())
+	if err := p.syncEncryptionKey(""); err != nil {
+		p.API.LogWarn("could not retire the previous encryption key", "error", err.Error())
+	}
+	p.scheduleTokenEncryptionMigration()
+
+	if p.webhookQueue == nil {
+		queue := newWebhookQueue(p)
+		queue.Start()
+		p.webhookQueue = queue
+	}
+
 	return nil
 }
 
+// runScheduledJob dispatches jobs fired by the job scheduler by their key.
+func (p *Plugin) runScheduledJob(key string, props any) {
+	switch {
+	case strings.HasPrefix(key, reminderJobKeyPrefix):
+		p.runReminderJob(props)
+	case strings.HasPrefix(key, tokenRefreshJobKeyPrefix):
+		p.runTokenRefreshJob()
+	case strings.HasPrefix(key, tokenEncryptionJobKeyPrefix):
+		p.runTokenEncryptionMigration()
+	default:
+		p.API.LogWarn("unknown scheduled job", "key", key)
+	}
+}
+
 func (p *Plugin) OnDeactivate() error {
+	if p.webhookQueue != nil {
+		p.webhookQueue.Stop()
+		p.webhookQueue = nil
+	}
 	return nil
 }
 
@@ -130,10 +182,19 @@ func (p *Plugin) registerSiteURL() error {
 func (p *Plugin) getActiveClient(user *model.User) (zoom.Client, string, error) {
 	config := p.getConfiguration()
 
+	// Server-to-Server OAuth
+	if config.ServerToServerApp {
+		token, err := p.getAccountToken()
+		if err != nil {
+			return nil, "Unable to authenticate with Zoom. Contact your System administrator.", err
+		}
+		return zoom.NewOAuthClient(token, p.getOAuthConfig(), p.siteURL, p.getZoomAPIURL(), true, p), "", nil
+	}
+
 	// OAuth Account Level
 	if config.AccountLevelApp {
 		message := "Zoom App not connected. Contact your System administrator."
-		token, err := p.getSuperuserToken()
+		token, err := p.getFreshSuperUserToken()
 		if user.IsSystemAdmin() {
 			message = fmt.Sprintf(zoom.OAuthPrompt, p.siteURL)
 		}
@@ -148,7 +209,10 @@ func (p *Plugin) getActiveClient(user *model.User) (zoom.Client, string, error)
 
 	// Oauth User Level
 	message := fmt.Sprintf(zoom.OAuthPrompt, p.siteURL)
-	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, user.Id)
+	info, err := p.getFreshOAuthUserInfo(user.Id)
+	if errors.Is(err, errConnectionBroken) {
+		return nil, "Your Zoom connection stopped working. " + message, err
+	}
 	if err != nil {
 		return nil, message, errors.Wrap(err, "could not fetch Zoom OAuth info")
 	}
@@ -201,48 +265,9 @@ func (p *Plugin) sendDirectMessage(userID string, message string) error {
 		UserId:    p.botUserID,
 	}
 
-	_, err = p.API.CreatePost(post)
-	return err
-}
-
-func (p *Plugin) GetZoomSuperUserToken() (*oauth2.Token, error) {
-	token, err := p.getSuperuserToken()
-	if err != nil {
-		return nil, errors.Wrap(err, "could not get token")
-	}
-	if token == nil {
-		return nil, errors.New("the Zoom app is not connected")
-	}
-	return token, nil
-}
-
-func (p *Plugin) SetZoomSuperUserToken(token *oauth2.Token) error {
-	err := p.setSuperUserToken(token)
-	if err != nil {
-		return errors.Wrap(err, "could not set token")
-	}
-	return nil
-}
-
-func (p *Plugin) GetZoomOAuthUserInfo(userID string) (*zoom.OAuthUserInfo, error) {
-	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, userID)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not get token")
-	}
-	if info == nil {
-		return nil, errors.New("the Zoom app is not connected")
-	}
-
-	return info, nil
-}
-
-func (p *Plugin) UpdateZoomOAuthUserInfo(userID string, info *zoom.OAuthUserInfo) error {
-	if err := p.storeOAuthUserInfo(info); err != nil {
-		msg := "unable to update user token"
-		p.API.LogWarn(msg, "error", err.Error())
-		return errors.Wrap(err, msg)
+	if _, err = p.API.CreatePost(post); err != nil {
+		return err
 	}
-
 	return nil
 }
 
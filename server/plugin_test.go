@@ -170,6 +170,8 @@ func TestPlugin(t *testing.T) {
 			api.On("KVSetWithOptions", "mutex_mmi_bot_ensure", []byte(nil), model.PluginKVSetOptions{ExpireInSeconds: 0}).Return(true, nil)
 			api.On("KVSetWithOptions", "post_meeting_234", []byte(nil), model.PluginKVSetOptions{ExpireInSeconds: 0}).Return(true, nil)
 			api.On("KVGet", mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, meetingChannelKey) })).Return(nil, (*model.AppError)(nil)).Maybe()
+			api.On("KVGet", activeEncryptionKeyKey).Return(nil, (*model.AppError)(nil)).Maybe()
+			api.On("KVSetWithOptions", activeEncryptionKeyKey, mock.AnythingOfType("[]uint8"), model.PluginKVSetOptions{Atomic: true}).Return(true, nil).Maybe()
 			api.On("KVSetWithExpiry", mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, meetingChannelKey) }), mock.AnythingOfType("[]uint8"), int64(adHocMeetingChannelTTL)).Return(nil).Maybe()
 
 			api.On("EnsureBotUser", &model.Bot{
@@ -208,6 +210,8 @@ func TestPlugin(t *testing.T) {
 				RestrictMeetingCreation: true,
 			})
 			p.SetAPI(api)
+			p.jobScheduler = &testJobScheduler{}
+			p.webhookQueue = &inlineWebhookQueue{p: &p}
 
 			err = p.OnActivate()
 			require.Nil(t, err)
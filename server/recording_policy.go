@@ -0,0 +1,161 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// recordingKind groups the files of a cloud recording that share an archival policy.
+type recordingKind string
+
+const (
+	recordingKindVideo      recordingKind = "video"
+	recordingKindAudio      recordingKind = "audio"
+	recordingKindTranscript recordingKind = "transcript"
+	recordingKindChat       recordingKind = "chat"
+)
+
+var recordingKinds = []recordingKind{recordingKindVideo, recordingKindAudio, recordingKindTranscript, recordingKindChat}
+
+// recordingAction is what is done with a recording file in a channel.
+type recordingAction string
+
+const (
+	recordingActionIgnore recordingAction = "ignore"
+	recordingActionLink   recordingAction = "link"
+	recordingActionUpload recordingAction = "upload"
+)
+
+var recordingActions = []recordingAction{recordingActionIgnore, recordingActionLink, recordingActionUpload}
+
+// recordingPolicy maps each kind of recording file to the action taken for it.
+type recordingPolicy map[recordingKind]recordingAction
+
+// recordingKindOf returns the kind of a recording file, or false for files the plugin doesn't post.
+func recordingKindOf(recording zoom.RecordingFile) (recordingKind, bool) {
+	switch {
+	case recording.RecordingType == zoom.RecordingTypeChat:
+		return recordingKindChat, true
+	case recording.RecordingType == zoom.RecordingTypeAudioTranscript:
+		return recordingKindTranscript, true
+	case strings.EqualFold(recording.FileType, zoom.RecordingFileTypeMP4):
+		return recordingKindVideo, true
+	case strings.EqualFold(recording.FileType, zoom.RecordingFileTypeM4A):
+		return recordingKindAudio, true
+	default:
+		return "", false
+	}
+}
+
+func isRecordingKind(value string) bool {
+	for _, kind := range recordingKinds {
+		if string(kind) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isRecordingAction(value string) bool {
+	for _, action := range recordingActions {
+		if string(action) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRecordingPolicy parses a comma separated list of kind=action pairs, e.g. "video=link, chat=upload".
+func parseRecordingPolicy(value string) (recordingPolicy, error) {
+	policy := recordingPolicy{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		kind, action, found := strings.Cut(field, "=")
+		kind, action = strings.ToLower(strings.TrimSpace(kind)), strings.ToLower(strings.TrimSpace(action))
+		if !found || !isRecordingKind(kind) || !isRecordingAction(action) {
+			return nil, errors.Errorf("%q is not one of video, audio, transcript or chat set to ignore, link or upload", field)
+		}
+		policy[recordingKind(kind)] = recordingAction(action)
+	}
+
+	return policy, nil
+}
+
+// String formats the policy in the order of recordingKinds, as parsed by parseRecordingPolicy.
+func (rp recordingPolicy) String() string {
+	fields := make([]string, 0, len(rp))
+	for _, kind := range recordingKinds {
+		if action, ok := rp[kind]; ok {
+			fields = append(fields, fmt.Sprintf("%s=%s", kind, action))
+		}
+	}
+	return strings.Join(fields, ", ")
+}
+
+// defaultRecordingPolicy returns the plugin-wide policy. Kinds the plugin settings leave out
+// keep the original behavior: chat files and transcripts are uploaded, videos are linked, and
+// audio files are skipped, unless recording uploads are enabled.
+func (p *Plugin) defaultRecordingPolicy() recordingPolicy {
+	config := p.getConfiguration()
+	policy := recordingPolicy{
+		recordingKindVideo:      recordingActionLink,
+		recordingKindAudio:      recordingActionIgnore,
+		recordingKindTranscript: recordingActionUpload,
+		recordingKindChat:       recordingActionUpload,
+	}
+	if config.UploadRecordings {
+		policy[recordingKindVideo] = recordingActionUpload
+		policy[recordingKindAudio] = recordingActionUpload
+	}
+
+	// The configuration is validated on load, so parsing can't fail here.
+	configured, _ := parseRecordingPolicy(config.RecordingPolicy)
+	for kind, action := range configured {
+		policy[kind] = action
+	}
+	return policy
+}
+
+// getChannelRecordingPolicy returns the policy of a channel. Kinds the channel doesn't set
+// use the plugin-wide policy.
+func (p *Plugin) getChannelRecordingPolicy(channelID string) (recordingPolicy, error) {
+	settings, err := p.listZoomChannelSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	policy := p.defaultRecordingPolicy()
+	for kind, action := range settings[channelID].RecordingPolicy {
+		policy[kind] = action
+	}
+	return policy, nil
+}
+
+// recordingActionFor returns the action taken for a recording file under a policy. Videos and
+// audio files are only uploaded when recording uploads are enabled, and are linked otherwise.
+func (p *Plugin) recordingActionFor(policy recordingPolicy, recording zoom.RecordingFile) recordingAction {
+	kind, ok := recordingKindOf(recording)
+	if !ok {
+		return recordingActionIgnore
+	}
+
+	action, ok := policy[kind]
+	if !ok {
+		return recordingActionIgnore
+	}
+	if action == recordingActionUpload && isUploadableRecording(recording) && !p.getConfiguration().UploadRecordings {
+		return recordingActionLink
+	}
+	return action
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestParseRecordingPolicy(t *testing.T) {
+	policy, err := parseRecordingPolicy(" chat=Upload, video = link,,")
+	require.NoError(t, err)
+	assert.Equal(t, recordingPolicy{recordingKindChat: recordingActionUpload, recordingKindVideo: recordingActionLink}, policy)
+	assert.Equal(t, "video=link, chat=upload", policy.String())
+
+	policy, err = parseRecordingPolicy("")
+	require.NoError(t, err)
+	assert.Empty(t, policy)
+
+	for _, value := range []string{"video", "video=keep", "slides=upload"} {
+		_, err = parseRecordingPolicy(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestGetChannelRecordingPolicy(t *testing.T) {
+	settings, _ := json.Marshal(ZoomChannelSettingsMap{
+		"legal-channel": {Preference: "default", RecordingPolicy: recordingPolicy{recordingKindVideo: recordingActionIgnore, recordingKindChat: recordingActionIgnore}},
+	})
+	api := &plugintest.API{}
+	api.On("KVGet", zoomChannelSettings).Return(settings, nil)
+
+	p := Plugin{}
+	cfg := *testConfig
+	cfg.RecordingPolicy = "transcript=link"
+	p.setConfiguration(&cfg)
+	p.SetAPI(api)
+
+	policy, err := p.getChannelRecordingPolicy("legal-channel")
+	require.NoError(t, err)
+	assert.Equal(t, recordingPolicy{
+		recordingKindVideo:      recordingActionIgnore,
+		recordingKindAudio:      recordingActionIgnore,
+		recordingKindTranscript: recordingActionLink,
+		recordingKindChat:       recordingActionIgnore,
+	}, policy)
+
+	policy, err = p.getChannelRecordingPolicy("other-channel")
+	require.NoError(t, err)
+	assert.Equal(t, recordingPolicy{
+		recordingKindVideo:      recordingActionLink,
+		recordingKindAudio:      recordingActionIgnore,
+		recordingKindTranscript: recordingActionLink,
+		recordingKindChat:       recordingActionUpload,
+	}, policy)
+}
+
+func TestRecordingActionFor(t *testing.T) {
+	p := Plugin{}
+	p.setConfiguration(testConfig)
+
+	policy := recordingPolicy{recordingKindVideo: recordingActionUpload, recordingKindChat: recordingActionUpload}
+	video := zoom.RecordingFile{FileType: "MP4"}
+	chat := zoom.RecordingFile{RecordingType: zoom.RecordingTypeChat, FileType: "CHAT"}
+
+	assert.Equal(t, recordingActionLink, p.recordingActionFor(policy, video), "videos are linked until uploads are enabled")
+	assert.Equal(t, recordingActionUpload, p.recordingActionFor(policy, chat))
+	assert.Equal(t, recordingActionIgnore, p.recordingActionFor(policy, zoom.RecordingFile{FileType: "M4A"}), "kinds missing from the policy are ignored")
+	assert.Equal(t, recordingActionIgnore, p.recordingActionFor(policy, zoom.RecordingFile{FileType: "CSV"}))
+
+	cfg := *testConfig
+	cfg.UploadRecordings = true
+	p.setConfiguration(&cfg)
+	assert.Equal(t, recordingActionUpload, p.recordingActionFor(policy, video))
+}
+
+func TestPostRecordingGroupFollowsThePolicy(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return len(post.FileIds) == 0 && post.Message == "Here's the zoom meeting recording:\n**Link:** [Meeting Recording](https://zoom.us/rec/play/abc)"
+	})).Return(&model.Post{}, nil).Once()
+
+	p := Plugin{botUserID: "bot-id"}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+
+	group := []zoom.RecordingFile{
+		{RecordingType: zoom.RecordingTypeChat, FileType: "CHAT", DownloadURL: "https://zoom.us/rec/download/chat"},
+		{FileType: "MP4", PlayURL: "https://zoom.us/rec/play/abc"},
+		{FileType: "M4A", PlayURL: "https://zoom.us/rec/play/audio"},
+	}
+	policy := recordingPolicy{recordingKindVideo: recordingActionLink, recordingKindAudio: recordingActionIgnore, recordingKindChat: recordingActionIgnore}
+
+	require.NoError(t, p.postRecordingGroup(&zoom.RecordingWebhook{}, group, policy, &model.Post{Id: "post-id", ChannelId: "channel-id"}))
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything, mock.Anything)
+}
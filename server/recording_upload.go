@@ -0,0 +1,122 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	defaultMaxRecordingUploadSizeMB = 1024
+
+	// recordingUploadChunkSize is how much of a recording is sent to Mattermost per upload call.
+	recordingUploadChunkSize = 64 << 20
+
+	// recordingDownloadTimeout replaces the download timeout for recordings, which can take
+	// much longer to stream than chat files and transcripts.
+	recordingDownloadTimeout = 2 * time.Hour
+
+	recordingFilenameTimeFormat = "2006-01-02_15-04"
+)
+
+// parseMaxRecordingUploadSize returns the recording upload ceiling in bytes. An empty value
+// means the default ceiling.
+func parseMaxRecordingUploadSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultMaxRecordingUploadSizeMB << 20, nil
+	}
+
+	megabytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || megabytes <= 0 || megabytes > 1<<20 {
+		return 0, errors.Errorf("%q is not a positive number of megabytes", value)
+	}
+	return megabytes << 20, nil
+}
+
+// isUploadableRecording reports whether a recording file is copied into Mattermost when
+// recording uploads are enabled.
+func isUploadableRecording(recording zoom.RecordingFile) bool {
+	return strings.EqualFold(recording.FileType, zoom.RecordingFileTypeMP4) || strings.EqualFold(recording.FileType, zoom.RecordingFileTypeM4A)
+}
+
+// recordingFilename names an uploaded recording after its start and type, e.g. zoom_2026-03-04_14-01_shared_screen.mp4.
+func recordingFilename(recording zoom.RecordingFile) string {
+	name := "zoom"
+	if !recording.RecordingStart.IsZero() {
+		name += "_" + recording.RecordingStart.UTC().Format(recordingFilenameTimeFormat)
+	}
+	if recordingType := strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return -1
+	}, recording.RecordingType), "_"); recordingType != "" {
+		name += "_" + recordingType
+	}
+	return name + "." + strings.ToLower(recording.FileType)
+}
+
+// uploadRecordingFile streams a recording from Zoom into the channel's file storage through
+// an upload session, so the recording is never held in memory as a whole.
+func (p *Plugin) uploadRecordingFile(recording zoom.RecordingFile, downloadToken, channelID string) (*model.FileInfo, error) {
+	maxSize, err := parseMaxRecordingUploadSize(p.getConfiguration().MaxRecordingUploadSizeMB)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(recording.FileSize)
+	if size <= 0 {
+		return nil, errors.New("the recording size is unknown")
+	}
+	if size > maxSize {
+		return nil, errors.Errorf("the recording is %d bytes, over the upload limit of %d bytes", size, maxSize)
+	}
+
+	session, err := p.API.CreateUploadSession(&model.UploadSession{
+		Type:      model.UploadTypeAttachment,
+		UserId:    p.botUserID,
+		ChannelId: channelID,
+		Filename:  recordingFilename(recording),
+		FileSize:  size,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the upload session")
+	}
+
+	client := *p.downloadClient
+	client.Timeout = recordingDownloadTimeout
+	response, err := p.openZoomDownload(&client, recording.DownloadURL, downloadToken)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body := io.LimitReader(response.Body, size)
+	for {
+		offset := session.FileOffset
+		fileInfo, err := p.API.UploadData(session, io.LimitReader(body, recordingUploadChunkSize))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not upload the recording")
+		}
+		if fileInfo != nil {
+			return fileInfo, nil
+		}
+
+		session, err = p.API.GetUploadSession(session.Id)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get the upload session")
+		}
+		if session.FileOffset <= offset {
+			return nil, errors.Errorf("the download ended after %d of %d bytes", session.FileOffset, size)
+		}
+	}
+}
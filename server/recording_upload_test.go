@@ -0,0 +1,133 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestParseMaxRecordingUploadSize(t *testing.T) {
+	size, err := parseMaxRecordingUploadSize("")
+	require.NoError(t, err)
+	assert.Equal(t, int64(defaultMaxRecordingUploadSizeMB<<20), size)
+
+	size, err = parseMaxRecordingUploadSize(" 200 ")
+	require.NoError(t, err)
+	assert.Equal(t, int64(200<<20), size)
+
+	for _, value := range []string{"0", "-5", "1.5", "lots"} {
+		_, err = parseMaxRecordingUploadSize(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestRecordingFilename(t *testing.T) {
+	assert.Equal(t, "zoom_2026-03-04_14-01_shared_screen_with_speaker_viewCC.mp4", recordingFilename(zoom.RecordingFile{
+		RecordingStart: time.Date(2026, time.March, 4, 14, 1, 0, 0, time.UTC),
+		RecordingType:  "shared_screen_with_speaker_view(CC)",
+		FileType:       "MP4",
+	}))
+	assert.Equal(t, "zoom_audio_only.m4a", recordingFilename(zoom.RecordingFile{RecordingType: "audio_only", FileType: "M4A"}))
+}
+
+func TestUploadRecordingFile(t *testing.T) {
+	const content = "recording content"
+
+	setup := func(t *testing.T) (*Plugin, *plugintest.API, string) {
+		httpServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(content))
+		}))
+		t.Cleanup(httpServer.Close)
+
+		cfg := *testConfig
+		cfg.ZoomURL = httpServer.URL
+		p := &Plugin{botUserID: "bot-id"}
+		p.setConfiguration(&cfg)
+		p.downloadClient = httpServer.Client()
+
+		api := &plugintest.API{}
+		p.SetAPI(api)
+		return p, api, httpServer.URL + "/rec/download/abc"
+	}
+
+	t.Run("the recording is streamed into an upload session", func(t *testing.T) {
+		p, api, downloadURL := setup(t)
+		session := &model.UploadSession{Id: "session-id", FileSize: int64(len(content))}
+		api.On("CreateUploadSession", mock.MatchedBy(func(us *model.UploadSession) bool {
+			return us.Type == model.UploadTypeAttachment && us.UserId == "bot-id" && us.ChannelId == "channel-id" &&
+				us.FileSize == int64(len(content)) && us.Filename == "zoom_audio_only.m4a"
+		})).Return(session, nil)
+		api.On("UploadData", session, mock.Anything).Return(func(_ *model.UploadSession, rd io.Reader) (*model.FileInfo, error) {
+			data, err := io.ReadAll(rd)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+			return &model.FileInfo{Id: "file-id"}, nil
+		})
+
+		fileInfo, err := p.uploadRecordingFile(zoom.RecordingFile{RecordingType: "audio_only", FileType: "M4A", FileSize: len(content), DownloadURL: downloadURL}, "token", "channel-id")
+		require.NoError(t, err)
+		assert.Equal(t, "file-id", fileInfo.Id)
+	})
+
+	t.Run("a download shorter than the recording fails", func(t *testing.T) {
+		p, api, downloadURL := setup(t)
+		session := &model.UploadSession{Id: "session-id", FileSize: 100}
+		api.On("CreateUploadSession", mock.Anything).Return(session, nil)
+		api.On("UploadData", mock.Anything, mock.Anything).Return(func(_ *model.UploadSession, rd io.Reader) (*model.FileInfo, error) {
+			_, err := io.ReadAll(rd)
+			return nil, err
+		})
+		api.On("GetUploadSession", "session-id").Return(&model.UploadSession{Id: "session-id", FileSize: 100, FileOffset: int64(len(content))}, nil)
+
+		_, err := p.uploadRecordingFile(zoom.RecordingFile{FileType: "MP4", FileSize: 100, DownloadURL: downloadURL}, "token", "channel-id")
+		assert.EqualError(t, err, "the download ended after 17 of 100 bytes")
+	})
+
+	t.Run("recordings over the limit are not uploaded", func(t *testing.T) {
+		p, api, downloadURL := setup(t)
+		cfg := *p.getConfiguration()
+		cfg.MaxRecordingUploadSizeMB = "1"
+		p.setConfiguration(&cfg)
+
+		_, err := p.uploadRecordingFile(zoom.RecordingFile{FileType: "MP4", FileSize: 2 << 20, DownloadURL: downloadURL}, "token", "channel-id")
+		assert.Error(t, err)
+		api.AssertNotCalled(t, "CreateUploadSession", mock.Anything)
+	})
+}
+
+func TestOpenZoomDownloadFailures(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	httpServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer httpServer.Close()
+
+	cfg := *testConfig
+	cfg.ZoomURL = httpServer.URL
+	p := &Plugin{}
+	p.setConfiguration(&cfg)
+	downloadURL := httpServer.URL + "/rec/download/abc"
+
+	_, err := p.openZoomDownload(httpServer.Client(), downloadURL, "token")
+	assert.ErrorIs(t, err, errZoomDownloadUnavailable, "the webhook is attempted again later")
+
+	status = http.StatusNotFound
+	_, err = p.openZoomDownload(httpServer.Client(), downloadURL, "token")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, errZoomDownloadUnavailable)
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	meetingIDForContext    = "meetingID"
+	registrantIDForContext = "registrantID"
+	emailForContext        = "email"
+	registrantForContext   = "registrant"
+
+	registrationStatusApproved = "approved"
+	registrationStatusPending  = "pending"
+)
+
+// meetingRegistrationRequest is the body of a request to register for the meeting of a post.
+type meetingRegistrationRequest struct {
+	PostID string `json:"post_id"`
+}
+
+// meetingRegistrationResponse tells the webapp whether the registration awaits the host's approval.
+type meetingRegistrationResponse struct {
+	Status string `json:"status"`
+}
+
+// handleRegisterForMeeting registers the user for the meeting of a post with their Mattermost
+// profile. Registrants are added through the Zoom connection of the user who scheduled the meeting.
+func (p *Plugin) handleRegisterForMeeting(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req meetingRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	post, appErr := p.API.GetPost(req.PostID)
+	if appErr != nil || post.DeleteAt != 0 {
+		http.Error(w, "meeting not found", http.StatusNotFound)
+		return
+	}
+	registration, _ := post.Props["meeting_registration"].(bool)
+	meetingID, ok := post.Props["meeting_id"].(float64)
+	if !registration || !ok || post.Props["meeting_status"] == zoom.WebhookStatusEnded {
+		http.Error(w, "the meeting is not open for registration", http.StatusBadRequest)
+		return
+	}
+
+	if !p.API.HasPermissionToChannel(userID, post.ChannelId, model.PermissionReadChannel) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		http.Error(w, "could not find the user", http.StatusInternalServerError)
+		return
+	}
+
+	client, err := p.getFirstActiveClient([]string{post.UserId})
+	if err != nil {
+		p.API.LogWarn("Could not get the Zoom client of the meeting host", "post_id", post.Id, "err", err.Error())
+		http.Error(w, "The host of the meeting is not connected to Zoom", http.StatusBadRequest)
+		return
+	}
+
+	registrant, err := client.AddMeetingRegistrant(int(meetingID), meetingRegistrantFromUser(user))
+	if err != nil {
+		p.API.LogWarn("Could not register for the meeting", "meeting_id", int(meetingID), "err", err.Error())
+		http.Error(w, "Zoom could not register you for the meeting", http.StatusBadGateway)
+		return
+	}
+
+	status := registrationStatusApproved
+	topic := getString("meeting_topic", post.Props)
+	message := fmt.Sprintf("You are registered for [%s](%s). Use your personal link to join: %s", topic, p.permalink(post.Id), registrant.JoinURL)
+	if approval, _ := post.Props["meeting_registration_approval"].(string); approval == registrationManual {
+		status = registrationStatusPending
+		message = fmt.Sprintf("Your registration for [%s](%s) is waiting for the host's approval. Zoom will email you the link to join once you are approved.", topic, p.permalink(post.Id))
+	}
+	if err := p.sendDirectMessage(userID, message); err != nil {
+		p.API.LogWarn("Could not send the registration message", "user_id", userID, "err", err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(meetingRegistrationResponse{Status: status}); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
+
+// meetingRegistrantFromUser fills a Zoom registrant from a Mattermost profile. Zoom requires a
+// first name, so the username stands in for users who didn't set one.
+func meetingRegistrantFromUser(user *model.User) *zoom.MeetingRegistrantRequest {
+	registrant := &zoom.MeetingRegistrantRequest{
+		Email:     user.Email,
+		FirstName: strings.TrimSpace(user.FirstName),
+		LastName:  strings.TrimSpace(user.LastName),
+	}
+	if registrant.FirstName == "" {
+		registrant.FirstName = user.Username
+	}
+	return registrant
+}
+
+// handleMeetingRegistrationCreated asks the host of a meeting scheduled from Mattermost to
+// approve or deny a registrant waiting for approval.
+func (p *Plugin) handleMeetingRegistrationCreated(w http.ResponseWriter, _ *http.Request, body []byte) {
+	var webhook zoom.RegistrationWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		p.API.LogError("Error unmarshaling meeting registration webhook", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	object := webhook.Payload.Object
+	if object.Registrant.Status != zoom.RegistrantStatusPending {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	meetingID, err := strconv.Atoi(object.ID)
+	if err != nil {
+		http.Error(w, "invalid meeting ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, appErr := p.getMeetingChannelEntry(meetingID)
+	if appErr != nil {
+		p.API.LogWarn("Could not get the meeting entry", "meeting_id", meetingID, "err", appErr.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if entry == nil || !entry.IsScheduled || entry.CreatedBy == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := p.sendRegistrantApprovalRequest(entry, object); err != nil {
+		p.API.LogWarn("Could not ask the host to approve the registrant", "meeting_id", meetingID, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) sendRegistrantApprovalRequest(entry *meetingChannelEntry, object zoom.RegistrationWebhookObject) error {
+	channel, appErr := p.API.GetDirectChannel(entry.CreatedBy, p.botUserID)
+	if appErr != nil {
+		return errors.Wrap(appErr, "could not get the direct channel of the host")
+	}
+
+	topic := object.Topic
+	if topic == "" {
+		topic = defaultMeetingTopic
+	}
+	if entry.PostID != "" {
+		topic = fmt.Sprintf("[%s](%s)", topic, p.permalink(entry.PostID))
+	}
+
+	registrant := strings.TrimSpace(object.Registrant.FirstName + " " + object.Registrant.LastName)
+	if registrant == "" {
+		registrant = object.Registrant.Email
+	} else if object.Registrant.Email != "" {
+		registrant = fmt.Sprintf("%s (%s)", registrant, object.Registrant.Email)
+	}
+
+	apiEndPoint := fmt.Sprintf("/plugins/%s%s", url.PathEscape(manifest.Id), pathRegistrantAction)
+	action := func(id, name, style, registrantAction string) *model.PostAction {
+		return &model.PostAction{
+			Id:    id,
+			Name:  name,
+			Type:  model.PostActionTypeButton,
+			Style: style,
+			Integration: &model.PostActionIntegration{
+				URL: apiEndPoint,
+				Context: map[string]interface{}{
+					actionForContext:       registrantAction,
+					meetingIDForContext:    object.ID,
+					registrantIDForContext: object.Registrant.ID,
+					emailForContext:        object.Registrant.Email,
+					registrantForContext:   registrant,
+				},
+			},
+		}
+	}
+
+	slackAttachment := model.SlackAttachment{
+		Title: "Registration waiting for approval",
+		Text:  fmt.Sprintf("%s registered for %s.", registrant, topic),
+		Actions: []*model.PostAction{
+			action("approve", "Approve", "primary", zoom.RegistrantActionApprove),
+			action("deny", "Deny", "danger", zoom.RegistrantActionDeny),
+		},
+	}
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		UserId:    p.botUserID,
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{&slackAttachment})
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// handleRegistrantAction approves or denies a registrant from the buttons of the approval request.
+func (p *Plugin) handleRegistrantAction(w http.ResponseWriter, r *http.Request) {
+	var request model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" || userID != request.UserId {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	action, _ := request.Context[actionForContext].(string)
+	meetingIDValue, _ := request.Context[meetingIDForContext].(string)
+	registrantID, _ := request.Context[registrantIDForContext].(string)
+	email, _ := request.Context[emailForContext].(string)
+	registrant, _ := request.Context[registrantForContext].(string)
+	meetingID, err := strconv.Atoi(meetingIDValue)
+	if err != nil || registrantID == "" || (action != zoom.RegistrantActionApprove && action != zoom.RegistrantActionDeny) {
+		http.Error(w, "invalid request context", http.StatusBadRequest)
+		return
+	}
+
+	entry, appErr := p.getMeetingChannelEntry(meetingID)
+	if appErr != nil || entry == nil || entry.CreatedBy != userID {
+		p.writePostActionResponse(w, &model.PostActionIntegrationResponse{EphemeralText: "Only the host of the meeting can approve its registrants."})
+		return
+	}
+
+	client, err := p.getFirstActiveClient([]string{userID})
+	if err != nil {
+		p.writePostActionResponse(w, &model.PostActionIntegrationResponse{EphemeralText: "You are not connected to Zoom. Run `/zoom connect` and try again."})
+		return
+	}
+
+	if err := client.UpdateMeetingRegistrantStatus(meetingID, &zoom.RegistrantStatusRequest{
+		Action:      action,
+		Registrants: []zoom.RegistrantStatusItem{{ID: registrantID, Email: email}},
+	}); err != nil {
+		p.API.LogWarn("Could not update the registrant status", "meeting_id", meetingID, "err", err.Error())
+		p.writePostActionResponse(w, &model.PostActionIntegrationResponse{EphemeralText: "Zoom could not update the registration. Please try again."})
+		return
+	}
+
+	outcome := "Approved"
+	if action == zoom.RegistrantActionDeny {
+		outcome = "Denied"
+	}
+	post := &model.Post{
+		Id:        request.PostId,
+		ChannelId: request.ChannelId,
+		UserId:    p.botUserID,
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{{
+		Title: "Registration " + strings.ToLower(outcome),
+		Text:  fmt.Sprintf("%s the registration of %s.", outcome, registrant),
+	}})
+
+	p.writePostActionResponse(w, &model.PostActionIntegrationResponse{Update: post})
+}
+
+func (p *Plugin) writePostActionResponse(w http.ResponseWriter, response *model.PostActionIntegrationResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
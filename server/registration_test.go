@@ -0,0 +1,160 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestOAuthClientMeetingRegistrants(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/meetings/55/registrants":
+			var registrant zoom.MeetingRegistrantRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&registrant))
+			assert.Equal(t, zoom.MeetingRegistrantRequest{Email: "ann@example.com", FirstName: "ann"}, registrant)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":55,"registrant_id":"r1","join_url":"https://zoom.us/w/55?tk=abc"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/meetings/55/registrants/status":
+			var status zoom.RegistrantStatusRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&status))
+			assert.Equal(t, zoom.RegistrantActionApprove, status.Action)
+			assert.Equal(t, []zoom.RegistrantStatusItem{{ID: "r1"}}, status.Registrants)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	token := &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}
+	client := zoom.NewOAuthClient(token, &oauth2.Config{}, "", ts.URL, false, nil)
+
+	registration, err := client.AddMeetingRegistrant(55, meetingRegistrantFromUser(&model.User{Username: "ann", Email: "ann@example.com"}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://zoom.us/w/55?tk=abc", registration.JoinURL)
+
+	err = client.UpdateMeetingRegistrantStatus(55, &zoom.RegistrantStatusRequest{Action: zoom.RegistrantActionApprove, Registrants: []zoom.RegistrantStatusItem{{ID: "r1"}}})
+	require.NoError(t, err)
+
+	_, err = client.AddMeetingRegistrant(56, &zoom.MeetingRegistrantRequest{})
+	assert.Error(t, err)
+}
+
+func TestHandleMeetingRegistrationCreated(t *testing.T) {
+	entry, err := json.Marshal(meetingChannelEntry{ChannelID: "channel-id", CreatedBy: "host-id", IsScheduled: true, PostID: "post-id"})
+	require.NoError(t, err)
+
+	t.Run("pending registrants are sent to the host", func(t *testing.T) {
+		api := &plugintest.API{}
+		allowFlexibleLogging(api)
+		api.On("KVGet", meetingChannelKVKey(55)).Return(entry, nil)
+		api.On("GetDirectChannel", "host-id", "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+		api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			attachments := post.Attachments()
+			return post.ChannelId == "dm-id" && len(attachments) == 1 &&
+				attachments[0].Text == "Ann Lee (ann@example.com) registered for [Launch](https://mm.example.com/_redirect/pl/post-id)." &&
+				len(attachments[0].Actions) == 2 && attachments[0].Actions[0].Integration.Context[registrantIDForContext] == "r1"
+		})).Return(&model.Post{}, nil).Once()
+
+		p := webinarTestPlugin(api)
+		p.siteURL = "https://mm.example.com"
+		w := httptest.NewRecorder()
+		p.handleMeetingRegistrationCreated(w, nil, []byte(`{"event":"meeting.registration_created","payload":{"object":{"id":"55","topic":"Launch","registrant":{"id":"r1","email":"ann@example.com","first_name":"Ann","last_name":"Lee","status":"pending"}}}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("approved registrants are ignored", func(t *testing.T) {
+		api := &plugintest.API{}
+		allowFlexibleLogging(api)
+
+		w := httptest.NewRecorder()
+		webinarTestPlugin(api).handleMeetingRegistrationCreated(w, nil, []byte(`{"event":"meeting.registration_created","payload":{"object":{"id":"55","registrant":{"id":"r1","status":"approved"}}}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	})
+}
+
+func TestHandleRegistrantActionRequiresHost(t *testing.T) {
+	entry, err := json.Marshal(meetingChannelEntry{ChannelID: "channel-id", CreatedBy: "host-id", IsScheduled: true})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("GetLicense").Return(nil)
+	api.On("KVGet", meetingChannelKVKey(55)).Return(entry, nil)
+	p := webinarTestPlugin(api)
+
+	body, err := json.Marshal(model.PostActionIntegrationRequest{
+		UserId:  "other-id",
+		PostId:  "dm-post-id",
+		Context: map[string]any{actionForContext: zoom.RegistrantActionApprove, meetingIDForContext: "55", registrantIDForContext: "r1"},
+	})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, pathRegistrantAction, bytes.NewReader(body))
+	request.Header.Set(MattermostUserIDHeader, "other-id")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, request)
+
+	var response model.PostActionIntegrationResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "Only the host of the meeting can approve its registrants.", response.EphemeralText)
+	assert.Nil(t, response.Update)
+}
+
+func TestHandleRegisterForMeeting(t *testing.T) {
+	post := &model.Post{
+		Id:        "post-id",
+		ChannelId: "channel-id",
+		UserId:    "host-id",
+		Props: model.StringInterface{
+			"meeting_id":                    float64(55),
+			"meeting_status":                zoom.MeetingStatusScheduled,
+			"meeting_registration":          true,
+			"meeting_registration_approval": registrationManual,
+		},
+	}
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("GetLicense").Return(nil)
+	api.On("GetPost", "post-id").Return(post, nil)
+	api.On("HasPermissionToChannel", "outsider-id", "channel-id", model.PermissionReadChannel).Return(false)
+	p := webinarTestPlugin(api)
+
+	request := httptest.NewRequest(http.MethodPost, pathRegisterForMeeting, bytes.NewBufferString(`{"post_id":"post-id"}`))
+	request.Header.Set(MattermostUserIDHeader, "outsider-id")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, request)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	ended := post.Clone()
+	ended.Id = "ended-id"
+	ended.Props["meeting_status"] = zoom.WebhookStatusEnded
+	api.On("GetPost", "ended-id").Return(ended, nil)
+
+	request = httptest.NewRequest(http.MethodPost, pathRegisterForMeeting, bytes.NewBufferString(`{"post_id":"ended-id"}`))
+	request.Header.Set(MattermostUserIDHeader, "outsider-id")
+	w = httptest.NewRecorder()
+	p.ServeHTTP(nil, w, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
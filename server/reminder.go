@@ -0,0 +1,306 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	reminderJobKeyPrefix = "reminder_"
+
+	// maxReminderLeadMinutes caps how far ahead of the start a reminder can be sent.
+	maxReminderLeadMinutes = 7 * 24 * 60
+
+	// maxReminderDirectMessages bounds how many channel members are reminded by DM,
+	// so a large channel mapped to a meeting doesn't flood the bot.
+	maxReminderDirectMessages = 100
+	reminderMembersPerPage    = 100
+)
+
+// jobScheduler is the subset of cluster.JobOnceScheduler used by the plugin. Jobs are stored in
+// the KV store and guarded by a cluster mutex, so they survive restarts and run on one node only.
+type jobScheduler interface {
+	ScheduleOnce(key string, runAt time.Time, props any) (*cluster.JobOnce, error)
+	Cancel(key string)
+	ListScheduledJobs() ([]cluster.JobOnceMetadata, error)
+}
+
+// reminderJob is stored as the props of a scheduled reminder. Starts holds the meeting's
+// remaining start times so the next reminder can be scheduled once this one fires.
+type reminderJob struct {
+	MeetingID   int     `json:"meeting_id"`
+	ChannelID   string  `json:"channel_id"`
+	PostID      string  `json:"post_id,omitempty"`
+	Topic       string  `json:"topic"`
+	JoinURL     string  `json:"join_url"`
+	Starts      []int64 `json:"starts"`
+	Start       int64   `json:"start"`
+	LeadMinutes int     `json:"lead_minutes"`
+}
+
+func reminderJobKey(meetingID int, channelID string, start int64, leadMinutes int) string {
+	return fmt.Sprintf("%s%d_%s_%d_%d", reminderJobKeyPrefix, meetingID, channelID, start, leadMinutes)
+}
+
+// parseReminderMinutes parses a comma separated list of lead times in minutes.
+func parseReminderMinutes(value string) ([]int, error) {
+	var minutes []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		lead, err := strconv.Atoi(field)
+		if err != nil || lead <= 0 || lead > maxReminderLeadMinutes {
+			return nil, errors.Errorf("%q is not a number of minutes between 1 and %d", field, maxReminderLeadMinutes)
+		}
+		minutes = append(minutes, lead)
+	}
+
+	return minutes, nil
+}
+
+func formatReminderMinutes(minutes []int) string {
+	fields := make([]string, 0, len(minutes))
+	for _, lead := range minutes {
+		fields = append(fields, strconv.Itoa(lead))
+	}
+	return strings.Join(fields, ", ")
+}
+
+// getChannelReminderSettings returns the reminder lead times for a channel and whether members
+// should also be reminded by DM. Channels without their own lead times use the plugin default.
+func (p *Plugin) getChannelReminderSettings(channelID string) ([]int, bool, error) {
+	settings, err := p.listZoomChannelSettings()
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, ok := settings[channelID]
+	if ok && value.RemindersDisabled {
+		return nil, false, nil
+	}
+	if ok && len(value.ReminderMinutes) > 0 {
+		return value.ReminderMinutes, value.RemindMembers, nil
+	}
+
+	// The configuration is validated on load, so parsing can't fail here.
+	minutes, _ := parseReminderMinutes(p.getConfiguration().ReminderMinutes)
+	return minutes, ok && value.RemindMembers, nil
+}
+
+// meetingStartTimes returns the start times of a meeting, using its occurrences when it recurs.
+func meetingStartTimes(meeting *zoom.Meeting) []int64 {
+	var starts []int64
+	if len(meeting.Occurrences) > 0 {
+		for _, occurrence := range meeting.Occurrences {
+			if strings.EqualFold(occurrence.Status, "deleted") {
+				continue
+			}
+			if start, err := time.Parse(time.RFC3339, occurrence.StartTime); err == nil {
+				starts = append(starts, start.Unix())
+			}
+		}
+	} else if start, err := time.Parse(time.RFC3339, meeting.StartTime); err == nil {
+		starts = append(starts, start.Unix())
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts
+}
+
+// nextReminder finds the earliest start and lead time whose reminder is still due after now.
+func nextReminder(starts []int64, leads []int, now time.Time) (int64, int, bool) {
+	var (
+		nextStart int64
+		nextLead  int
+		nextRunAt time.Time
+		found     bool
+	)
+	for _, start := range starts {
+		for _, lead := range leads {
+			runAt := time.Unix(start, 0).Add(-time.Duration(lead) * time.Minute)
+			if !runAt.After(now) {
+				continue
+			}
+			if !found || runAt.Before(nextRunAt) {
+				nextStart, nextLead, nextRunAt, found = start, lead, runAt, true
+			}
+		}
+	}
+
+	return nextStart, nextLead, found
+}
+
+// scheduleMeetingReminders schedules the first upcoming reminder for a meeting mapped to a channel.
+// Later reminders are chained from the job that fires before them.
+func (p *Plugin) scheduleMeetingReminders(meeting *zoom.Meeting, channelID, postID string) {
+	if p.jobScheduler == nil {
+		return
+	}
+
+	starts := meetingStartTimes(meeting)
+	if len(starts) == 0 {
+		return
+	}
+
+	joinURL := meeting.JoinURL
+	if joinURL == "" {
+		joinURL = fmt.Sprintf("%s/j/%v", p.getZoomURL(), meeting.ID)
+	}
+
+	p.scheduleNextReminder(&reminderJob{
+		MeetingID: meeting.ID,
+		ChannelID: channelID,
+		PostID:    postID,
+		Topic:     meeting.Topic,
+		JoinURL:   joinURL,
+		Starts:    starts,
+	}, time.Now())
+}
+
+func (p *Plugin) scheduleNextReminder(job *reminderJob, now time.Time) {
+	leads, _, err := p.getChannelReminderSettings(job.ChannelID)
+	if err != nil {
+		p.API.LogWarn("could not get the channel reminder settings", "channel_id", job.ChannelID, "error", err.Error())
+		return
+	}
+
+	start, lead, ok := nextReminder(job.Starts, leads, now)
+	if !ok {
+		return
+	}
+
+	remaining := make([]int64, 0, len(job.Starts))
+	for _, s := range job.Starts {
+		if s >= start {
+			remaining = append(remaining, s)
+		}
+	}
+
+	next := *job
+	next.Starts = remaining
+	next.Start = start
+	next.LeadMinutes = lead
+
+	runAt := time.Unix(start, 0).Add(-time.Duration(lead) * time.Minute)
+	if _, err := p.jobScheduler.ScheduleOnce(reminderJobKey(job.MeetingID, job.ChannelID, start, lead), runAt, next); err != nil {
+		p.API.LogDebug("could not schedule the meeting reminder", "meeting_id", job.MeetingID, "error", err.Error())
+	}
+}
+
+// cancelMeetingReminders removes the pending reminders of a meeting in a channel.
+func (p *Plugin) cancelMeetingReminders(meetingID int, channelID string) {
+	if p.jobScheduler == nil {
+		return
+	}
+
+	jobs, err := p.jobScheduler.ListScheduledJobs()
+	if err != nil {
+		p.API.LogWarn("could not list the scheduled reminders", "error", err.Error())
+		return
+	}
+
+	prefix := fmt.Sprintf("%s%d_%s_", reminderJobKeyPrefix, meetingID, channelID)
+	for _, job := range jobs {
+		if strings.HasPrefix(job.Key, prefix) {
+			p.jobScheduler.Cancel(job.Key)
+		}
+	}
+}
+
+// runReminderJob posts the reminder into the channel the meeting is mapped to, DMs the channel
+// members when the channel asks for it and chains the next reminder.
+func (p *Plugin) runReminderJob(props any) {
+	var job reminderJob
+	data, err := json.Marshal(props)
+	if err == nil {
+		err = json.Unmarshal(data, &job)
+	}
+	if err != nil {
+		p.API.LogWarn("could not decode the meeting reminder", "error", err.Error())
+		return
+	}
+
+	// The meeting may have been unsubscribed or remapped since the reminder was scheduled.
+	entry, appErr := p.getMeetingChannelEntry(job.MeetingID)
+	if appErr != nil {
+		p.API.LogWarn("could not get the channel for the meeting reminder", "meeting_id", job.MeetingID, "error", appErr.Error())
+		return
+	}
+	if entry == nil || !entry.hasChannel(job.ChannelID) {
+		return
+	}
+
+	now := time.Now()
+	p.scheduleNextReminder(&job, now)
+
+	// Jobs overdue after downtime fire late; don't remind about a meeting that already started.
+	if now.Unix() >= job.Start {
+		return
+	}
+
+	message := fmt.Sprintf("Reminder: **%s** starts in %d minute(s). [Join Meeting](%s)", job.Topic, job.LeadMinutes, job.JoinURL)
+
+	post := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: job.ChannelID,
+		Message:   message,
+	}
+	if job.PostID != "" {
+		if card, err := p.client.Post.GetPost(job.PostID); err == nil && card.DeleteAt == 0 {
+			post.RootId = card.Id
+			if card.RootId != "" {
+				post.RootId = card.RootId
+			}
+		}
+	}
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		p.API.LogWarn("could not post the meeting reminder", "meeting_id", job.MeetingID, "error", appErr.Error())
+	}
+
+	_, remindMembers, err := p.getChannelReminderSettings(job.ChannelID)
+	if err != nil || !remindMembers {
+		return
+	}
+	p.remindChannelMembers(job.ChannelID, message)
+}
+
+func (p *Plugin) remindChannelMembers(channelID, message string) {
+	sent := 0
+	for page := 0; sent < maxReminderDirectMessages; page++ {
+		members, appErr := p.API.GetChannelMembers(channelID, page, reminderMembersPerPage)
+		if appErr != nil {
+			p.API.LogWarn("could not get the channel members to remind", "channel_id", channelID, "error", appErr.Error())
+			return
+		}
+
+		for _, member := range members {
+			if member.UserId == p.botUserID || sent >= maxReminderDirectMessages {
+				continue
+			}
+			if err := p.sendDirectMessage(member.UserId, message); err != nil {
+				p.API.LogWarn("could not send the meeting reminder", "user_id", member.UserId, "error", err.Error())
+			}
+			sent++
+		}
+
+		if len(members) < reminderMembersPerPage {
+			return
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+type scheduledTestJob struct {
+	key   string
+	runAt time.Time
+	props any
+}
+
+type testJobScheduler struct {
+	scheduled []scheduledTestJob
+	cancelled []string
+}
+
+func (s *testJobScheduler) ScheduleOnce(key string, runAt time.Time, props any) (*cluster.JobOnce, error) {
+	s.scheduled = append(s.scheduled, scheduledTestJob{key: key, runAt: runAt, props: props})
+	return nil, nil
+}
+
+func (s *testJobScheduler) Cancel(key string) {
+	s.cancelled = append(s.cancelled, key)
+}
+
+func (s *testJobScheduler) ListScheduledJobs() ([]cluster.JobOnceMetadata, error) {
+	jobs := make([]cluster.JobOnceMetadata, 0, len(s.scheduled))
+	for _, job := range s.scheduled {
+		jobs = append(jobs, cluster.JobOnceMetadata{Key: job.key, RunAt: job.runAt, Props: job.props})
+	}
+	return jobs, nil
+}
+
+func TestParseReminderMinutes(t *testing.T) {
+	minutes, err := parseReminderMinutes(" 15, 5 ,")
+	require.NoError(t, err)
+	assert.Equal(t, []int{15, 5}, minutes)
+
+	minutes, err = parseReminderMinutes("")
+	require.NoError(t, err)
+	assert.Empty(t, minutes)
+
+	_, err = parseReminderMinutes("10, soon")
+	assert.Error(t, err)
+
+	_, err = parseReminderMinutes("-5")
+	assert.Error(t, err)
+}
+
+func TestMeetingStartTimes(t *testing.T) {
+	meeting := &zoom.Meeting{StartTime: "2026-03-04T14:00:00Z"}
+	assert.Equal(t, []int64{time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC).Unix()}, meetingStartTimes(meeting))
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"start_time": "2026-03-04T14:00:00Z",
+		"occurrences": [
+			{"occurrence_id": "3", "start_time": "2026-03-18T14:00:00Z", "status": "available"},
+			{"occurrence_id": "2", "start_time": "2026-03-11T14:00:00Z", "status": "deleted"},
+			{"occurrence_id": "1", "start_time": "2026-03-04T14:00:00Z", "status": "available"}
+		]
+	}`), meeting))
+	assert.Equal(t, []int64{
+		time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC).Unix(),
+		time.Date(2026, time.March, 18, 14, 0, 0, 0, time.UTC).Unix(),
+	}, meetingStartTimes(meeting))
+
+	assert.Empty(t, meetingStartTimes(&zoom.Meeting{}))
+}
+
+func TestNextReminder(t *testing.T) {
+	now := time.Date(2026, time.March, 4, 13, 50, 0, 0, time.UTC)
+	first := time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC).Unix()
+	second := time.Date(2026, time.March, 5, 14, 0, 0, 0, time.UTC).Unix()
+
+	start, lead, ok := nextReminder([]int64{first, second}, []int{15, 5}, now)
+	require.True(t, ok)
+	assert.Equal(t, first, start)
+	assert.Equal(t, 5, lead)
+
+	start, lead, ok = nextReminder([]int64{first, second}, []int{15, 5}, now.Add(5*time.Minute))
+	require.True(t, ok)
+	assert.Equal(t, second, start)
+	assert.Equal(t, 15, lead)
+
+	_, _, ok = nextReminder([]int64{first}, nil, now)
+	assert.False(t, ok)
+}
+
+func TestRunReminderJob(t *testing.T) {
+	api := &plugintest.API{}
+	p := Plugin{botUserID: "bot-id"}
+	p.setConfiguration(testConfig)
+	scheduler := &testJobScheduler{}
+	p.jobScheduler = scheduler
+
+	now := time.Now()
+	start := now.Add(5 * time.Minute).Unix()
+	nextStart := now.Add(24 * time.Hour).Unix()
+
+	meetingEntry, _ := json.Marshal(meetingChannelEntry{ChannelID: "channel-id", IsScheduled: true, PostID: "card-id"})
+	api.On("KVGet", "meeting_channel_123").Return(meetingEntry, nil)
+	settings, _ := json.Marshal(ZoomChannelSettingsMap{
+		"channel-id": {Preference: "default", ReminderMinutes: []int{15, 10}, RemindMembers: true},
+	})
+	api.On("KVGet", zoomChannelSettings).Return(settings, nil)
+	api.On("GetPost", "card-id").Return(&model.Post{Id: "card-id", ChannelId: "channel-id"}, nil)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == "channel-id" && post.RootId == "card-id" && strings.Contains(post.Message, "**Standup** starts in 10 minute(s)")
+	})).Return(&model.Post{}, nil).Once()
+	api.On("GetChannelMembers", "channel-id", 0, reminderMembersPerPage).Return(model.ChannelMembers{
+		{UserId: "bot-id"},
+		{UserId: "user-id"},
+	}, nil)
+	api.On("GetDirectChannel", "user-id", "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == "dm-id"
+	})).Return(&model.Post{}, nil).Once()
+	allowFlexibleLogging(api)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+
+	p.runScheduledJob(reminderJobKey(123, "channel-id", start, 10), map[string]any{
+		"meeting_id":   123,
+		"channel_id":   "channel-id",
+		"post_id":      "card-id",
+		"topic":        "Standup",
+		"join_url":     "https://zoom.us/j/123",
+		"starts":       []int64{start, nextStart},
+		"start":        start,
+		"lead_minutes": 10,
+	})
+
+	api.AssertExpectations(t)
+	require.Len(t, scheduler.scheduled, 1)
+	assert.Equal(t, reminderJobKey(123, "channel-id", nextStart, 15), scheduler.scheduled[0].key)
+	next := scheduler.scheduled[0].props.(reminderJob)
+	assert.Equal(t, []int64{nextStart}, next.Starts)
+	assert.Equal(t, "card-id", next.PostID)
+}
+
+func TestRunReminderJobSkipsRemappedMeeting(t *testing.T) {
+	api := &plugintest.API{}
+	p := Plugin{botUserID: "bot-id"}
+	p.setConfiguration(testConfig)
+	scheduler := &testJobScheduler{}
+	p.jobScheduler = scheduler
+
+	meetingEntry, _ := json.Marshal(meetingChannelEntry{ChannelID: "other-channel-id", IsSubscription: true})
+	api.On("KVGet", "meeting_channel_123").Return(meetingEntry, nil)
+	allowFlexibleLogging(api)
+	p.SetAPI(api)
+
+	p.runReminderJob(reminderJob{MeetingID: 123, ChannelID: "channel-id", Starts: []int64{time.Now().Add(time.Hour).Unix()}})
+
+	api.AssertNotCalled(t, "CreatePost", mock.Anything)
+	assert.Empty(t, scheduler.scheduled)
+}
@@ -0,0 +1,667 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	scheduleFieldTopic        = "topic"
+	scheduleFieldDate         = "start_date"
+	scheduleFieldTime         = "start_time"
+	scheduleFieldDuration     = "duration"
+	scheduleFieldTimezone     = "timezone"
+	scheduleFieldRecurrence   = "recurrence"
+	scheduleFieldInterval     = "repeat_interval"
+	scheduleFieldOccurrences  = "occurrences"
+	scheduleFieldRegistration = "registration"
+
+	recurrenceNone    = "none"
+	recurrenceDaily   = "daily"
+	recurrenceWeekly  = "weekly"
+	recurrenceMonthly = "monthly"
+
+	registrationAutomatic = "automatic"
+	registrationManual    = "manual"
+	registrationNone      = "none"
+
+	scheduleDateLayout      = "2006-01-02"
+	scheduleTimeLayout      = "15:04"
+	zoomStartTimeLayout     = "2006-01-02T15:04:05"
+	scheduledPostTimeLayout = "Mon Jan 2 15:04 MST 2006"
+
+	defaultScheduledMeetingDuration = 60
+	maxScheduledMeetingDuration     = 24 * 60
+	maxRecurrenceInterval           = 90
+	maxRecurrenceOccurrences        = 60
+)
+
+var scheduleRecurrenceTypes = map[string]zoom.RecurrenceType{
+	recurrenceDaily:   zoom.RecurrenceTypeDaily,
+	recurrenceWeekly:  zoom.RecurrenceTypeWeekly,
+	recurrenceMonthly: zoom.RecurrenceTypeMonthly,
+}
+
+// meetingApprovalTypes maps the registration options of the dialog to Zoom's approval types.
+var meetingApprovalTypes = map[string]int{
+	registrationAutomatic: zoom.MeetingApprovalAutomatic,
+	registrationManual:    zoom.MeetingApprovalManual,
+	registrationNone:      zoom.MeetingApprovalNoRegistration,
+}
+
+// scheduleDialogState is carried through the schedule dialog so the submission
+// handler knows where the command was run from.
+type scheduleDialogState struct {
+	RootID string `json:"root_id"`
+	// HostID is the user the meeting is scheduled for with the --for flag.
+	HostID string `json:"host_id,omitempty"`
+}
+
+// scheduledMeeting is a validated schedule dialog submission.
+type scheduledMeeting struct {
+	Topic      string
+	Start      time.Time
+	Duration   int
+	Timezone   string
+	Recurrence *zoom.MeetingRecurrence
+	// Registration is one of the registration options of the dialog.
+	Registration string
+	// Delegation is set when the meeting is scheduled for another user, who hosts it.
+	Delegation *meetingDelegation
+}
+
+// runScheduleCommand opens the dialog used to schedule a Zoom meeting in the current channel.
+func (p *Plugin) runScheduleCommand(args *model.CommandArgs, user *model.User) (string, error) {
+	restrict, err := p.isChannelRestrictedForMeetings(args.ChannelId)
+	if err != nil {
+		p.client.Log.Error("Unable to check channel preference", "ChannelID", args.ChannelId, "Error", err.Error())
+		return "Error occurred while scheduling meeting", nil
+	}
+
+	if restrict {
+		return "Creating Zoom meeting is disabled for this channel.", nil
+	}
+
+	if _, appErr := p.API.GetChannelMember(args.ChannelId, user.Id); appErr != nil {
+		return fmt.Sprintf("We could not get the channel members (channelId: %v)", args.ChannelId), nil
+	}
+
+	if _, authErr := p.authenticateAndFetchZoomUser(user); authErr != nil {
+		// the user state will be needed later while connecting the user to Zoom via OAuth
+		if appErr := p.storeOAuthUserState(user.Id, args.ChannelId, true); appErr != nil {
+			p.API.LogWarn("failed to store user state")
+		}
+		return authErr.Message, authErr.Err
+	}
+
+	dialogState := scheduleDialogState{RootID: args.RootId}
+	hostUsername, _, err := takeCommandFlag(strings.Join(strings.Fields(args.Command)[2:], " "), scheduleForFlag)
+	if err != nil {
+		return "Use `/zoom schedule --for @username` to schedule a meeting for another user.", nil
+	}
+	if hostUsername != "" {
+		delegation, message, err := p.resolveMeetingDelegation(user, hostUsername)
+		if message != "" {
+			return message, err
+		}
+		if delegation != nil {
+			dialogState.HostID = delegation.Host.Id
+		}
+	}
+
+	state, err := json.Marshal(dialogState)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode dialog state")
+	}
+
+	if appErr := p.API.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: args.TriggerId,
+		URL:       fmt.Sprintf("/plugins/%s%s", url.PathEscape(manifest.Id), pathScheduleMeeting),
+		Dialog:    p.getScheduleMeetingDialog(user, string(state)),
+	}); appErr != nil {
+		p.client.Log.Error("Failed to open the schedule meeting dialog", "Error", appErr.Error())
+		return "Unable to open the dialog for scheduling a meeting", nil
+	}
+
+	return "", nil
+}
+
+func (p *Plugin) getScheduleMeetingDialog(user *model.User, state string) model.Dialog {
+	return model.Dialog{
+		Title:       "Schedule a Zoom Meeting",
+		SubmitLabel: "Schedule",
+		State:       state,
+		Elements: append(scheduleTimeDialogElements(user, defaultMeetingTopic), []model.DialogElement{
+			{
+				DisplayName: "Repeat",
+				Name:        scheduleFieldRecurrence,
+				Type:        "select",
+				Default:     recurrenceNone,
+				Options: []*model.PostActionOptions{
+					{Text: "Does not repeat", Value: recurrenceNone},
+					{Text: "Daily", Value: recurrenceDaily},
+					{Text: "Weekly", Value: recurrenceWeekly},
+					{Text: "Monthly", Value: recurrenceMonthly},
+				},
+			},
+			{
+				DisplayName: "Repeat every",
+				Name:        scheduleFieldInterval,
+				Type:        "text",
+				SubType:     "number",
+				Default:     "1",
+				Optional:    true,
+				HelpText:    "Number of days, weeks or months between occurrences.",
+			},
+			{
+				DisplayName: "Number of occurrences",
+				Name:        scheduleFieldOccurrences,
+				Type:        "text",
+				SubType:     "number",
+				Optional:    true,
+				HelpText:    fmt.Sprintf("Required for repeating meetings, at most %d.", maxRecurrenceOccurrences),
+			},
+			registrationDialogElement(registrationNone),
+		}...),
+	}
+}
+
+// registrationDialogElement returns the dialog element choosing whether attendees register, and how they are approved.
+func registrationDialogElement(defaultValue string) model.DialogElement {
+	return model.DialogElement{
+		DisplayName: "Registration",
+		Name:        scheduleFieldRegistration,
+		Type:        "select",
+		Default:     defaultValue,
+		Options: []*model.PostActionOptions{
+			{Text: "Not required", Value: registrationNone},
+			{Text: "Required, approved automatically", Value: registrationAutomatic},
+			{Text: "Required, approved by the host", Value: registrationManual},
+		},
+	}
+}
+
+// scheduleTimeDialogElements returns the dialog elements for the topic and time of a meeting or webinar.
+func scheduleTimeDialogElements(user *model.User, topicPlaceholder string) []model.DialogElement {
+	timezone := user.GetPreferredTimezone()
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	return []model.DialogElement{
+		{
+			DisplayName: "Topic",
+			Name:        scheduleFieldTopic,
+			Type:        "text",
+			Placeholder: topicPlaceholder,
+			Optional:    true,
+			MaxLength:   200,
+		},
+		{
+			DisplayName: "Date",
+			Name:        scheduleFieldDate,
+			Type:        "text",
+			Placeholder: "YYYY-MM-DD",
+		},
+		{
+			DisplayName: "Start time",
+			Name:        scheduleFieldTime,
+			Type:        "text",
+			Placeholder: "HH:MM",
+			HelpText:    "24-hour clock, in the timezone below.",
+		},
+		{
+			DisplayName: "Duration (minutes)",
+			Name:        scheduleFieldDuration,
+			Type:        "text",
+			SubType:     "number",
+			Default:     strconv.Itoa(defaultScheduledMeetingDuration),
+		},
+		{
+			DisplayName: "Timezone",
+			Name:        scheduleFieldTimezone,
+			Type:        "text",
+			Default:     timezone,
+			HelpText:    "IANA timezone name, for example America/New_York.",
+		},
+	}
+}
+
+// parseScheduleSubmission validates the schedule dialog submission. Field level
+// problems are returned keyed by element name, ready for a SubmitDialogResponse.
+func parseScheduleSubmission(submission map[string]any, now time.Time) (*scheduledMeeting, map[string]string) {
+	fieldErrors := map[string]string{}
+	field := func(name string) string {
+		value, _ := submission[name].(string)
+		return strings.TrimSpace(value)
+	}
+
+	meeting := &scheduledMeeting{
+		Topic:        field(scheduleFieldTopic),
+		Timezone:     field(scheduleFieldTimezone),
+		Registration: field(scheduleFieldRegistration),
+	}
+	if meeting.Topic == "" {
+		meeting.Topic = defaultMeetingTopic
+	}
+	if meeting.Timezone == "" {
+		meeting.Timezone = "UTC"
+	}
+	if meeting.Registration == "" {
+		meeting.Registration = registrationNone
+	}
+	if _, ok := meetingApprovalTypes[meeting.Registration]; !ok {
+		fieldErrors[scheduleFieldRegistration] = "Unknown registration option."
+	}
+
+	location, err := time.LoadLocation(meeting.Timezone)
+	if err != nil {
+		fieldErrors[scheduleFieldTimezone] = "Unknown timezone."
+		location = time.UTC
+	}
+
+	date, err := time.ParseInLocation(scheduleDateLayout, field(scheduleFieldDate), location)
+	if err != nil {
+		fieldErrors[scheduleFieldDate] = "Use the format YYYY-MM-DD."
+	}
+	clock, err := time.Parse(scheduleTimeLayout, field(scheduleFieldTime))
+	if err != nil {
+		fieldErrors[scheduleFieldTime] = "Use the 24-hour format HH:MM."
+	}
+	if _, ok := fieldErrors[scheduleFieldDate]; !ok {
+		if _, ok := fieldErrors[scheduleFieldTime]; !ok {
+			meeting.Start = time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+			if !meeting.Start.After(now) {
+				fieldErrors[scheduleFieldTime] = "The meeting must start in the future."
+			}
+		}
+	}
+
+	meeting.Duration, err = strconv.Atoi(field(scheduleFieldDuration))
+	if err != nil || meeting.Duration <= 0 || meeting.Duration > maxScheduledMeetingDuration {
+		fieldErrors[scheduleFieldDuration] = fmt.Sprintf("Enter a number of minutes between 1 and %d.", maxScheduledMeetingDuration)
+	}
+
+	recurrence := field(scheduleFieldRecurrence)
+	if recurrence == "" || recurrence == recurrenceNone {
+		return meeting, fieldErrors
+	}
+
+	recurrenceType, ok := scheduleRecurrenceTypes[recurrence]
+	if !ok {
+		fieldErrors[scheduleFieldRecurrence] = "Unknown repeat option."
+		return meeting, fieldErrors
+	}
+
+	interval := 1
+	if raw := field(scheduleFieldInterval); raw != "" {
+		interval, err = strconv.Atoi(raw)
+		if err != nil || interval <= 0 || interval > maxRecurrenceInterval {
+			fieldErrors[scheduleFieldInterval] = fmt.Sprintf("Enter a number between 1 and %d.", maxRecurrenceInterval)
+		}
+	}
+
+	occurrences, err := strconv.Atoi(field(scheduleFieldOccurrences))
+	if err != nil || occurrences <= 0 || occurrences > maxRecurrenceOccurrences {
+		fieldErrors[scheduleFieldOccurrences] = fmt.Sprintf("Enter a number between 1 and %d.", maxRecurrenceOccurrences)
+	}
+
+	meeting.Recurrence = &zoom.MeetingRecurrence{
+		Type:           recurrenceType,
+		RepeatInterval: interval,
+		EndTimes:       occurrences,
+	}
+	switch recurrenceType {
+	case zoom.RecurrenceTypeWeekly:
+		// Zoom numbers week days from 1 (Sunday) to 7 (Saturday).
+		meeting.Recurrence.WeeklyDays = strconv.Itoa(int(meeting.Start.Weekday()) + 1)
+	case zoom.RecurrenceTypeMonthly:
+		meeting.Recurrence.MonthlyDay = meeting.Start.Day()
+	}
+
+	return meeting, fieldErrors
+}
+
+// createMeetingRequest builds the Zoom API request for the scheduled meeting.
+func (m *scheduledMeeting) createMeetingRequest() *zoom.CreateMeetingRequest {
+	meetingType := zoom.MeetingTypeScheduled
+	if m.Recurrence != nil {
+		meetingType = zoom.MeetingTypeRecurringWithFixedTime
+	}
+
+	request := &zoom.CreateMeetingRequest{
+		Topic:      m.Topic,
+		Type:       meetingType,
+		StartTime:  m.Start.Format(zoomStartTimeLayout),
+		Duration:   m.Duration,
+		Timezone:   m.Timezone,
+		Recurrence: m.Recurrence,
+	}
+	if m.Delegation != nil {
+		request.ScheduleFor = m.Delegation.HostEmail
+	}
+	request.Settings.ApprovalType = zoom.MeetingApprovalNoRegistration
+	if m.requiresRegistration() {
+		request.Settings.ApprovalType = meetingApprovalTypes[m.Registration]
+		request.Settings.RegistrationType = zoom.MeetingRegistrationOnce
+		request.Settings.RegistrantsConfirmationEmail = true
+		request.Settings.RegistrantsEmailNotification = true
+	}
+	return request
+}
+
+func (m *scheduledMeeting) requiresRegistration() bool {
+	return m.Registration != "" && m.Registration != registrationNone
+}
+
+// describeRecurrence returns a short human readable summary of a recurrence, e.g. "Every 2 weeks, 6 times".
+func describeRecurrence(recurrence *zoom.MeetingRecurrence) string {
+	if recurrence == nil {
+		return ""
+	}
+
+	unit := map[zoom.RecurrenceType]string{
+		zoom.RecurrenceTypeDaily:   "day",
+		zoom.RecurrenceTypeWeekly:  "week",
+		zoom.RecurrenceTypeMonthly: "month",
+	}[recurrence.Type]
+	if unit == "" {
+		return "Repeats"
+	}
+
+	every := "Every " + unit
+	if recurrence.RepeatInterval > 1 {
+		every = fmt.Sprintf("Every %d %ss", recurrence.RepeatInterval, unit)
+	}
+	if recurrence.EndTimes > 0 {
+		every += fmt.Sprintf(", %d times", recurrence.EndTimes)
+	}
+	return every
+}
+
+func (p *Plugin) handleScheduleMeeting(w http.ResponseWriter, r *http.Request) {
+	submitRequest, state, ok := p.decodeScheduleDialogSubmission(w, r)
+	if !ok {
+		return
+	}
+
+	response := p.scheduleMeetingFromDialog(submitRequest.UserId, submitRequest.ChannelId, state.RootID, state.HostID, submitRequest.Submission)
+	p.writeDialogResponse(w, response)
+}
+
+// decodeScheduleDialogSubmission reads the submission of a schedule or webinar dialog made by
+// the requesting user. It writes the response and returns false when the request is invalid or
+// the dialog was cancelled.
+func (p *Plugin) decodeScheduleDialogSubmission(w http.ResponseWriter, r *http.Request) (*model.SubmitDialogRequest, *scheduleDialogState, bool) {
+	submitRequest := &model.SubmitDialogRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&submitRequest); err != nil {
+		p.API.LogError("Error decoding dialog request", "Error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	if submitRequest == nil {
+		p.API.LogError("Empty dialog request body")
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" || userID != submitRequest.UserId {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
+	if submitRequest.Cancelled {
+		w.WriteHeader(http.StatusOK)
+		return nil, nil, false
+	}
+
+	var state scheduleDialogState
+	if submitRequest.State != "" {
+		if err := json.Unmarshal([]byte(submitRequest.State), &state); err != nil {
+			http.Error(w, "invalid dialog state", http.StatusBadRequest)
+			return nil, nil, false
+		}
+	}
+
+	return submitRequest, &state, true
+}
+
+func (p *Plugin) writeDialogResponse(w http.ResponseWriter, response *model.SubmitDialogResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
+
+func (p *Plugin) scheduleMeetingFromDialog(userID, channelID, rootID, hostID string, submission map[string]any) *model.SubmitDialogResponse {
+	meeting, fieldErrors := parseScheduleSubmission(submission, time.Now())
+	if len(fieldErrors) > 0 {
+		return &model.SubmitDialogResponse{Errors: fieldErrors}
+	}
+
+	restrict, err := p.isChannelRestrictedForMeetings(channelID)
+	if err != nil {
+		p.API.LogError("Unable to check channel preference", "ChannelID", channelID, "Error", err.Error())
+		return &model.SubmitDialogResponse{Error: "Error occurred while scheduling meeting"}
+	}
+	if restrict {
+		return &model.SubmitDialogResponse{Error: "Creating Zoom meeting is disabled for this channel."}
+	}
+
+	if _, appErr := p.API.GetChannelMember(channelID, userID); appErr != nil {
+		return &model.SubmitDialogResponse{Error: "You are not a member of this channel."}
+	}
+
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return &model.SubmitDialogResponse{Error: "Unable to find your user account."}
+	}
+
+	zoomUser, authErr := p.authenticateAndFetchZoomUser(user)
+	if authErr != nil {
+		p.API.LogWarn("failed to authenticate the Zoom user", "error", authErr.Error())
+		return &model.SubmitDialogResponse{Error: "Unable to connect to Zoom. Run `/zoom connect` and try again."}
+	}
+
+	if hostID != "" {
+		// The delegation is checked again, as it may have been revoked while the dialog was open.
+		host, appErr := p.API.GetUser(hostID)
+		if appErr != nil {
+			return &model.SubmitDialogResponse{Error: "Unable to find the host of the meeting."}
+		}
+		delegation, message, err := p.resolveMeetingDelegation(user, host.Username)
+		if err != nil {
+			p.API.LogWarn("failed to check the meeting delegation", "error", err.Error())
+		}
+		if message != "" {
+			return &model.SubmitDialogResponse{Error: message}
+		}
+		meeting.Delegation = delegation
+	}
+
+	if err := p.scheduleMeeting(user, zoomUser, channelID, rootID, meeting); err != nil {
+		p.API.LogWarn("failed to schedule the meeting", "error", err.Error())
+		return &model.SubmitDialogResponse{Error: "Zoom could not schedule the meeting. Please try again."}
+	}
+
+	return &model.SubmitDialogResponse{}
+}
+
+// scheduleMeeting creates the meeting in Zoom, posts the scheduled card and maps the
+// meeting to the channel so meeting.started updates that card.
+func (p *Plugin) scheduleMeeting(user *model.User, zoomUser *zoom.User, channelID, rootID string, meeting *scheduledMeeting) error {
+	client, _, err := p.getActiveClient(user)
+	if err != nil {
+		return errors.Wrap(err, "could not get the active Zoom client")
+	}
+
+	request := meeting.createMeetingRequest()
+	if err = p.addAlternativeHosts(zoomUser, channelID, request); err != nil {
+		return err
+	}
+	violations, err := p.enforceSecurityPolicy(channelID, request)
+	if err != nil {
+		return err
+	}
+
+	created, err := client.CreateMeeting(zoomUser, request)
+	if err != nil {
+		return errors.Wrap(err, "could not create the Zoom meeting")
+	}
+	p.postSecurityPolicyNote(user.Id, channelID, rootID, "meeting", violations)
+
+	post, err := p.postScheduledMeeting(user, created, meeting, channelID, rootID)
+	if err != nil {
+		return errors.Wrap(err, "could not post the scheduled meeting")
+	}
+
+	if err := p.storeScheduledMeetingForChannel(created.ID, channelID, post.Id, user.Id, scheduledMeetingEntryTTL(created, meeting, time.Now())); err != nil {
+		p.API.LogWarn("failed to store channel for scheduled meeting", "meeting_id", created.ID, "error", err.Error())
+	}
+
+	p.scheduleMeetingReminders(created, channelID, post.Id)
+
+	return nil
+}
+
+func (p *Plugin) postScheduledMeeting(creator *model.User, created *zoom.Meeting, meeting *scheduledMeeting, channelID, rootID string) (*model.Post, error) {
+	meetingURL := created.JoinURL
+	if meetingURL == "" {
+		meetingURL = fmt.Sprintf("%s/j/%v", p.getZoomURL(), created.ID)
+	}
+
+	startText := meeting.Start.Format(scheduledPostTimeLayout)
+	details := fmt.Sprintf("Starts: %s\n\nDuration: %d minute(s)", startText, meeting.Duration)
+	if recurrence := describeRecurrence(meeting.Recurrence); recurrence != "" {
+		details += "\n\nRepeats: " + recurrence
+	}
+	if meeting.requiresRegistration() {
+		details += "\n\nRegistration required"
+	}
+	if meeting.Delegation != nil {
+		details += fmt.Sprintf("\n\nHost: @%s, scheduled by @%s", meeting.Delegation.Host.Username, creator.Username)
+	}
+	alternativeHosts := p.alternativeHostNames(created.Settings.AlternativeHosts)
+	if len(alternativeHosts) > 0 {
+		details += "\n\nAlternative hosts: " + strings.Join(alternativeHosts, ", ")
+	}
+
+	slackAttachment := model.SlackAttachment{
+		Fallback: fmt.Sprintf("Video Meeting scheduled for %s at [%d](%s).\n\n[Join Meeting](%s)", startText, created.ID, meetingURL, meetingURL),
+		Title:    meeting.Topic,
+		Text:     fmt.Sprintf("Meeting ID: [%d](%s)\n\n%s\n\n[Join Meeting](%s)", created.ID, meetingURL, details, meetingURL),
+	}
+
+	post := &model.Post{
+		UserId:    creator.Id,
+		ChannelId: channelID,
+		RootId:    rootID,
+		Message:   "I have scheduled a meeting",
+		Type:      "custom_zoom",
+		Props: map[string]interface{}{
+			"attachments":              []*model.SlackAttachment{&slackAttachment},
+			"meeting_id":               created.ID,
+			"meeting_uuid":             created.UUID,
+			"meeting_link":             meetingURL,
+			"meeting_status":           zoom.MeetingStatusScheduled,
+			"meeting_personal":         false,
+			"meeting_topic":            meeting.Topic,
+			"meeting_creator_username": creator.Username,
+			"meeting_provider":         zoomProviderName,
+			"meeting_start_time":       meeting.Start.UnixMilli(),
+			"meeting_duration":         meeting.Duration,
+			"meeting_timezone":         meeting.Timezone,
+			"meeting_recurrence":       describeRecurrence(meeting.Recurrence),
+			"meeting_registration":     meeting.requiresRegistration(),
+		},
+	}
+	if meeting.requiresRegistration() {
+		post.Props["meeting_registration_approval"] = meeting.Registration
+	}
+	if len(alternativeHosts) > 0 {
+		post.Props["meeting_alternative_hosts"] = alternativeHosts
+	}
+	if meeting.Delegation != nil {
+		for key, value := range meeting.Delegation.postProps(creator) {
+			post.Props[key] = value
+		}
+	}
+
+	createdPost, appErr := p.API.CreatePost(post)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return createdPost, nil
+}
+
+// startScheduledMeetingPost flips a scheduled card to started. It returns false when the
+// card can't be reused, e.g. a later occurrence of a recurring meeting whose card already ended.
+func (p *Plugin) startScheduledMeetingPost(postID, meetingUUID string) bool {
+	post, err := p.client.Post.GetPost(postID)
+	if err != nil {
+		p.API.LogWarn("could not get the scheduled meeting post", "post_id", postID, "error", err.Error())
+		return false
+	}
+	if post.DeleteAt != 0 || post.Props["meeting_status"] == zoom.WebhookStatusEnded {
+		return false
+	}
+
+	if post.Props["meeting_status"] == zoom.MeetingStatusScheduled {
+		post.Message = "I have started a meeting"
+		post.Props["meeting_status"] = zoom.WebhookStatusStarted
+		if meetingUUID != "" {
+			post.Props["meeting_uuid"] = meetingUUID
+		}
+		if err := p.client.Post.UpdatePost(post); err != nil {
+			p.API.LogWarn("could not update the scheduled meeting post", "post_id", postID, "error", err.Error())
+			return false
+		}
+	}
+
+	if meetingUUID != "" {
+		if appErr := p.storeMeetingPostID(meetingUUID, postID); appErr != nil {
+			p.API.LogWarn("failed to store UUID mapping for scheduled post", "error", appErr.Error())
+		}
+	}
+
+	return true
+}
+
+// scheduledMeetingEntryTTL keeps the channel mapping until a day after the last occurrence
+// ends, leaving room for recording webhooks. Zero means the end is unknown and the entry
+// does not expire.
+func scheduledMeetingEntryTTL(created *zoom.Meeting, meeting *scheduledMeeting, now time.Time) int64 {
+	last := meeting.Start
+	if meeting.Recurrence != nil {
+		if len(created.Occurrences) == 0 {
+			return 0
+		}
+		occurrenceStart, err := time.Parse(time.RFC3339, created.Occurrences[len(created.Occurrences)-1].StartTime)
+		if err != nil {
+			return 0
+		}
+		last = occurrenceStart
+	}
+
+	expireAt := last.Add(time.Duration(meeting.Duration)*time.Minute + adHocMeetingChannelTTL*time.Second)
+	ttl := int64(expireAt.Sub(now).Seconds())
+	if ttl <= 0 {
+		return adHocMeetingChannelTTL
+	}
+	return ttl
+}
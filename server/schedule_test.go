@@ -0,0 +1,152 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestParseScheduleSubmission(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("single meeting", func(t *testing.T) {
+		meeting, fieldErrors := parseScheduleSubmission(map[string]any{
+			scheduleFieldTopic:    "Planning",
+			scheduleFieldDate:     "2026-03-04",
+			scheduleFieldTime:     "14:30",
+			scheduleFieldDuration: "45",
+			scheduleFieldTimezone: "Europe/Berlin",
+		}, now)
+		require.Empty(t, fieldErrors)
+
+		request := meeting.createMeetingRequest()
+		assert.Equal(t, zoom.MeetingTypeScheduled, request.Type)
+		assert.Equal(t, "Planning", request.Topic)
+		assert.Equal(t, "2026-03-04T14:30:00", request.StartTime)
+		assert.Equal(t, "Europe/Berlin", request.Timezone)
+		assert.Equal(t, 45, request.Duration)
+		assert.Nil(t, request.Recurrence)
+	})
+
+	t.Run("weekly recurrence uses the start week day", func(t *testing.T) {
+		meeting, fieldErrors := parseScheduleSubmission(map[string]any{
+			scheduleFieldDate:        "2026-03-04",
+			scheduleFieldTime:        "10:00",
+			scheduleFieldDuration:    "30",
+			scheduleFieldRecurrence:  recurrenceWeekly,
+			scheduleFieldInterval:    "2",
+			scheduleFieldOccurrences: "6",
+		}, now)
+		require.Empty(t, fieldErrors)
+
+		request := meeting.createMeetingRequest()
+		assert.Equal(t, defaultMeetingTopic, request.Topic)
+		assert.Equal(t, zoom.MeetingTypeRecurringWithFixedTime, request.Type)
+		require.NotNil(t, request.Recurrence)
+		assert.Equal(t, zoom.RecurrenceTypeWeekly, request.Recurrence.Type)
+		assert.Equal(t, 2, request.Recurrence.RepeatInterval)
+		assert.Equal(t, 6, request.Recurrence.EndTimes)
+		assert.Equal(t, "4", request.Recurrence.WeeklyDays) // Wednesday
+		assert.Equal(t, "Every 2 weeks, 6 times", describeRecurrence(request.Recurrence))
+	})
+
+	t.Run("registration sets the approval type", func(t *testing.T) {
+		meeting, fieldErrors := parseScheduleSubmission(map[string]any{
+			scheduleFieldDate:         "2026-03-04",
+			scheduleFieldTime:         "10:00",
+			scheduleFieldDuration:     "30",
+			scheduleFieldRegistration: registrationManual,
+		}, now)
+		require.Empty(t, fieldErrors)
+
+		request := meeting.createMeetingRequest()
+		assert.Equal(t, zoom.MeetingApprovalManual, request.Settings.ApprovalType)
+		assert.Equal(t, zoom.MeetingRegistrationOnce, request.Settings.RegistrationType)
+
+		meeting.Registration = registrationNone
+		assert.Equal(t, zoom.MeetingApprovalNoRegistration, meeting.createMeetingRequest().Settings.ApprovalType)
+
+		_, fieldErrors = parseScheduleSubmission(map[string]any{scheduleFieldRegistration: "sometimes"}, now)
+		assert.Contains(t, fieldErrors, scheduleFieldRegistration)
+	})
+
+	t.Run("invalid fields are reported individually", func(t *testing.T) {
+		_, fieldErrors := parseScheduleSubmission(map[string]any{
+			scheduleFieldDate:       "04/03/2026",
+			scheduleFieldTime:       "2pm",
+			scheduleFieldDuration:   "0",
+			scheduleFieldTimezone:   "Mars/Olympus",
+			scheduleFieldRecurrence: recurrenceMonthly,
+		}, now)
+		assert.Contains(t, fieldErrors, scheduleFieldDate)
+		assert.Contains(t, fieldErrors, scheduleFieldTime)
+		assert.Contains(t, fieldErrors, scheduleFieldDuration)
+		assert.Contains(t, fieldErrors, scheduleFieldTimezone)
+		assert.Contains(t, fieldErrors, scheduleFieldOccurrences)
+	})
+
+	t.Run("start in the past is rejected", func(t *testing.T) {
+		_, fieldErrors := parseScheduleSubmission(map[string]any{
+			scheduleFieldDate:     "2026-03-01",
+			scheduleFieldTime:     "10:00",
+			scheduleFieldDuration: "30",
+		}, now)
+		assert.Equal(t, "The meeting must start in the future.", fieldErrors[scheduleFieldTime])
+	})
+}
+
+func TestScheduledMeetingEntryTTL(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	meeting := &scheduledMeeting{Start: now.Add(2 * time.Hour), Duration: 60}
+
+	ttl := scheduledMeetingEntryTTL(&zoom.Meeting{}, meeting, now)
+	assert.Equal(t, int64((3*time.Hour).Seconds())+adHocMeetingChannelTTL, ttl)
+
+	meeting.Recurrence = &zoom.MeetingRecurrence{Type: zoom.RecurrenceTypeDaily, EndTimes: 3}
+	assert.Equal(t, int64(0), scheduledMeetingEntryTTL(&zoom.Meeting{}, meeting, now))
+}
+
+func TestHandleMeetingStartedUpdatesScheduledPost(t *testing.T) {
+	api := &plugintest.API{}
+	p := Plugin{}
+	p.setConfiguration(testConfig)
+
+	api.On("GetLicense").Return(nil)
+	meetingEntry, _ := json.Marshal(meetingChannelEntry{
+		ChannelID:   "channel-id",
+		CreatedBy:   "user-id",
+		IsScheduled: true,
+		PostID:      "scheduled-post-id",
+	})
+	api.On("KVGet", "meeting_channel_123").Return(meetingEntry, nil)
+	api.On("GetPost", "scheduled-post-id").Return(&model.Post{
+		Id:        "scheduled-post-id",
+		ChannelId: "channel-id",
+		Props:     model.StringInterface{"meeting_status": zoom.MeetingStatusScheduled},
+	}, nil)
+	api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.Props["meeting_status"] == zoom.WebhookStatusStarted && post.Props["meeting_uuid"] == "abc"
+	})).Return(&model.Post{}, nil).Once()
+	api.On("KVSetWithExpiry", "post_meeting_abc", []byte("scheduled-post-id"), int64(meetingPostIDTTL)).Return(nil).Once()
+	allowFlexibleLogging(api)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+
+	w := sendSignedWebhook(t, &p, `{"payload":{"object": {"id": "123", "uuid": "abc"}},"event":"meeting.started"}`)
+	require.Equal(t, 200, w.Result().StatusCode)
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "CreatePost", mock.Anything)
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	// meetingPasscodeLength stays within the 10 characters Zoom allows, and is numeric to
+	// satisfy accounts that only accept numeric passcodes.
+	meetingPasscodeLength = 8
+
+	policyViolationPasscode       = "passcode is not set"
+	policyViolationWaitingRoom    = "waiting room is off"
+	policyViolationAuthentication = "authenticated join is off"
+)
+
+// policyEnforcements describes, per violation, the setting the policy enforced in its place.
+var policyEnforcements = map[string]string{
+	policyViolationPasscode:       "a passcode was added",
+	policyViolationWaitingRoom:    "the waiting room was turned on",
+	policyViolationAuthentication: "authenticated join was turned on",
+}
+
+// securityPolicy is the set of settings required of meetings created in channels that enforce
+// the security policy. The admin sets the requirements in the plugin settings, and turns the
+// policy on per channel with `/zoom channel-settings`.
+type securityPolicy struct {
+	RequirePasscode       bool
+	RequireWaitingRoom    bool
+	RequireAuthentication bool
+}
+
+// getSecurityPolicy returns the policy enforced in a channel, or nil when the channel doesn't
+// enforce one or the admin didn't require any setting.
+func (p *Plugin) getSecurityPolicy(channelID string) (*securityPolicy, error) {
+	config := p.getConfiguration()
+	policy := &securityPolicy{
+		RequirePasscode:       config.RequireMeetingPasscode,
+		RequireWaitingRoom:    config.RequireWaitingRoom,
+		RequireAuthentication: config.RequireAuthenticatedJoin,
+	}
+	if !policy.RequirePasscode && !policy.RequireWaitingRoom && !policy.RequireAuthentication {
+		return nil, nil
+	}
+
+	settings, err := p.listZoomChannelSettings()
+	if err != nil {
+		return nil, err
+	}
+	if !settings[channelID].EnforceSecurityPolicy {
+		return nil, nil
+	}
+	return policy, nil
+}
+
+// violations returns the required settings missing from a meeting, in the words shown to users.
+func (s *securityPolicy) violations(passcode string, waitingRoom, authentication bool) []string {
+	var violations []string
+	if s.RequirePasscode && passcode == "" {
+		violations = append(violations, policyViolationPasscode)
+	}
+	if s.RequireWaitingRoom && !waitingRoom {
+		violations = append(violations, policyViolationWaitingRoom)
+	}
+	if s.RequireAuthentication && !authentication {
+		violations = append(violations, policyViolationAuthentication)
+	}
+	return violations
+}
+
+// enforce validates a meeting request against the policy and overrides the settings that
+// violate it. It returns the violations it fixed.
+func (s *securityPolicy) enforce(request *zoom.CreateMeetingRequest) ([]string, error) {
+	violations := s.violations(request.Password, request.Settings.WaitingRoom, request.Settings.MeetingAuthentication)
+	passcode, err := s.passcode(request.Password)
+	if err != nil {
+		return nil, err
+	}
+	request.Password = passcode
+	if s.RequireWaitingRoom {
+		request.Settings.WaitingRoom = true
+		// Zoom ignores the waiting room of meetings participants can join before the host.
+		request.Settings.JoinBeforeHost = false
+	}
+	if s.RequireAuthentication {
+		request.Settings.MeetingAuthentication = true
+	}
+	return violations, nil
+}
+
+// enforceWebinar validates a webinar request against the policy and overrides the settings that
+// violate it. It returns the violations it fixed. Webinars have no waiting room, attendees can't
+// join before the host starts the webinar, so the waiting room requirement doesn't apply to them.
+func (s *securityPolicy) enforceWebinar(request *zoom.CreateWebinarRequest) ([]string, error) {
+	violations := s.violations(request.Password, true, request.Settings.MeetingAuthentication)
+	passcode, err := s.passcode(request.Password)
+	if err != nil {
+		return nil, err
+	}
+	request.Password = passcode
+	if s.RequireAuthentication {
+		request.Settings.MeetingAuthentication = true
+	}
+	return violations, nil
+}
+
+// passcode returns the passcode a meeting gets under the policy, generating one when the policy
+// requires a passcode and the meeting has none.
+func (s *securityPolicy) passcode(current string) (string, error) {
+	if !s.RequirePasscode || current != "" {
+		return current, nil
+	}
+	passcode, err := generateMeetingPasscode()
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate a meeting passcode")
+	}
+	return passcode, nil
+}
+
+// enforceSecurityPolicy applies the security policy of a channel, if any, to a new meeting. It
+// returns the violations it fixed, to be reported with postSecurityPolicyNote once the meeting
+// is created.
+func (p *Plugin) enforceSecurityPolicy(channelID string, request *zoom.CreateMeetingRequest) ([]string, error) {
+	policy, err := p.getSecurityPolicy(channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the security policy of the channel")
+	}
+	if policy == nil {
+		return nil, nil
+	}
+	return policy.enforce(request)
+}
+
+// enforceWebinarSecurityPolicy applies the security policy of a channel, if any, to a new webinar.
+func (p *Plugin) enforceWebinarSecurityPolicy(channelID string, request *zoom.CreateWebinarRequest) ([]string, error) {
+	policy, err := p.getSecurityPolicy(channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the security policy of the channel")
+	}
+	if policy == nil {
+		return nil, nil
+	}
+	return policy.enforceWebinar(request)
+}
+
+// postSecurityPolicyNote tells the creator of a meeting which of its settings the security
+// policy of the channel overrode. kind is "meeting" or "webinar".
+func (p *Plugin) postSecurityPolicyNote(userID, channelID, rootID, kind string, violations []string) {
+	if len(violations) == 0 {
+		return
+	}
+
+	enforced := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		enforced = append(enforced, policyEnforcements[violation])
+	}
+	p.postEphemeral(userID, channelID, rootID, fmt.Sprintf("The settings of your %s were changed to comply with the security policy of this channel: %s.", kind, strings.Join(enforced, ", ")))
+}
+
+// checkPMISecurityPolicy returns the message telling a user why their Personal Meeting ID can't
+// be used in a channel, or an empty string when it complies with the channel's security policy.
+func (p *Plugin) checkPMISecurityPolicy(user *model.User, channelID string, pmi int) string {
+	policy, err := p.getSecurityPolicy(channelID)
+	if err != nil {
+		p.API.LogWarn("Could not get the security policy of the channel", "channel_id", channelID, "error", err.Error())
+		return "Unable to check the security policy of this channel. Please try again."
+	}
+	if policy == nil {
+		return ""
+	}
+
+	meeting, err := p.getMeeting(user, pmi)
+	if err != nil {
+		p.API.LogWarn("Could not get the Personal Meeting ID settings", "user_id", user.Id, "error", err.Error())
+		return "Unable to check the settings of your Personal Meeting ID against the security policy of this channel. Please try again."
+	}
+
+	violations := policy.violations(meeting.Password, meeting.Settings.WaitingRoom, meeting.Settings.MeetingAuthentication)
+	if len(violations) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Your Personal Meeting ID can't be used in this channel because it doesn't comply with the security policy: %s. Update it in your [Zoom settings](%s/profile/setting), or switch to a unique meeting ID with `/zoom settings`.", strings.Join(violations, ", "), p.getZoomURL())
+}
+
+// generateMeetingPasscode returns a random numeric passcode.
+func generateMeetingPasscode() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < meetingPasscodeLength; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(digit.String())
+	}
+	return sb.String(), nil
+}
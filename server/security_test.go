@@ -0,0 +1,107 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestSecurityPolicyEnforce(t *testing.T) {
+	policy := &securityPolicy{RequirePasscode: true, RequireWaitingRoom: true, RequireAuthentication: true}
+
+	request := &zoom.CreateMeetingRequest{Topic: "Interview"}
+	request.Settings.JoinBeforeHost = true
+	violations, err := policy.enforce(request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{policyViolationPasscode, policyViolationWaitingRoom, policyViolationAuthentication}, violations)
+	assert.Regexp(t, `^[0-9]{8}$`, request.Password)
+	assert.True(t, request.Settings.WaitingRoom)
+	assert.False(t, request.Settings.JoinBeforeHost)
+	assert.True(t, request.Settings.MeetingAuthentication)
+
+	request = &zoom.CreateMeetingRequest{Password: "secret"}
+	violations, err = (&securityPolicy{RequirePasscode: true}).enforce(request)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+	assert.Equal(t, "secret", request.Password)
+	assert.False(t, request.Settings.WaitingRoom)
+}
+
+func TestSecurityPolicyEnforceWebinar(t *testing.T) {
+	policy := &securityPolicy{RequirePasscode: true, RequireWaitingRoom: true, RequireAuthentication: true}
+
+	request := &zoom.CreateWebinarRequest{Topic: "Town hall"}
+	violations, err := policy.enforceWebinar(request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{policyViolationPasscode, policyViolationAuthentication}, violations)
+	assert.Regexp(t, `^[0-9]{8}$`, request.Password)
+	assert.True(t, request.Settings.MeetingAuthentication)
+}
+
+func TestPostSecurityPolicyNote(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("SendEphemeralPost", "user-id", mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == "channel-id" && post.RootId == "root-id" &&
+			post.Message == "The settings of your webinar were changed to comply with the security policy of this channel: a passcode was added, authenticated join was turned on."
+	})).Return(nil).Once()
+	p := webinarTestPlugin(api)
+
+	p.postSecurityPolicyNote("user-id", "channel-id", "root-id", "webinar", nil)
+	p.postSecurityPolicyNote("user-id", "channel-id", "root-id", "webinar", []string{policyViolationPasscode, policyViolationAuthentication})
+	api.AssertExpectations(t)
+}
+
+func TestCheckPMISecurityPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/meetings/123" {
+			http.NotFound(w, r)
+			return
+		}
+		meeting := zoom.Meeting{ID: 123}
+		meeting.Settings.WaitingRoom = true
+		require.NoError(t, json.NewEncoder(w).Encode(meeting))
+	}))
+	defer ts.Close()
+
+	config := *testConfig
+	config.EncryptionKey = "4Su-mLR7N6VwC6aXjYhQoT0shtS9fKz+"
+	config.ZoomAPIURL = ts.URL
+	config.RequireMeetingPasscode = true
+	config.RequireWaitingRoom = true
+
+	accessToken, err := encryptLegacy([]byte(config.EncryptionKey), "token")
+	require.NoError(t, err)
+	userInfo, err := json.Marshal(zoom.OAuthUserInfo{OAuthToken: &oauth2.Token{AccessToken: accessToken, Expiry: time.Now().Add(time.Hour)}})
+	require.NoError(t, err)
+	settings, err := json.Marshal(ZoomChannelSettingsMap{"channel-id": {EnforceSecurityPolicy: true}})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", zoomChannelSettings).Return(settings, nil)
+	api.On("KVGet", zoomUserByMMID+"user-id").Return(userInfo, nil)
+	p := webinarTestPlugin(api)
+	p.setConfiguration(&config)
+	user := &model.User{Id: "user-id"}
+
+	message := p.checkPMISecurityPolicy(user, "channel-id", 123)
+	assert.Contains(t, message, "doesn't comply with the security policy: passcode is not set.")
+	assert.NotContains(t, message, policyViolationWaitingRoom)
+
+	assert.Empty(t, p.checkPMISecurityPolicy(user, "other-id", 123))
+}
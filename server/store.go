@@ -19,6 +19,7 @@ import (
 const (
 	postMeetingKey        = "post_meeting_"
 	meetingChannelKey     = "meeting_channel_"
+	webinarChannelKey     = "webinar_channel_"
 	zoomStateKeyPrefix    = "zoomuserstate"
 	zoomUserByMMID        = "zoomtoken_"
 	zoomUserByZoomID      = "zoomtokenbyzoomid_"
@@ -32,22 +33,40 @@ const (
 
 type ZoomChannelSettingsMapValue struct {
 	Preference string
+
+	// ReminderMinutes overrides the plugin-wide reminder lead times for the channel.
+	// RemindersDisabled turns reminders off, and RemindMembers also DMs the channel members.
+	ReminderMinutes   []int `json:",omitempty"`
+	RemindersDisabled bool  `json:",omitempty"`
+	RemindMembers     bool  `json:",omitempty"`
+
+	// RecordingPolicy overrides the plugin-wide recording policy for the kinds it sets.
+	RecordingPolicy recordingPolicy `json:",omitempty"`
+
+	// DefaultTemplate names the meeting template applied to meetings started in the channel.
+	DefaultTemplate string `json:",omitempty"`
+
+	// EnforceSecurityPolicy applies the security policy of the plugin settings to meetings created in the channel.
+	EnforceSecurityPolicy bool `json:",omitempty"`
+
+	// AlternativeHosts chooses who becomes an alternative host of the channel's meetings: nobody,
+	// the channel admins, or the users of AlternativeHostUserIDs.
+	AlternativeHosts       string   `json:",omitempty"`
+	AlternativeHostUserIDs []string `json:",omitempty"`
 }
 
 type ZoomChannelSettingsMap map[string]ZoomChannelSettingsMapValue
 
+// storeOAuthUserInfo stores the info of a user, holding the token refresh mutex of the user so it
+// doesn't race with a refresh of the token.
 func (p *Plugin) storeOAuthUserInfo(info *zoom.OAuthUserInfo) error {
-	config := p.getConfiguration()
-
-	encryptedToken, err := encrypt([]byte(config.EncryptionKey), info.OAuthToken.AccessToken)
+	mutex, err := p.lockTokenRefresh(info.UserID)
 	if err != nil {
-		return errors.Wrap(err, "could not encrypt OAuth token")
+		return err
 	}
+	defer mutex.Unlock()
 
-	original := info.OAuthToken.AccessToken
-	info.OAuthToken.AccessToken = encryptedToken
-
-	encoded, err := json.Marshal(info)
+	encoded, err := p.encodeOAuthUserInfo(info)
 	if err != nil {
 		return err
 	}
@@ -60,24 +79,86 @@ func (p *Plugin) storeOAuthUserInfo(info *zoom.OAuthUserInfo) error {
 		return err
 	}
 
-	info.OAuthToken.AccessToken = original
 	return nil
 }
 
-func (p *Plugin) fetchOAuthUserInfo(tokenKey, userID string) (*zoom.OAuthUserInfo, error) {
-	config := p.getConfiguration()
+// compareAndStoreOAuthUserInfo stores the info of a user only if the stored value is still
+// oldValue, and reports whether it did. The token refresh mutex of the user must be held.
+func (p *Plugin) compareAndStoreOAuthUserInfo(info *zoom.OAuthUserInfo, oldValue []byte) (bool, error) {
+	encoded, err := p.encodeOAuthUserInfo(info)
+	if err != nil {
+		return false, err
+	}
+
+	ok, appErr := p.API.KVSetWithOptions(zoomUserByMMID+info.UserID, encoded, model.PluginKVSetOptions{
+		Atomic:   true,
+		OldValue: oldValue,
+	})
+	if appErr != nil {
+		return false, appErr
+	}
+	if !ok {
+		return false, nil
+	}
+
+	if appErr := p.API.KVSet(zoomUserByZoomID+info.ZoomID, encoded); appErr != nil {
+		return true, appErr
+	}
+	return true, nil
+}
+
+// encodeOAuthUserInfo encodes the info of a user for the KV store, sealed with the current
+// encryption key.
+func (p *Plugin) encodeOAuthUserInfo(info *zoom.OAuthUserInfo) ([]byte, error) {
+	plaintext, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
 
+	encoded, err := p.sealValue(plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt OAuth token")
+	}
+	return encoded, nil
+}
+
+func (p *Plugin) fetchOAuthUserInfo(tokenKey, userID string) (*zoom.OAuthUserInfo, error) {
 	encoded, appErr := p.API.KVGet(tokenKey + userID)
 	if appErr != nil || encoded == nil {
 		return nil, errors.New("must connect user account to Zoom first")
 	}
 
+	return p.decodeOAuthUserInfo(encoded)
+}
+
+// decodeOAuthUserInfo decodes the info of a user read from the KV store. Info stored before
+// tokens were sealed has only its access token encrypted.
+func (p *Plugin) decodeOAuthUserInfo(encoded []byte) (*zoom.OAuthUserInfo, error) {
+	sealed, ok := parseSealedValue(encoded)
+	if !ok {
+		return p.decodeLegacyOAuthUserInfo(encoded)
+	}
+
+	plaintext, err := p.openValue(sealed)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt OAuth token")
+	}
+
+	var info zoom.OAuthUserInfo
+	if err := json.Unmarshal(plaintext, &info); err != nil || info.OAuthToken == nil {
+		return nil, errors.New("could not parse OAuth access token")
+	}
+
+	return &info, nil
+}
+
+func (p *Plugin) decodeLegacyOAuthUserInfo(encoded []byte) (*zoom.OAuthUserInfo, error) {
 	var info zoom.OAuthUserInfo
-	if err := json.Unmarshal(encoded, &info); err != nil {
+	if err := json.Unmarshal(encoded, &info); err != nil || info.OAuthToken == nil {
 		return nil, errors.New("could not parse OAuth access token")
 	}
 
-	plainToken, err := decrypt([]byte(config.EncryptionKey), info.OAuthToken.AccessToken)
+	plainToken, err := p.decryptLegacyAccessToken(info.OAuthToken.AccessToken)
 	if err != nil {
 		return nil, errors.New("could not decrypt OAuth access token")
 	}
@@ -88,25 +169,38 @@ func (p *Plugin) fetchOAuthUserInfo(tokenKey, userID string) (*zoom.OAuthUserInf
 }
 
 func (p *Plugin) disconnectOAuthUser(userID string) error {
-	// according to the definition encoded would be nil
-	encoded, err := p.API.KVGet(zoomUserByMMID + userID)
+	mutex, err := p.lockTokenRefresh(userID)
 	if err != nil {
-		return errors.Wrap(err, "could not find OAuth user info")
+		return err
+	}
+	defer mutex.Unlock()
+
+	// according to the definition encoded would be nil
+	encoded, appErr := p.API.KVGet(zoomUserByMMID + userID)
+	if appErr != nil {
+		return errors.Wrap(appErr, "could not find OAuth user info")
 	}
 	if encoded == nil {
 		return errors.New("you are not connected to Zoom yet")
 	}
 
-	var info zoom.OAuthUserInfo
-	if err := json.Unmarshal(encoded, &info); err != nil {
-		return errors.Wrap(err, "could not decode OAuth user info")
-	}
-
-	appErr := p.API.KVDelete(zoomUserByMMID + userID)
+	appErr = p.API.KVDelete(zoomUserByMMID + userID)
 	if appErr != nil {
 		return appErr
 	}
 
+	if err := p.removeConnectedUsers(userID); err != nil {
+		p.API.LogWarn("could not update the connected users index", "user_id", userID, "error", err.Error())
+	}
+
+	// Info sealed with a forgotten encryption key can't be decoded, but users must still be able
+	// to disconnect and connect again.
+	info, decodeErr := p.decodeOAuthUserInfo(encoded)
+	if decodeErr != nil {
+		p.API.LogWarn("could not decode the OAuth user info of a disconnected user", "user_id", userID, "error", decodeErr.Error())
+		return nil
+	}
+
 	appErr = p.API.KVDelete(zoomUserByZoomID + info.ZoomID)
 	if appErr != nil {
 		return appErr
@@ -171,11 +265,27 @@ func (p *Plugin) fetchMeetingPostID(meetingUUID string) (string, error) {
 	return string(postIDData), nil
 }
 
+// meetingSubscription is a channel subscribed to a meeting's events by CreatedBy.
+type meetingSubscription struct {
+	ChannelID string `json:"channel_id"`
+	CreatedBy string `json:"created_by"`
+}
+
 // meetingChannelEntry stores metadata about a meeting-to-channel mapping.
 type meetingChannelEntry struct {
 	ChannelID      string `json:"channel_id"`
 	IsSubscription bool   `json:"is_subscription"`
 	CreatedBy      string `json:"created_by"`
+
+	// Subscriptions lists every channel subscribed to the meeting. ChannelID and CreatedBy
+	// mirror the first subscription, and entries stored before multi-channel subscriptions
+	// are read as a single subscription.
+	Subscriptions []meetingSubscription `json:"subscriptions,omitempty"`
+
+	// IsScheduled marks meetings created via /zoom schedule. PostID points at the
+	// scheduled card that meeting.started should update instead of posting anew.
+	IsScheduled bool   `json:"is_scheduled,omitempty"`
+	PostID      string `json:"post_id,omitempty"`
 }
 
 // Ad-hoc meeting channel entries expire after 24 hours. This must be long
@@ -187,43 +297,149 @@ func meetingChannelKVKey(meetingID int) string {
 	return fmt.Sprintf("%v%v", meetingChannelKey, meetingID)
 }
 
-func (p *Plugin) storeSubscriptionForMeeting(meetingID int, channelID, userID string) error {
-	existing, appErr := p.getMeetingChannelEntry(meetingID)
-	if appErr != nil {
-		return appErr
+// subscription returns the subscription of channelID, or nil if the channel isn't subscribed.
+func (e *meetingChannelEntry) subscription(channelID string) *meetingSubscription {
+	for i := range e.Subscriptions {
+		if e.Subscriptions[i].ChannelID == channelID {
+			return &e.Subscriptions[i]
+		}
 	}
-	if existing != nil && existing.IsSubscription {
-		if existing.ChannelID == channelID && existing.CreatedBy == userID {
+	return nil
+}
+
+// hasChannel reports whether events of the meeting are posted to channelID.
+func (e *meetingChannelEntry) hasChannel(channelID string) bool {
+	if e.IsSubscription {
+		return e.subscription(channelID) != nil
+	}
+	return e.ChannelID == channelID
+}
+
+func decodeMeetingChannelEntry(raw []byte) (*meetingChannelEntry, error) {
+	var entry meetingChannelEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+	if entry.IsSubscription && len(entry.Subscriptions) == 0 && entry.ChannelID != "" {
+		entry.Subscriptions = []meetingSubscription{{ChannelID: entry.ChannelID, CreatedBy: entry.CreatedBy}}
+	}
+	return &entry, nil
+}
+
+const meetingSubscriptionMaxRetries = 5
+
+// updateMeetingSubscriptions applies mutate to the subscriptions of a meeting with a
+// compare-and-set write, so concurrent subscribes to different channels aren't lost.
+// Non-subscription entries are replaced by the first subscription. An entry left
+// without subscriptions is deleted.
+func (p *Plugin) updateMeetingSubscriptions(meetingID int, mutate func([]meetingSubscription) ([]meetingSubscription, error)) error {
+	key := meetingChannelKVKey(meetingID)
+
+	for i := 0; i < meetingSubscriptionMaxRetries; i++ {
+		oldRaw, appErr := p.API.KVGet(key)
+		if appErr != nil {
+			return appErr
+		}
+
+		var subscriptions []meetingSubscription
+		if oldRaw != nil {
+			existing, err := decodeMeetingChannelEntry(oldRaw)
+			if err != nil {
+				return errors.Wrap(err, "corrupted meeting channel entry")
+			}
+			if existing.IsSubscription {
+				subscriptions = existing.Subscriptions
+			}
+		}
+
+		updated, err := mutate(subscriptions)
+		if err != nil {
+			return err
+		}
+
+		var newRaw []byte
+		if len(updated) > 0 {
+			newRaw, err = json.Marshal(meetingChannelEntry{
+				ChannelID:      updated[0].ChannelID,
+				IsSubscription: true,
+				CreatedBy:      updated[0].CreatedBy,
+				Subscriptions:  updated,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(key, newRaw, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldRaw,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
 			return nil
 		}
-		return errors.New("meeting already has an existing subscription")
 	}
 
-	entry := meetingChannelEntry{
-		ChannelID:      channelID,
-		IsSubscription: true,
-		CreatedBy:      userID,
-	}
-	data, err := json.Marshal(entry)
-	if err != nil {
+	return errors.New("updateMeetingSubscriptions: too many concurrent updates")
+}
+
+func (p *Plugin) storeSubscriptionForMeeting(meetingID int, channelID, userID string) error {
+	added := false
+	err := p.updateMeetingSubscriptions(meetingID, func(subscriptions []meetingSubscription) ([]meetingSubscription, error) {
+		added = false
+		for _, subscription := range subscriptions {
+			if subscription.ChannelID != channelID {
+				continue
+			}
+			if subscription.CreatedBy == userID {
+				return subscriptions, nil
+			}
+			return nil, errors.New("channel is already subscribed to the meeting")
+		}
+		added = true
+		return append(subscriptions, meetingSubscription{ChannelID: channelID, CreatedBy: userID}), nil
+	})
+	if err != nil || !added {
 		return err
 	}
-	if appErr := p.API.KVSet(meetingChannelKVKey(meetingID), data); appErr != nil {
-		return appErr
-	}
 
 	if err := p.addToSubscriptionIndex(userID, meetingID); err != nil {
 		p.API.LogWarn("failed to update subscription index, rolling back",
 			"meeting_id", meetingID,
 			"error", err.Error(),
 		)
-		_ = p.deleteChannelForMeeting(meetingID)
+		_, _ = p.removeSubscriptionForMeeting(meetingID, channelID)
 		return errors.Wrap(err, "failed to update subscription index")
 	}
 
 	return nil
 }
 
+// removeSubscriptionForMeeting unsubscribes channelID from the meeting and returns the
+// removed subscription, or nil if the channel wasn't subscribed.
+func (p *Plugin) removeSubscriptionForMeeting(meetingID int, channelID string) (*meetingSubscription, error) {
+	var removed *meetingSubscription
+	err := p.updateMeetingSubscriptions(meetingID, func(subscriptions []meetingSubscription) ([]meetingSubscription, error) {
+		removed = nil
+		remaining := make([]meetingSubscription, 0, len(subscriptions))
+		for i := range subscriptions {
+			if subscriptions[i].ChannelID == channelID {
+				removed = &subscriptions[i]
+				continue
+			}
+			remaining = append(remaining, subscriptions[i])
+		}
+		return remaining, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
 func (p *Plugin) storeChannelForMeeting(meetingID int, channelID string) error {
 	key := meetingChannelKVKey(meetingID)
 
@@ -231,7 +447,7 @@ func (p *Plugin) storeChannelForMeeting(meetingID int, channelID string) error {
 	if appErr != nil {
 		return appErr
 	}
-	if existing != nil && existing.IsSubscription {
+	if existing != nil && (existing.IsSubscription || existing.IsScheduled) {
 		return nil
 	}
 
@@ -249,6 +465,34 @@ func (p *Plugin) storeChannelForMeeting(meetingID int, channelID string) error {
 	return nil
 }
 
+// storeScheduledMeetingForChannel maps a scheduled meeting to the channel and card it was
+// scheduled from. A zero ttl stores the entry without expiry. Subscriptions take precedence
+// and are never overwritten.
+func (p *Plugin) storeScheduledMeetingForChannel(meetingID int, channelID, postID, userID string, ttl int64) error {
+	existing, appErr := p.getMeetingChannelEntry(meetingID)
+	if appErr != nil {
+		return appErr
+	}
+	if existing != nil && existing.IsSubscription {
+		return nil
+	}
+
+	entry := meetingChannelEntry{
+		ChannelID:   channelID,
+		CreatedBy:   userID,
+		IsScheduled: true,
+		PostID:      postID,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, appErr := p.API.KVSetWithOptions(meetingChannelKVKey(meetingID), data, model.PluginKVSetOptions{ExpireInSeconds: ttl}); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
 func (p *Plugin) getMeetingChannelEntry(meetingID int) (*meetingChannelEntry, *model.AppError) {
 	key := meetingChannelKVKey(meetingID)
 	raw, appErr := p.API.KVGet(key)
@@ -259,8 +503,8 @@ func (p *Plugin) getMeetingChannelEntry(meetingID int) (*meetingChannelEntry, *m
 		return nil, nil
 	}
 
-	var entry meetingChannelEntry
-	if err := json.Unmarshal(raw, &entry); err != nil {
+	entry, err := decodeMeetingChannelEntry(raw)
+	if err != nil {
 		p.API.LogWarn("failed to unmarshal meeting channel entry",
 			"key", key,
 			"error", err.Error(),
@@ -270,23 +514,60 @@ func (p *Plugin) getMeetingChannelEntry(meetingID int) (*meetingChannelEntry, *m
 	if entry.ChannelID == "" {
 		return nil, nil
 	}
-	return &entry, nil
+	return entry, nil
 }
 
-func (p *Plugin) fetchChannelForMeeting(meetingID int) (string, *model.AppError) {
+// hasMeetingSubscriptionBy reports whether userID still has the meeting subscribed to any channel.
+func (p *Plugin) hasMeetingSubscriptionBy(meetingID int, userID string) bool {
 	entry, appErr := p.getMeetingChannelEntry(meetingID)
-	if appErr != nil {
-		return "", appErr
+	if appErr != nil || entry == nil {
+		return false
 	}
-	if entry == nil {
-		return "", nil
+	for _, subscription := range entry.Subscriptions {
+		if subscription.CreatedBy == userID {
+			return true
+		}
 	}
-	return entry.ChannelID, nil
+	return false
 }
 
-func (p *Plugin) deleteChannelForMeeting(meetingID int) error {
-	key := meetingChannelKVKey(meetingID)
-	return p.client.KV.Delete(key)
+// webinarEntry maps a webinar created with /zoom webinar to the channel and card it was created from.
+type webinarEntry struct {
+	ChannelID string `json:"channel_id"`
+	PostID    string `json:"post_id"`
+	CreatedBy string `json:"created_by"`
+}
+
+func webinarEntryKVKey(webinarID int) string {
+	return fmt.Sprintf("%v%v", webinarChannelKey, webinarID)
+}
+
+// storeWebinarEntry maps a webinar to its channel and card. A zero ttl stores the entry without expiry.
+func (p *Plugin) storeWebinarEntry(webinarID int, entry *webinarEntry, ttl int64) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, appErr := p.API.KVSetWithOptions(webinarEntryKVKey(webinarID), data, model.PluginKVSetOptions{ExpireInSeconds: ttl}); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func (p *Plugin) getWebinarEntry(webinarID int) (*webinarEntry, error) {
+	raw, appErr := p.API.KVGet(webinarEntryKVKey(webinarID))
+	if appErr != nil {
+		return nil, appErr
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var entry webinarEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
 }
 
 const subscriptionIndexKey = "subscription_index_"
@@ -371,7 +652,7 @@ func (p *Plugin) removeFromSubscriptionIndex(userID string, meetingID int) error
 	})
 }
 
-func (p *Plugin) listAllMeetingSubscriptions(userID string) (map[string]string, error) {
+func (p *Plugin) listAllMeetingSubscriptions(userID string) (map[string][]string, error) {
 	raw, appErr := p.API.KVGet(subscriptionIndexKVKey(userID))
 	if appErr != nil {
 		return nil, appErr
@@ -387,16 +668,18 @@ func (p *Plugin) listAllMeetingSubscriptions(userID string) (map[string]string,
 		}
 	}
 
-	subscriptions := make(map[string]string)
+	subscriptions := make(map[string][]string)
 	for _, meetingID := range idx.MeetingIDs {
 		entry, appErr := p.getMeetingChannelEntry(meetingID)
 		if appErr != nil || entry == nil || !entry.IsSubscription {
 			continue
 		}
-		if entry.CreatedBy != userID {
-			continue
+		for _, subscription := range entry.Subscriptions {
+			if subscription.CreatedBy == userID {
+				key := fmt.Sprintf("%d", meetingID)
+				subscriptions[key] = append(subscriptions[key], subscription.ChannelID)
+			}
 		}
-		subscriptions[fmt.Sprintf("%d", meetingID)] = entry.ChannelID
 	}
 
 	return subscriptions, nil
@@ -408,25 +691,86 @@ func getOAuthUserStateKey(userID string) string {
 }
 
 func (p *Plugin) getSuperuserToken() (*oauth2.Token, error) {
-	var token oauth2.Token
 	rawToken, appErr := p.API.KVGet(zoomSuperUserTokenKey)
 	if appErr != nil {
 		return nil, appErr
 	}
+
+	return p.decodeSuperUserToken(rawToken)
+}
+
+// encodeSuperUserToken encodes the account level token for the KV store, sealed with the current
+// encryption key.
+func (p *Plugin) encodeSuperUserToken(token *oauth2.Token) ([]byte, error) {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	rawToken, err := p.sealValue(plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt the token")
+	}
+	return rawToken, nil
+}
+
+// decodeSuperUserToken decodes the account level token read from the KV store, or returns nil
+// when the app isn't connected. Tokens stored before they were sealed are plain JSON.
+func (p *Plugin) decodeSuperUserToken(rawToken []byte) (*oauth2.Token, error) {
 	if len(rawToken) == 0 {
 		return nil, nil
 	}
 
-	err := json.Unmarshal(rawToken, &token)
-	if err != nil {
+	plaintext := rawToken
+	if sealed, ok := parseSealedValue(rawToken); ok {
+		var err error
+		if plaintext, err = p.openValue(sealed); err != nil {
+			return nil, errors.Wrap(err, "could not decrypt the token")
+		}
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(plaintext, &token); err != nil {
 		return nil, err
 	}
 
 	return &token, nil
 }
 
+// compareAndSetSuperUserToken stores the account level token only if the stored value is still
+// oldValue, and reports whether it did. The token refresh mutex of the account level token must
+// be held.
+func (p *Plugin) compareAndSetSuperUserToken(token *oauth2.Token, oldValue []byte) (bool, error) {
+	rawToken, err := p.encodeSuperUserToken(token)
+	if err != nil {
+		return false, err
+	}
+
+	ok, appErr := p.API.KVSetWithOptions(zoomSuperUserTokenKey, rawToken, model.PluginKVSetOptions{
+		Atomic:   true,
+		OldValue: oldValue,
+	})
+	if appErr != nil {
+		return false, appErr
+	}
+	return ok, nil
+}
+
+// setSuperUserToken stores the account level token, holding its token refresh mutex so it doesn't
+// race with a refresh of the token.
 func (p *Plugin) setSuperUserToken(token *oauth2.Token) error {
-	rawToken, err := json.Marshal(token)
+	mutex, err := p.lockTokenRefresh(superUserTokenMutexName)
+	if err != nil {
+		return err
+	}
+	defer mutex.Unlock()
+
+	return p.storeSuperUserTokenLocked(token)
+}
+
+// storeSuperUserTokenLocked stores the account level token. Its token refresh mutex must be held.
+func (p *Plugin) storeSuperUserTokenLocked(token *oauth2.Token) error {
+	rawToken, err := p.encodeSuperUserToken(token)
 	if err != nil {
 		return err
 	}
@@ -440,6 +784,12 @@ func (p *Plugin) setSuperUserToken(token *oauth2.Token) error {
 }
 
 func (p *Plugin) removeSuperUserToken() error {
+	mutex, err := p.lockTokenRefresh(superUserTokenMutexName)
+	if err != nil {
+		return err
+	}
+	defer mutex.Unlock()
+
 	appErr := p.API.KVDelete(zoomSuperUserTokenKey)
 	if appErr != nil {
 		return appErr
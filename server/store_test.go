@@ -0,0 +1,72 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+)
+
+func TestStoreSubscriptionForMeeting(t *testing.T) {
+	existing, _ := json.Marshal(meetingChannelEntry{
+		ChannelID:      "channel-a",
+		IsSubscription: true,
+		CreatedBy:      "user-a",
+	})
+
+	t.Run("adds a second channel to a legacy subscription", func(t *testing.T) {
+		api := &plugintest.API{}
+		p := Plugin{}
+		api.On("KVGet", "meeting_channel_123").Return(existing, nil)
+
+		var stored meetingChannelEntry
+		api.On("KVSetWithOptions", "meeting_channel_123", mock.AnythingOfType("[]uint8"), model.PluginKVSetOptions{Atomic: true, OldValue: existing}).Run(func(args mock.Arguments) {
+			require.NoError(t, json.Unmarshal(args.Get(1).([]byte), &stored))
+		}).Return(true, nil).Once()
+		api.On("KVGet", subscriptionIndexKVKey("user-b")).Return(nil, nil)
+		api.On("KVSetWithOptions", subscriptionIndexKVKey("user-b"), []byte(`{"meeting_ids":[123]}`), model.PluginKVSetOptions{Atomic: true}).Return(true, nil).Once()
+		p.SetAPI(api)
+
+		require.NoError(t, p.storeSubscriptionForMeeting(123, "channel-b", "user-b"))
+		api.AssertExpectations(t)
+
+		assert.True(t, stored.IsSubscription)
+		assert.Equal(t, "channel-a", stored.ChannelID)
+		assert.Equal(t, []meetingSubscription{
+			{ChannelID: "channel-a", CreatedBy: "user-a"},
+			{ChannelID: "channel-b", CreatedBy: "user-b"},
+		}, stored.Subscriptions)
+	})
+
+	t.Run("rejects a channel subscribed by another user", func(t *testing.T) {
+		api := &plugintest.API{}
+		p := Plugin{}
+		api.On("KVGet", "meeting_channel_123").Return(existing, nil)
+		p.SetAPI(api)
+
+		assert.Error(t, p.storeSubscriptionForMeeting(123, "channel-a", "user-b"))
+		api.AssertNotCalled(t, "KVSetWithOptions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("removing the last subscription deletes the entry", func(t *testing.T) {
+		api := &plugintest.API{}
+		p := Plugin{}
+		api.On("KVGet", "meeting_channel_123").Return(existing, nil)
+		api.On("KVSetWithOptions", "meeting_channel_123", []byte(nil), model.PluginKVSetOptions{Atomic: true, OldValue: existing}).Return(true, nil).Once()
+		p.SetAPI(api)
+
+		removed, err := p.removeSubscriptionForMeeting(123, "channel-a")
+		require.NoError(t, err)
+		require.NotNil(t, removed)
+		assert.Equal(t, "user-a", removed.CreatedBy)
+		api.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	actionItemsSentKeyPrefix = "action_items_sent_"
+
+	summarizerTimeout         = 2 * time.Minute
+	maxSummarizerResponseSize = 1 << 20
+)
+
+// actionItemMentionPattern matches the @username mentions of a lowercased action item, but not
+// the domains of email addresses.
+var actionItemMentionPattern = regexp.MustCompile(`(?:^|[^a-z0-9._-])@([a-z0-9][a-z0-9._-]*)`)
+
+// meetingSummarizer produces a summary of a meeting from its transcript.
+type meetingSummarizer interface {
+	Summarize(transcript *summaryTranscript) (*meetingSummary, error)
+}
+
+// summaryTranscript is the transcript of a meeting as given to a summarizer.
+type summaryTranscript struct {
+	MeetingID   int    `json:"meeting_id"`
+	MeetingUUID string `json:"meeting_uuid"`
+	Topic       string `json:"topic"`
+	// Speakers maps the lowercased names of the speakers matched to Mattermost users to their usernames.
+	Speakers map[string]string        `json:"speakers"`
+	Segments []transcriptIndexSegment `json:"segments"`
+}
+
+// speakerLabel returns the @mention of the speaker when it is matched to a user, or else its name.
+func (t *summaryTranscript) speakerLabel(speaker string) string {
+	if username, ok := t.Speakers[strings.ToLower(speaker)]; ok {
+		return "@" + username
+	}
+	if speaker == "" {
+		return "Unknown speaker"
+	}
+	return speaker
+}
+
+// meetingSummary is what a summarizer makes of a meeting. Action items may mention users as @username.
+type meetingSummary struct {
+	Summary     string   `json:"summary"`
+	ActionItems []string `json:"action_items"`
+	Decisions   []string `json:"decisions"`
+}
+
+func (s *meetingSummary) isEmpty() bool {
+	return strings.TrimSpace(s.Summary) == "" && len(s.ActionItems) == 0 && len(s.Decisions) == 0
+}
+
+// Markdown renders the summary as the message of a post.
+func (s *meetingSummary) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("#### Meeting summary")
+	if summary := strings.TrimSpace(s.Summary); summary != "" {
+		sb.WriteString("\n" + summary)
+	}
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString("\n\n##### " + title)
+		for _, item := range items {
+			sb.WriteString("\n- " + item)
+		}
+	}
+	writeList("Decisions", s.Decisions)
+	writeList("Action items", s.ActionItems)
+	return sb.String()
+}
+
+// httpSummarizer sends the transcript as JSON in a POST request to an external service, which
+// responds with the meeting summary as JSON.
+type httpSummarizer struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s *httpSummarizer) Summarize(transcript *summaryTranscript) (*meetingSummary, error) {
+	body, err := json.Marshal(transcript)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "summarizer request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("summarizer responded with status %d", resp.StatusCode)
+	}
+
+	var summary meetingSummary
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSummarizerResponseSize)).Decode(&summary); err != nil {
+		return nil, errors.Wrap(err, "could not decode the summarizer response")
+	}
+	return &summary, nil
+}
+
+// validateSummarizerURL checks that an external summarizer URL is an absolute HTTP(S) URL.
+func validateSummarizerURL(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("%q is not an absolute HTTP URL", value)
+	}
+	return nil
+}
+
+// getMeetingSummarizer returns the external summarizer when one is configured, or else the built-in one.
+func (p *Plugin) getMeetingSummarizer() meetingSummarizer {
+	config := p.getConfiguration()
+	if config.SummarizerURL == "" {
+		return extractiveSummarizer{}
+	}
+
+	return &httpSummarizer{
+		url:    config.SummarizerURL,
+		token:  config.SummarizerToken,
+		client: &http.Client{Timeout: summarizerTimeout},
+	}
+}
+
+// postMeetingSummary replies to the meeting post with a summary of the transcript, and sends the
+// users mentioned by action items their items. mentions maps lowercased speaker names to @mentions.
+func (p *Plugin) postMeetingSummary(meeting *zoom.RecordingWebhookObject, post *model.Post, segments []zoom.TranscriptSegment, mentions map[string]string) error {
+	transcript := &summaryTranscript{
+		MeetingID:   meeting.ID,
+		MeetingUUID: meeting.UUID,
+		Topic:       meeting.Topic,
+		Speakers:    map[string]string{},
+		Segments:    transcriptIndexSegments(segments),
+	}
+	for name, mention := range mentions {
+		transcript.Speakers[name] = strings.TrimPrefix(mention, "@")
+	}
+
+	summary, err := p.getMeetingSummarizer().Summarize(transcript)
+	if err != nil {
+		return errors.Wrap(err, "could not summarize the transcript")
+	}
+	if summary.isEmpty() {
+		return nil
+	}
+
+	if _, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.botUserID,
+		ChannelId: post.ChannelId,
+		RootId:    post.Id,
+		Message:   summary.Markdown(),
+	}); appErr != nil {
+		return appErr
+	}
+
+	p.sendActionItems(meeting, post, summary.ActionItems)
+	return nil
+}
+
+// sendActionItems sends every user mentioned by action items a direct message with their items.
+// Users who can't read the channel of the meeting post are left out, and a user gets the items
+// of a meeting only once even when it is posted to several channels.
+func (p *Plugin) sendActionItems(meeting *zoom.RecordingWebhookObject, post *model.Post, actionItems []string) {
+	var usernames []string
+	items := map[string][]string{}
+	for _, item := range actionItems {
+		for _, match := range actionItemMentionPattern.FindAllStringSubmatch(strings.ToLower(item), -1) {
+			username := strings.TrimRight(match[1], ".-_")
+			if _, ok := items[username]; !ok {
+				usernames = append(usernames, username)
+			}
+			if n := len(items[username]); n == 0 || items[username][n-1] != item {
+				items[username] = append(items[username], item)
+			}
+		}
+	}
+
+	topic := meeting.Topic
+	if topic == "" {
+		topic = "a Zoom meeting"
+	}
+
+	for _, username := range usernames {
+		user, appErr := p.API.GetUserByUsername(username)
+		if appErr != nil || user.IsBot {
+			continue
+		}
+		if !p.API.HasPermissionToChannel(user.Id, post.ChannelId, model.PermissionReadChannel) {
+			continue
+		}
+
+		message := fmt.Sprintf("You have action items from [%s](%s):\n- %s", topic, p.permalink(post.Id), strings.Join(items[username], "\n- "))
+		key := ""
+		if meeting.UUID != "" {
+			key = hashedKey(actionItemsSentKeyPrefix, meeting.UUID, user.Id)
+		}
+		if err := p.doOnce(key, recordingPostedTTL, func() error { return p.sendDirectMessage(user.Id, message) }); err != nil {
+			p.API.LogWarn("Could not send the action items", "user_id", user.Id, "err", err.Error())
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	extractiveSummarySentences = 3
+	extractiveMaxListItems     = 10
+	extractiveMinSentenceWords = 4
+)
+
+var (
+	// summarySentencePattern ends sentences at punctuation followed by a space, so that email
+	// addresses and decimals stay whole.
+	summarySentencePattern = regexp.MustCompile(`.+?(?:[.!?]+(?:\s+|$)|$)`)
+	summaryWordPattern     = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+	// decisionCues and actionItemCues are phrases marking a sentence as a decision or an action item.
+	decisionCues = []string{
+		"we decided", "we've decided", "decided to", "we agreed", "we're agreed", "agreed to",
+		"the decision is", "let's go with", "we'll go with", "we are going with", "we're going with",
+	}
+	actionItemCues = []string{
+		"action item", "i will", "i'll", "we will", "we'll", "follow up", "needs to", "need to",
+		"can you", "could you", "please", "to do", "todo", "take care of", "by tomorrow", "by monday",
+		"by next week", "by the end of",
+	}
+
+	summaryStopWords = wordSet(`a about after again all also am an and any are as at be because been
+		before being but by can could did do does doing don't for from get got had has have having he her
+		here him his how i i'm if in into is it it's its just know let's like me more my no not now of off
+		ok okay on one or our out over really right say she so some that that's the their them then there
+		they think this those to too um uh up us very was we we're well were what when where which who why
+		will with would yeah yes you your`)
+)
+
+// extractiveSummarizer is the built-in summarizer. It works offline by picking the sentences of
+// the transcript that share the most words with the rest of the meeting, and finds decisions and
+// action items by the phrases they usually start with.
+type extractiveSummarizer struct{}
+
+type summarySentence struct {
+	speaker string
+	text    string
+	words   []string
+}
+
+func (extractiveSummarizer) Summarize(transcript *summaryTranscript) (*meetingSummary, error) {
+	var sentences []summarySentence
+	frequency := map[string]int{}
+	for _, segment := range transcript.Segments {
+		for _, text := range summarySentencePattern.FindAllString(segment.Text, -1) {
+			text = strings.TrimSpace(text)
+			if text == "" {
+				continue
+			}
+
+			var words []string
+			for _, word := range summaryWordPattern.FindAllString(strings.ToLower(text), -1) {
+				if len(word) > 2 && !summaryStopWords[word] {
+					words = append(words, word)
+					frequency[word]++
+				}
+			}
+			sentences = append(sentences, summarySentence{speaker: segment.Speaker, text: text, words: words})
+		}
+	}
+
+	summary := &meetingSummary{}
+	for _, sentence := range sentences {
+		lower := strings.ToLower(sentence.text)
+		item := transcript.speakerLabel(sentence.speaker) + ": " + sentence.text
+		switch {
+		case containsAny(lower, decisionCues):
+			if len(summary.Decisions) < extractiveMaxListItems {
+				summary.Decisions = append(summary.Decisions, item)
+			}
+		case containsAny(lower, actionItemCues):
+			if len(summary.ActionItems) < extractiveMaxListItems {
+				summary.ActionItems = append(summary.ActionItems, item)
+			}
+		}
+	}
+
+	summary.Summary = strings.Join(topSummarySentences(sentences, frequency, extractiveSummarySentences), " ")
+	return summary, nil
+}
+
+// topSummarySentences returns the n sentences whose words are the most frequent in the meeting,
+// in the order they were said. Sentences too short to carry content are passed over.
+func topSummarySentences(sentences []summarySentence, frequency map[string]int, n int) []string {
+	type scored struct {
+		index int
+		score float64
+	}
+
+	var candidates []scored
+	for i, sentence := range sentences {
+		if len(sentence.words) < extractiveMinSentenceWords {
+			continue
+		}
+
+		total := 0
+		for _, word := range sentence.words {
+			total += frequency[word]
+		}
+		candidates = append(candidates, scored{index: i, score: float64(total) / float64(len(sentence.words))})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].index < candidates[j].index })
+
+	texts := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		texts = append(texts, sentences[candidate.index].text)
+	}
+	return texts
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestExtractiveSummarizer(t *testing.T) {
+	transcript := &summaryTranscript{
+		Speakers: map[string]string{"alice smith": "alice"},
+		Segments: []transcriptIndexSegment{
+			{Speaker: "Alice Smith", Text: "Welcome everyone. Today we review the launch plan for the mobile release."},
+			{Speaker: "Bob", Text: "The mobile release is blocked on the launch review. We agreed to ship the mobile release on Tuesday."},
+			{Speaker: "Alice Smith", Text: "Great. I'll send the launch checklist to the team by Monday."},
+			{Text: "Okay."},
+		},
+	}
+
+	summary, err := extractiveSummarizer{}.Summarize(transcript)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bob: We agreed to ship the mobile release on Tuesday."}, summary.Decisions)
+	assert.Equal(t, []string{"@alice: I'll send the launch checklist to the team by Monday."}, summary.ActionItems)
+	assert.Contains(t, summary.Summary, "The mobile release is blocked on the launch review.")
+	assert.NotContains(t, summary.Summary, "Okay.")
+}
+
+func TestMeetingSummaryMarkdown(t *testing.T) {
+	summary := &meetingSummary{Summary: "We planned the release.", ActionItems: []string{"@alice: Send the checklist"}}
+	assert.Equal(t, "#### Meeting summary\nWe planned the release.\n\n##### Action items\n- @alice: Send the checklist", summary.Markdown())
+	assert.True(t, (&meetingSummary{Summary: " "}).isEmpty())
+}
+
+func TestHTTPSummarizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var transcript summaryTranscript
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&transcript))
+		assert.Equal(t, "Planning", transcript.Topic)
+
+		_, _ = w.Write([]byte(`{"summary":"Planned.","decisions":["Ship it"],"action_items":["@bob: Write the notes"]}`))
+	}))
+	defer server.Close()
+
+	p := Plugin{}
+	cfg := *testConfig
+	cfg.SummarizerURL = server.URL
+	cfg.SummarizerToken = "secret"
+	p.setConfiguration(&cfg)
+
+	summary, err := p.getMeetingSummarizer().Summarize(&summaryTranscript{Topic: "Planning"})
+	require.NoError(t, err)
+	assert.Equal(t, &meetingSummary{Summary: "Planned.", Decisions: []string{"Ship it"}, ActionItems: []string{"@bob: Write the notes"}}, summary)
+
+	missing := cfg
+	missing.SummarizerURL = server.URL + "/missing"
+	p.setConfiguration(&missing)
+	_, err = p.getMeetingSummarizer().Summarize(&summaryTranscript{})
+	assert.Error(t, err)
+}
+
+func TestValidateSummarizerURL(t *testing.T) {
+	assert.NoError(t, validateSummarizerURL(""))
+	assert.NoError(t, validateSummarizerURL("https://summarizer.example.com/summarize"))
+	assert.Error(t, validateSummarizerURL("summarizer.example.com"))
+	assert.Error(t, validateSummarizerURL("ftp://summarizer.example.com"))
+}
+
+func TestPostMeetingSummary(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.RootId == "post-id" && strings.HasPrefix(post.Message, "#### Meeting summary") &&
+			strings.Contains(post.Message, "##### Action items\n- @alice: I'll send the launch checklist to @bob and bob@example.com.")
+	})).Return(&model.Post{}, nil).Once()
+
+	api.On("GetUserByUsername", "alice").Return(&model.User{Id: "alice-id"}, nil)
+	api.On("GetUserByUsername", "bob").Return(&model.User{Id: "bob-id"}, nil)
+	api.On("HasPermissionToChannel", "alice-id", "channel-id", model.PermissionReadChannel).Return(true)
+	api.On("HasPermissionToChannel", "bob-id", "channel-id", model.PermissionReadChannel).Return(false)
+	api.On("KVSetWithOptions", hashedKey(actionItemsSentKeyPrefix, "uuid", "alice-id"), mock.Anything, mock.Anything).Return(true, nil).Once()
+	api.On("GetDirectChannel", "alice-id", "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == "dm-id" && strings.HasPrefix(post.Message, "You have action items from [Planning](https://mm.example.com/_redirect/pl/post-id):\n- @alice")
+	})).Return(&model.Post{}, nil).Once()
+
+	p := Plugin{botUserID: "bot-id", siteURL: "https://mm.example.com"}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+
+	segments := []zoom.TranscriptSegment{{Speaker: "Alice", Text: "I'll send the launch checklist to @bob and bob@example.com."}}
+	err := p.postMeetingSummary(&zoom.RecordingWebhookObject{UUID: "uuid", Topic: "Planning"}, &model.Post{Id: "post-id", ChannelId: "channel-id"}, segments, map[string]string{"alice": "@alice"})
+	require.NoError(t, err)
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "GetUserByUsername", "example.com")
+}
@@ -0,0 +1,609 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	meetingTemplatesKey        = "meeting_templates"
+	meetingTemplatesMaxRetries = 5
+
+	templateFieldName                  = "name"
+	templateFieldScope                 = "scope"
+	templateFieldWaitingRoom           = "waiting_room"
+	templateFieldMuteUponEntry         = "mute_upon_entry"
+	templateFieldJoinBeforeHost        = "join_before_host"
+	templateFieldAutoRecording         = "auto_recording"
+	templateFieldAuthentication        = "meeting_authentication"
+	templateFieldAuthenticationDomains = "authentication_domains"
+	templateFieldAlternativeHosts      = "alternative_hosts"
+	templateFieldTrackingFields        = "tracking_fields"
+
+	templateScopeChannel = "channel"
+	templateScopeGlobal  = "global"
+
+	autoRecordingNone  = "none"
+	autoRecordingLocal = "local"
+	autoRecordingCloud = "cloud"
+
+	startTemplateFlag = "--template"
+)
+
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// meetingTemplate is a named preset of meeting settings. Templates without a ChannelID are
+// available in every channel, and a channel's own template shadows a global one of the same name.
+type meetingTemplate struct {
+	Name      string `json:"name"`
+	ChannelID string `json:"channel_id,omitempty"`
+	CreatedBy string `json:"created_by"`
+
+	WaitingRoom           bool                 `json:"waiting_room"`
+	MuteUponEntry         bool                 `json:"mute_upon_entry"`
+	JoinBeforeHost        bool                 `json:"join_before_host"`
+	AutoRecording         string               `json:"auto_recording"`
+	MeetingAuthentication bool                 `json:"meeting_authentication"`
+	AuthenticationDomains string               `json:"authentication_domains,omitempty"`
+	AlternativeHosts      []string             `json:"alternative_hosts,omitempty"`
+	TrackingFields        []zoom.TrackingField `json:"tracking_fields,omitempty"`
+}
+
+// meetingTemplates maps templateKey to the template.
+type meetingTemplates map[string]meetingTemplate
+
+// templateKey returns the key of a template in meetingTemplates.
+func templateKey(channelID, name string) string {
+	return channelID + "/" + strings.ToLower(name)
+}
+
+// apply sets the settings of the template on a meeting request.
+func (t *meetingTemplate) apply(request *zoom.CreateMeetingRequest) {
+	request.Settings.WaitingRoom = t.WaitingRoom
+	request.Settings.MuteUponEntry = t.MuteUponEntry
+	request.Settings.JoinBeforeHost = t.JoinBeforeHost
+	if t.AutoRecording != "" {
+		request.Settings.AutoRecording = t.AutoRecording
+	}
+	request.Settings.MeetingAuthentication = t.MeetingAuthentication
+	if t.MeetingAuthentication {
+		request.Settings.AuthenticationDomains = t.AuthenticationDomains
+	}
+	request.Settings.AlternativeHosts = strings.Join(t.AlternativeHosts, ",")
+	request.TrackingFields = append(request.TrackingFields, t.TrackingFields...)
+}
+
+// describe returns a one line summary of the settings of the template.
+func (t *meetingTemplate) describe() string {
+	var parts []string
+	if t.WaitingRoom {
+		parts = append(parts, "waiting room")
+	}
+	if t.MuteUponEntry {
+		parts = append(parts, "mute upon entry")
+	}
+	if t.JoinBeforeHost {
+		parts = append(parts, "join before host")
+	}
+	if t.AutoRecording != "" && t.AutoRecording != autoRecordingNone {
+		parts = append(parts, t.AutoRecording+" recording")
+	}
+	if t.MeetingAuthentication {
+		parts = append(parts, "authentication required")
+	}
+	if len(t.AlternativeHosts) > 0 {
+		parts = append(parts, fmt.Sprintf("%d alternative host(s)", len(t.AlternativeHosts)))
+	}
+	if len(t.TrackingFields) > 0 {
+		parts = append(parts, fmt.Sprintf("%d tracking field(s)", len(t.TrackingFields)))
+	}
+	if len(parts) == 0 {
+		return "Zoom defaults"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (p *Plugin) listMeetingTemplates() (meetingTemplates, error) {
+	templates, _, err := p.getMeetingTemplates()
+	return templates, err
+}
+
+func (p *Plugin) getMeetingTemplates() (meetingTemplates, []byte, error) {
+	b, appErr := p.API.KVGet(meetingTemplatesKey)
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Message)
+	}
+
+	templates := meetingTemplates{}
+	if len(b) == 0 {
+		return templates, b, nil
+	}
+	if err := json.Unmarshal(b, &templates); err != nil {
+		return nil, nil, err
+	}
+	return templates, b, nil
+}
+
+// updateMeetingTemplates applies mutate to the meeting templates with a compare-and-set write, so
+// concurrent changes don't overwrite each other. mutate returns false to leave them alone.
+func (p *Plugin) updateMeetingTemplates(mutate func(meetingTemplates) bool) error {
+	for i := 0; i < meetingTemplatesMaxRetries; i++ {
+		templates, oldB, err := p.getMeetingTemplates()
+		if err != nil {
+			return err
+		}
+		if !mutate(templates) {
+			return nil
+		}
+
+		newB, err := json.Marshal(templates)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(meetingTemplatesKey, newB, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldB,
+		})
+		if appErr != nil {
+			return errors.New(appErr.Message)
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("updateMeetingTemplates: too many concurrent updates")
+}
+
+// channelTemplates returns the templates available in a channel, sorted by name.
+func (templates meetingTemplates) channelTemplates(channelID string) []meetingTemplate {
+	byName := map[string]meetingTemplate{}
+	for _, template := range templates {
+		if template.ChannelID != "" && template.ChannelID != channelID {
+			continue
+		}
+		if existing, ok := byName[template.Name]; ok && existing.ChannelID != "" {
+			continue
+		}
+		byName[template.Name] = template
+	}
+
+	available := make([]meetingTemplate, 0, len(byName))
+	for _, template := range byName {
+		available = append(available, template)
+	}
+	sort.Slice(available, func(i, j int) bool { return available[i].Name < available[j].Name })
+	return available
+}
+
+// find returns the template of a channel with the given name, preferring the channel's own template.
+func (templates meetingTemplates) find(channelID, name string) (*meetingTemplate, bool) {
+	for _, key := range []string{templateKey(channelID, name), templateKey("", name)} {
+		if template, ok := templates[key]; ok {
+			return &template, true
+		}
+	}
+	return nil, false
+}
+
+// resolveMeetingTemplate returns the template to create a meeting with in a channel: the named
+// one, or else the channel's default template. It returns nil when neither is set.
+func (p *Plugin) resolveMeetingTemplate(channelID, name string) (*meetingTemplate, error) {
+	if name == "" {
+		settings, err := p.listZoomChannelSettings()
+		if err != nil {
+			return nil, err
+		}
+		name = settings[channelID].DefaultTemplate
+		if name == "" {
+			return nil, nil
+		}
+	}
+
+	templates, err := p.listMeetingTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	template, ok := templates.find(channelID, name)
+	if !ok {
+		return nil, errors.Errorf("meeting template %q not found", name)
+	}
+	return template, nil
+}
+
+// parseStartTemplateFlag takes the --template flag off the arguments of /zoom start, and returns
+// the template name and the remaining topic.
+func parseStartTemplateFlag(args string) (string, string, error) {
+	name, topic, err := takeCommandFlag(args, startTemplateFlag)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.ToLower(name), topic, nil
+}
+
+// canManageTemplates reports whether a user may create or delete templates of the given scope in a channel.
+func (p *Plugin) canManageTemplates(userID, channelID, scope string) bool {
+	if p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		return true
+	}
+	return scope == templateScopeChannel && p.API.HasPermissionToChannel(userID, channelID, model.PermissionManageChannelRoles)
+}
+
+func (p *Plugin) runTemplateCommand(args *model.CommandArgs, params []string, user *model.User) (string, error) {
+	switch {
+	case len(params) == 1 && params[0] == templateActionList:
+		return p.runTemplateListCommand(args.ChannelId)
+	case len(params) >= 1 && len(params) <= 2 && params[0] == templateActionCreate:
+		name := ""
+		if len(params) == 2 {
+			name = strings.ToLower(params[1])
+		}
+		return p.runTemplateCreateCommand(args, user, name)
+	case len(params) == 2 && params[0] == templateActionDelete:
+		return p.runTemplateDeleteCommand(args.ChannelId, user, strings.ToLower(params[1]))
+	default:
+		return strings.ReplaceAll(templateHelpText, "|", "`"), nil
+	}
+}
+
+func (p *Plugin) runTemplateListCommand(channelID string) (string, error) {
+	templates, err := p.listMeetingTemplates()
+	if err != nil {
+		return "Unable to list the meeting templates.", errors.Wrap(err, "cannot list meeting templates")
+	}
+
+	available := templates.channelTemplates(channelID)
+	if len(available) == 0 {
+		return "There are no meeting templates in this channel. Create one with `/zoom template create [name]`.", nil
+	}
+
+	settings, err := p.listZoomChannelSettings()
+	if err != nil {
+		return "Unable to list the meeting templates.", errors.Wrap(err, "cannot list channel settings")
+	}
+	defaultTemplate := settings[channelID].DefaultTemplate
+
+	var sb strings.Builder
+	sb.WriteString("#### Meeting templates\n")
+	for _, template := range available {
+		scope := "all channels"
+		if template.ChannelID != "" {
+			scope = "this channel"
+		}
+		sb.WriteString(fmt.Sprintf("* `%s` (%s): %s", template.Name, scope, template.describe()))
+		if template.Name == defaultTemplate {
+			sb.WriteString(" **(channel default)**")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func (p *Plugin) runTemplateCreateCommand(args *model.CommandArgs, user *model.User, name string) (string, error) {
+	if !p.canManageTemplates(user.Id, args.ChannelId, templateScopeChannel) {
+		return "Only channel admins can manage the meeting templates of this channel.", nil
+	}
+	if name != "" && !templateNamePattern.MatchString(name) {
+		return "Template names use up to 32 lowercase letters, digits, dashes and underscores.", nil
+	}
+
+	templates, err := p.listMeetingTemplates()
+	if err != nil {
+		return "Unable to open the template dialog.", errors.Wrap(err, "cannot list meeting templates")
+	}
+
+	current := meetingTemplate{Name: name, AutoRecording: autoRecordingNone}
+	if existing, ok := templates.find(args.ChannelId, name); ok && name != "" {
+		current = *existing
+	}
+
+	if appErr := p.API.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: args.TriggerId,
+		URL:       fmt.Sprintf("/plugins/%s%s", url.PathEscape(manifest.Id), pathMeetingTemplate),
+		Dialog:    p.getMeetingTemplateDialog(user.Id, current),
+	}); appErr != nil {
+		p.client.Log.Error("Failed to open the meeting template dialog", "Error", appErr.Error())
+		return "Unable to open the template dialog.", nil
+	}
+
+	return "", nil
+}
+
+func (p *Plugin) getMeetingTemplateDialog(userID string, current meetingTemplate) model.Dialog {
+	scope := templateScopeChannel
+	if current.Name != "" && current.ChannelID == "" {
+		scope = templateScopeGlobal
+	}
+	scopeOptions := []*model.PostActionOptions{{Text: "This channel", Value: templateScopeChannel}}
+	if p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		scopeOptions = append(scopeOptions, &model.PostActionOptions{Text: "All channels", Value: templateScopeGlobal})
+	}
+
+	trackingFields := make([]string, 0, len(current.TrackingFields))
+	for _, field := range current.TrackingFields {
+		trackingFields = append(trackingFields, field.Field+"="+field.Value)
+	}
+
+	return model.Dialog{
+		Title:       "Meeting Template",
+		SubmitLabel: "Save",
+		Elements: []model.DialogElement{
+			{
+				DisplayName: "Name",
+				Name:        templateFieldName,
+				Type:        "text",
+				Default:     current.Name,
+				Placeholder: "interview",
+				HelpText:    "Lowercase letters, digits, dashes and underscores.",
+				MaxLength:   32,
+			},
+			{
+				DisplayName: "Available in",
+				Name:        templateFieldScope,
+				Type:        "select",
+				Default:     scope,
+				Options:     scopeOptions,
+			},
+			{
+				DisplayName: "Waiting room",
+				Placeholder: "Admit participants from a waiting room",
+				Name:        templateFieldWaitingRoom,
+				Type:        "bool",
+				Optional:    true,
+				Default:     strconv.FormatBool(current.WaitingRoom),
+			},
+			{
+				DisplayName: "Mute upon entry",
+				Placeholder: "Mute participants when they join",
+				Name:        templateFieldMuteUponEntry,
+				Type:        "bool",
+				Optional:    true,
+				Default:     strconv.FormatBool(current.MuteUponEntry),
+			},
+			{
+				DisplayName: "Join before host",
+				Placeholder: "Let participants join before the host",
+				Name:        templateFieldJoinBeforeHost,
+				Type:        "bool",
+				Optional:    true,
+				Default:     strconv.FormatBool(current.JoinBeforeHost),
+			},
+			{
+				DisplayName: "Automatic recording",
+				Name:        templateFieldAutoRecording,
+				Type:        "select",
+				Default:     current.AutoRecording,
+				Options: []*model.PostActionOptions{
+					{Text: "Off", Value: autoRecordingNone},
+					{Text: "Record to the computer of the host", Value: autoRecordingLocal},
+					{Text: "Record to the cloud", Value: autoRecordingCloud},
+				},
+			},
+			{
+				DisplayName: "Authentication",
+				Placeholder: "Only signed-in Zoom users can join",
+				Name:        templateFieldAuthentication,
+				Type:        "bool",
+				Optional:    true,
+				Default:     strconv.FormatBool(current.MeetingAuthentication),
+			},
+			{
+				DisplayName: "Authentication domains",
+				Name:        templateFieldAuthenticationDomains,
+				Type:        "text",
+				Optional:    true,
+				Default:     current.AuthenticationDomains,
+				HelpText:    "Email domains allowed to join when authentication is on, comma separated.",
+			},
+			{
+				DisplayName: "Alternative hosts",
+				Name:        templateFieldAlternativeHosts,
+				Type:        "text",
+				Optional:    true,
+				Default:     strings.Join(current.AlternativeHosts, ", "),
+				HelpText:    "Email addresses of Zoom users who can start the meeting, comma separated.",
+			},
+			{
+				DisplayName: "Tracking fields",
+				Name:        templateFieldTrackingFields,
+				Type:        "textarea",
+				Optional:    true,
+				Default:     strings.Join(trackingFields, "\n"),
+				HelpText:    "One field=value per line, using the tracking fields of your Zoom account.",
+			},
+		},
+	}
+}
+
+// defaultTemplateDialogElement returns the select of the channel settings dialog for the default template.
+func defaultTemplateDialogElement(available []meetingTemplate, current string) model.DialogElement {
+	options := []*model.PostActionOptions{{Text: "None", Value: channelSettingsNoTemplate}}
+	for _, template := range available {
+		options = append(options, &model.PostActionOptions{Text: template.Name, Value: template.Name})
+	}
+	if current == "" {
+		current = channelSettingsNoTemplate
+	}
+
+	return model.DialogElement{
+		DisplayName: "Default meeting template",
+		HelpText:    "Settings applied to meetings started in this channel without a template.",
+		Name:        channelSettingsFieldDefaultTemplate,
+		Type:        "select",
+		Optional:    true,
+		Default:     current,
+		Options:     options,
+	}
+}
+
+// parseTemplateSubmission validates the template dialog submission. Field level problems are
+// returned keyed by element name, ready for a SubmitDialogResponse.
+func parseTemplateSubmission(submission map[string]any) (*meetingTemplate, string, map[string]string) {
+	fieldErrors := map[string]string{}
+	field := func(name string) string {
+		value, _ := submission[name].(string)
+		return strings.TrimSpace(value)
+	}
+	flag := func(name string) bool {
+		value, _ := submission[name].(bool)
+		return value
+	}
+
+	template := &meetingTemplate{
+		Name:                  strings.ToLower(field(templateFieldName)),
+		WaitingRoom:           flag(templateFieldWaitingRoom),
+		MuteUponEntry:         flag(templateFieldMuteUponEntry),
+		JoinBeforeHost:        flag(templateFieldJoinBeforeHost),
+		AutoRecording:         field(templateFieldAutoRecording),
+		MeetingAuthentication: flag(templateFieldAuthentication),
+		AuthenticationDomains: field(templateFieldAuthenticationDomains),
+	}
+	if !templateNamePattern.MatchString(template.Name) {
+		fieldErrors[templateFieldName] = "Use up to 32 lowercase letters, digits, dashes and underscores."
+	}
+
+	switch template.AutoRecording {
+	case "":
+		template.AutoRecording = autoRecordingNone
+	case autoRecordingNone, autoRecordingLocal, autoRecordingCloud:
+	default:
+		fieldErrors[templateFieldAutoRecording] = "Unknown recording option."
+	}
+
+	for _, host := range strings.Split(field(templateFieldAlternativeHosts), ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if !model.IsValidEmail(host) {
+			fieldErrors[templateFieldAlternativeHosts] = fmt.Sprintf("%q is not an email address.", host)
+			break
+		}
+		template.AlternativeHosts = append(template.AlternativeHosts, host)
+	}
+
+	for _, line := range strings.Split(field(templateFieldTrackingFields), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			fieldErrors[templateFieldTrackingFields] = fmt.Sprintf("%q is not in the field=value format.", line)
+			break
+		}
+		template.TrackingFields = append(template.TrackingFields, zoom.TrackingField{Field: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+
+	scope := field(templateFieldScope)
+	if scope != templateScopeChannel && scope != templateScopeGlobal {
+		fieldErrors[templateFieldScope] = "Unknown option."
+	}
+
+	return template, scope, fieldErrors
+}
+
+func (p *Plugin) handleMeetingTemplate(w http.ResponseWriter, r *http.Request) {
+	var submitRequest model.SubmitDialogRequest
+	if err := json.NewDecoder(r.Body).Decode(&submitRequest); err != nil {
+		p.API.LogError("Error decoding dialog request", "Error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	headerUserID := r.Header.Get(MattermostUserIDHeader)
+	if headerUserID == "" || headerUserID != submitRequest.UserId {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	template, scope, fieldErrors := parseTemplateSubmission(submitRequest.Submission)
+	if len(fieldErrors) > 0 {
+		p.writeDialogResponse(w, &model.SubmitDialogResponse{Errors: fieldErrors})
+		return
+	}
+
+	if !p.canManageTemplates(submitRequest.UserId, submitRequest.ChannelId, scope) {
+		p.writeDialogResponse(w, &model.SubmitDialogResponse{Error: "You don't have permission to save this template."})
+		return
+	}
+
+	if scope == templateScopeChannel {
+		template.ChannelID = submitRequest.ChannelId
+	}
+	template.CreatedBy = submitRequest.UserId
+
+	err := p.updateMeetingTemplates(func(templates meetingTemplates) bool {
+		templates[templateKey(template.ChannelID, template.Name)] = *template
+		return true
+	})
+	if err != nil {
+		p.API.LogError("Unable to store the meeting templates", "Error", err.Error())
+		p.writeDialogResponse(w, &model.SubmitDialogResponse{Error: "Unable to save the template."})
+		return
+	}
+
+	p.postCommandResponse(&model.CommandArgs{UserId: submitRequest.UserId, ChannelId: submitRequest.ChannelId}, fmt.Sprintf("Saved the meeting template `%s`: %s.", template.Name, template.describe()))
+	p.writeDialogResponse(w, &model.SubmitDialogResponse{})
+}
+
+func (p *Plugin) runTemplateDeleteCommand(channelID string, user *model.User, name string) (string, error) {
+	templates, err := p.listMeetingTemplates()
+	if err != nil {
+		return "Unable to delete the meeting template.", errors.Wrap(err, "cannot list meeting templates")
+	}
+
+	template, ok := templates.find(channelID, name)
+	if !ok {
+		return fmt.Sprintf("There is no meeting template `%s` in this channel.", name), nil
+	}
+
+	scope := templateScopeGlobal
+	if template.ChannelID != "" {
+		scope = templateScopeChannel
+	}
+	if !p.canManageTemplates(user.Id, channelID, scope) {
+		return "You don't have permission to delete this template.", nil
+	}
+
+	err = p.updateMeetingTemplates(func(templates meetingTemplates) bool {
+		key := templateKey(template.ChannelID, template.Name)
+		if _, ok := templates[key]; !ok {
+			return false
+		}
+		delete(templates, key)
+		return true
+	})
+	if err != nil {
+		return "Unable to delete the meeting template.", errors.Wrap(err, "cannot store meeting templates")
+	}
+
+	return fmt.Sprintf("Deleted the meeting template `%s`.", template.Name), nil
+}
+
+// meetingTemplateForStart resolves the template of a meeting started in a channel. A missing
+// named template is an error for the user, while a broken channel default is only logged.
+func (p *Plugin) meetingTemplateForStart(channelID, name string) (*meetingTemplate, error) {
+	template, err := p.resolveMeetingTemplate(channelID, name)
+	if err != nil && name == "" {
+		p.API.LogWarn("Could not get the default meeting template of the channel", "channel_id", channelID, "error", err.Error())
+		return nil, nil
+	}
+	return template, err
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestParseStartTemplateFlag(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args          string
+		expectedName  string
+		expectedTopic string
+		expectError   bool
+	}{
+		"no flag":            {args: "Weekly sync", expectedTopic: "Weekly sync"},
+		"flag before topic":  {args: "--template Interview Candidate call", expectedName: "interview", expectedTopic: "Candidate call"},
+		"flag after topic":   {args: "Candidate call --template=interview", expectedName: "interview", expectedTopic: "Candidate call"},
+		"flag without value": {args: "Candidate call --template", expectError: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			templateName, topic, err := parseStartTemplateFlag(tc.args)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedName, templateName)
+			assert.Equal(t, tc.expectedTopic, topic)
+		})
+	}
+}
+
+func TestParseTemplateSubmission(t *testing.T) {
+	t.Run("valid submission", func(t *testing.T) {
+		template, scope, fieldErrors := parseTemplateSubmission(map[string]any{
+			templateFieldName:             "Interview",
+			templateFieldScope:            templateScopeChannel,
+			templateFieldWaitingRoom:      true,
+			templateFieldAutoRecording:    autoRecordingCloud,
+			templateFieldAlternativeHosts: "ann@example.com, bob@example.com",
+			templateFieldTrackingFields:   "team = hiring\n\ncost_center=42",
+		})
+		require.Empty(t, fieldErrors)
+		assert.Equal(t, templateScopeChannel, scope)
+		assert.Equal(t, &meetingTemplate{
+			Name:             "interview",
+			WaitingRoom:      true,
+			AutoRecording:    autoRecordingCloud,
+			AlternativeHosts: []string{"ann@example.com", "bob@example.com"},
+			TrackingFields:   []zoom.TrackingField{{Field: "team", Value: "hiring"}, {Field: "cost_center", Value: "42"}},
+		}, template)
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		_, _, fieldErrors := parseTemplateSubmission(map[string]any{
+			templateFieldName:             "no spaces",
+			templateFieldScope:            "team",
+			templateFieldAutoRecording:    "tape",
+			templateFieldAlternativeHosts: "ann",
+			templateFieldTrackingFields:   "team",
+		})
+		assert.Len(t, fieldErrors, 5)
+	})
+}
+
+func TestMeetingTemplateApply(t *testing.T) {
+	template := meetingTemplate{
+		MuteUponEntry:         true,
+		AutoRecording:         autoRecordingLocal,
+		MeetingAuthentication: true,
+		AuthenticationDomains: "example.com",
+		AlternativeHosts:      []string{"ann@example.com", "bob@example.com"},
+		TrackingFields:        []zoom.TrackingField{{Field: "team", Value: "hiring"}},
+	}
+
+	request := &zoom.CreateMeetingRequest{Topic: "Interview", Type: zoom.MeetingTypeInstant}
+	template.apply(request)
+
+	assert.True(t, request.Settings.MuteUponEntry)
+	assert.False(t, request.Settings.WaitingRoom)
+	assert.Equal(t, autoRecordingLocal, request.Settings.AutoRecording)
+	assert.True(t, request.Settings.MeetingAuthentication)
+	assert.Equal(t, "example.com", request.Settings.AuthenticationDomains)
+	assert.Equal(t, "ann@example.com,bob@example.com", request.Settings.AlternativeHosts)
+	assert.Equal(t, []zoom.TrackingField{{Field: "team", Value: "hiring"}}, request.TrackingFields)
+}
+
+func TestMeetingTemplatesLookup(t *testing.T) {
+	templates := meetingTemplates{
+		templateKey("", "interview"):           {Name: "interview", WaitingRoom: true},
+		templateKey("", "standup"):             {Name: "standup"},
+		templateKey("channel-id", "interview"): {Name: "interview", ChannelID: "channel-id", MuteUponEntry: true},
+		templateKey("other-id", "retro"):       {Name: "retro", ChannelID: "other-id"},
+	}
+
+	available := templates.channelTemplates("channel-id")
+	require.Len(t, available, 2)
+	assert.Equal(t, "interview", available[0].Name)
+	assert.Equal(t, "channel-id", available[0].ChannelID)
+	assert.Equal(t, "standup", available[1].Name)
+
+	template, ok := templates.find("channel-id", "Interview")
+	require.True(t, ok)
+	assert.True(t, template.MuteUponEntry)
+
+	template, ok = templates.find("other-id", "interview")
+	require.True(t, ok)
+	assert.True(t, template.WaitingRoom)
+
+	_, ok = templates.find("channel-id", "retro")
+	assert.False(t, ok)
+}
+
+func TestResolveMeetingTemplate(t *testing.T) {
+	settings, err := json.Marshal(ZoomChannelSettingsMap{"channel-id": {DefaultTemplate: "interview"}})
+	require.NoError(t, err)
+	templates, err := json.Marshal(meetingTemplates{templateKey("", "interview"): {Name: "interview", WaitingRoom: true}})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", zoomChannelSettings).Return(settings, nil)
+	api.On("KVGet", meetingTemplatesKey).Return(templates, nil)
+	p := webinarTestPlugin(api)
+
+	template, err := p.resolveMeetingTemplate("channel-id", "")
+	require.NoError(t, err)
+	require.NotNil(t, template)
+	assert.Equal(t, "interview", template.Name)
+
+	template, err = p.resolveMeetingTemplate("other-id", "")
+	require.NoError(t, err)
+	assert.Nil(t, template)
+
+	_, err = p.resolveMeetingTemplate("channel-id", "standup")
+	assert.Error(t, err)
+}
+
+func TestRunTemplateDeleteCommandRequiresPermission(t *testing.T) {
+	templates, err := json.Marshal(meetingTemplates{templateKey("", "interview"): {Name: "interview"}})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", meetingTemplatesKey).Return(templates, nil)
+	api.On("HasPermissionTo", "user-id", model.PermissionManageSystem).Return(false)
+	p := webinarTestPlugin(api)
+
+	message, err := p.runTemplateDeleteCommand("channel-id", &model.User{Id: "user-id"}, "interview")
+	require.NoError(t, err)
+	assert.Equal(t, "You don't have permission to delete this template.", message)
+	api.AssertNotCalled(t, "KVSetWithOptions", meetingTemplatesKey, mock.Anything, mock.Anything)
+}
+
+func TestRunTemplateDeleteCommandKeepsConcurrentChanges(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := webinarTestPlugin(api)
+	require.NoError(t, p.updateMeetingTemplates(func(templates meetingTemplates) bool {
+		templates[templateKey("", "interview")] = meetingTemplate{Name: "interview"}
+		return true
+	}))
+
+	// Another admin saves a template while the permission is checked.
+	api.On("HasPermissionTo", "admin-id", model.PermissionManageSystem).Run(func(mock.Arguments) {
+		require.NoError(t, p.updateMeetingTemplates(func(templates meetingTemplates) bool {
+			templates[templateKey("", "standup")] = meetingTemplate{Name: "standup"}
+			return true
+		}))
+	}).Return(true)
+
+	message, err := p.runTemplateDeleteCommand("channel-id", &model.User{Id: "admin-id"}, "interview")
+	require.NoError(t, err)
+	assert.Equal(t, "Deleted the meeting template `interview`.", message)
+
+	templates, err := p.listMeetingTemplates()
+	require.NoError(t, err)
+	assert.Equal(t, meetingTemplates{templateKey("", "standup"): {Name: "standup"}}, templates)
+}
+
+func TestRunChannelSettingsListCommandShowsDefaultTemplate(t *testing.T) {
+	settings, err := json.Marshal(ZoomChannelSettingsMap{
+		"channel-id": {Preference: ZoomChannelPreferences[DefaultChannelRestrictionPreference], DefaultTemplate: "standup"},
+	})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("HasPermissionTo", "admin-id", model.PermissionManageSystem).Return(true)
+	api.On("KVGet", zoomChannelSettings).Return(settings, nil)
+	api.On("GetChannel", "channel-id").Return(&model.Channel{Id: "channel-id", DisplayName: "Town Square"}, nil)
+	p := webinarTestPlugin(api)
+
+	message, err := p.runChannelSettingsListCommand(&model.CommandArgs{UserId: "admin-id"})
+	require.NoError(t, err)
+	assert.Contains(t, message, "| Default Template |")
+	assert.Contains(t, message, "|Town Square|")
+	assert.Contains(t, message, "|standup|")
+}
@@ -0,0 +1,402 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// encryptionKeyringKey stores the encryption keys retired by regenerating the EncryptionKey
+	// setting, sealed with the current one, so tokens stored with them can still be opened.
+	encryptionKeyringKey = "zoomEncryptionKeyring_"
+	keyringMaxRetries    = 5
+
+	// activeEncryptionKeyKey stores the ID of the encryption key the tokens are sealed with, so a
+	// key changed while the plugin was stopped is detected on activation. The key itself is never
+	// stored in plaintext.
+	activeEncryptionKeyKey = "zoomEncryptionActiveKey_"
+
+	// tokenEncryptionJobKeyPrefix names the job sealing the stored tokens with the current
+	// encryption key, and tokenEncryptionMigratedKey stores the ID of the last key it completed for.
+	tokenEncryptionJobKeyPrefix = "token_encryption_"
+	tokenEncryptionMigratedKey  = "zoomTokenEncryptionMigrated_"
+)
+
+// activeEncryptionKey identifies the encryption key last seen in the EncryptionKey setting.
+type activeEncryptionKey struct {
+	KeyID string `json:"kid"`
+}
+
+// sealValue encrypts a value for the KV store with the current encryption key.
+func (p *Plugin) sealValue(plaintext []byte) ([]byte, error) {
+	return seal(p.getConfiguration().EncryptionKey, plaintext)
+}
+
+// openValue decrypts a sealed value of the KV store with the current encryption key, or with the
+// retired key it was sealed with.
+func (p *Plugin) openValue(sealed *sealedValue) ([]byte, error) {
+	current := p.getConfiguration().EncryptionKey
+	if sealed.KeyID == encryptionKeyID(current) {
+		return sealed.open(current)
+	}
+
+	retired, err := p.getRetiredEncryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range retired {
+		if sealed.KeyID == encryptionKeyID(key) {
+			return sealed.open(key)
+		}
+	}
+	return nil, errUnknownEncryptionKey
+}
+
+// decryptLegacyAccessToken decrypts an access token stored before tokens were sealed, trying the
+// current encryption key first.
+func (p *Plugin) decryptLegacyAccessToken(accessToken string) (string, error) {
+	plainToken, err := decryptLegacy([]byte(p.getConfiguration().EncryptionKey), accessToken)
+	if err == nil {
+		return plainToken, nil
+	}
+
+	retired, keysErr := p.getRetiredEncryptionKeys()
+	if keysErr != nil {
+		return "", keysErr
+	}
+	for _, key := range retired {
+		if plainToken, err = decryptLegacy([]byte(key), accessToken); err == nil {
+			return plainToken, nil
+		}
+	}
+	return "", err
+}
+
+// getRetiredEncryptionKeys returns the retired encryption keys, newest last.
+func (p *Plugin) getRetiredEncryptionKeys() ([]string, error) {
+	raw, appErr := p.API.KVGet(encryptionKeyringKey)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	sealed, ok := parseSealedValue(raw)
+	if !ok {
+		return nil, errors.New("the encryption keyring is malformed")
+	}
+	plaintext, err := sealed.open(p.getConfiguration().EncryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open the encryption keyring")
+	}
+
+	var keys []string
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, errors.Wrap(err, "could not decode the encryption keyring")
+	}
+	return keys, nil
+}
+
+// retireEncryptionKey adds the key replaced by a regenerated EncryptionKey setting to the
+// keyring, sealing the keyring with the new key. Every server of a cluster calls it when the
+// setting changes, so it is idempotent.
+func (p *Plugin) retireEncryptionKey(oldKey, newKey string) error {
+	for i := 0; i < keyringMaxRetries; i++ {
+		oldRaw, appErr := p.API.KVGet(encryptionKeyringKey)
+		if appErr != nil {
+			return appErr
+		}
+
+		var keys []string
+		if oldRaw != nil {
+			keys = p.openKeyring(oldRaw, oldKey, newKey)
+			if isSealedWith(oldRaw, encryptionKeyID(newKey)) && containsKey(keys, oldKey) {
+				return nil
+			}
+		}
+
+		retired := make([]string, 0, len(keys)+1)
+		for _, key := range keys {
+			if key != oldKey && key != newKey {
+				retired = append(retired, key)
+			}
+		}
+		retired = append(retired, oldKey)
+
+		plaintext, err := json.Marshal(retired)
+		if err != nil {
+			return err
+		}
+		newRaw, err := seal(newKey, plaintext)
+		if err != nil {
+			return errors.Wrap(err, "could not seal the encryption keyring")
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(encryptionKeyringKey, newRaw, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldRaw,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("retireEncryptionKey: too many concurrent updates")
+}
+
+// syncEncryptionKey retires the encryption key replaced by the EncryptionKey setting, then stores
+// the ID of the current key as active and schedules sealing the stored tokens with it. previous
+// is the key the setting held before a configuration change, or empty on activation. A key
+// changed while the plugin was stopped is only known from the PreviousEncryptionKey setting.
+func (p *Plugin) syncEncryptionKey(previous string) error {
+	config := p.getConfiguration()
+	current := config.EncryptionKey
+	if current == "" {
+		return nil
+	}
+	keyID := encryptionKeyID(current)
+
+	// The admin sets the previous key when the stored tokens can't be opened, so it is retired
+	// whatever key the tokens were sealed with. Retiring it again is a no-op.
+	if config.PreviousEncryptionKey != "" && config.PreviousEncryptionKey != current {
+		if err := p.retireEncryptionKey(config.PreviousEncryptionKey, current); err != nil {
+			return err
+		}
+		p.scheduleTokenEncryptionMigration()
+	}
+
+	for i := 0; i < keyringMaxRetries; i++ {
+		oldRaw, appErr := p.API.KVGet(activeEncryptionKeyKey)
+		if appErr != nil {
+			return appErr
+		}
+
+		var active activeEncryptionKey
+		if oldRaw != nil {
+			if err := json.Unmarshal(oldRaw, &active); err != nil {
+				p.API.LogWarn("the active encryption key is malformed", "error", err.Error())
+			}
+		}
+		newRaw, err := json.Marshal(activeEncryptionKey{KeyID: keyID})
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(oldRaw, newRaw) {
+			return nil
+		}
+
+		changed := active.KeyID != "" && active.KeyID != keyID
+		if previous != "" && previous != current && (active.KeyID == "" || encryptionKeyID(previous) == active.KeyID) {
+			if err := p.retireEncryptionKey(previous, current); err != nil {
+				return err
+			}
+		} else if changed && encryptionKeyID(config.PreviousEncryptionKey) != active.KeyID {
+			p.API.LogWarn("The encryption key changed while the plugin was stopped. Set the previous key in the Previous Encryption Key setting so the Zoom tokens encrypted with it can be read.", "key_id", active.KeyID)
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(activeEncryptionKeyKey, newRaw, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldRaw,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
+			if changed {
+				p.scheduleTokenEncryptionMigration()
+			}
+			return nil
+		}
+	}
+
+	return errors.New("syncEncryptionKey: too many concurrent updates")
+}
+
+// openKeyring opens a keyring sealed with either key. A keyring sealed with another key can't be
+// opened, and the keys it holds are forgotten.
+func (p *Plugin) openKeyring(raw []byte, oldKey, newKey string) []string {
+	sealed, ok := parseSealedValue(raw)
+	if !ok {
+		p.API.LogWarn("the encryption keyring is malformed, the retired encryption keys are forgotten")
+		return nil
+	}
+
+	plaintext, err := sealed.open(newKey)
+	if errors.Is(err, errUnknownEncryptionKey) {
+		plaintext, err = sealed.open(oldKey)
+	}
+	if err != nil {
+		p.API.LogWarn("could not open the encryption keyring, the retired encryption keys are forgotten", "error", err.Error())
+		return nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		p.API.LogWarn("could not decode the encryption keyring, the retired encryption keys are forgotten", "error", err.Error())
+		return nil
+	}
+	return keys
+}
+
+// scheduleTokenEncryptionMigration schedules sealing the stored tokens with the current
+// encryption key, unless it is scheduled.
+func (p *Plugin) scheduleTokenEncryptionMigration() {
+	if p.jobScheduler == nil {
+		return
+	}
+
+	key := tokenEncryptionJobKeyPrefix + encryptionKeyID(p.getConfiguration().EncryptionKey)
+	jobs, err := p.jobScheduler.ListScheduledJobs()
+	if err != nil {
+		p.API.LogWarn("could not list the scheduled jobs", "error", err.Error())
+		return
+	}
+	for _, job := range jobs {
+		if job.Key == key {
+			return
+		}
+	}
+
+	if _, err := p.jobScheduler.ScheduleOnce(key, time.Now(), nil); err != nil {
+		p.API.LogWarn("could not schedule the token encryption migration", "error", err.Error())
+	}
+}
+
+// runTokenEncryptionMigration seals the stored tokens that are in the legacy format or sealed
+// with a retired key with the current encryption key.
+func (p *Plugin) runTokenEncryptionMigration() {
+	keyID := encryptionKeyID(p.getConfiguration().EncryptionKey)
+	migrated, appErr := p.API.KVGet(tokenEncryptionMigratedKey)
+	if appErr == nil && string(migrated) == keyID {
+		return
+	}
+
+	complete := true
+	for page := 0; ; page++ {
+		keys, appErr := p.API.KVList(page, tokenRefreshKeysPerPage)
+		if appErr != nil {
+			p.API.LogWarn("could not list the stored Zoom tokens", "error", appErr.Error())
+			return
+		}
+
+		for _, key := range keys {
+			if !strings.HasPrefix(key, zoomUserByMMID) && !strings.HasPrefix(key, zoomUserByZoomID) {
+				continue
+			}
+			if err := p.resealOAuthUserInfo(key, keyID); err != nil {
+				p.API.LogWarn("could not encrypt the stored Zoom token with the current key", "key", key, "error", err.Error())
+				complete = false
+			}
+		}
+
+		if len(keys) < tokenRefreshKeysPerPage {
+			break
+		}
+	}
+
+	if err := p.resealSuperUserToken(keyID); err != nil {
+		p.API.LogWarn("could not encrypt the account level Zoom token with the current key", "error", err.Error())
+		complete = false
+	}
+
+	if !complete {
+		return
+	}
+	if appErr := p.API.KVSet(tokenEncryptionMigratedKey, []byte(keyID)); appErr != nil {
+		p.API.LogWarn("could not record the token encryption migration", "error", appErr.Error())
+	}
+}
+
+// resealOAuthUserInfo seals the info of a user stored at key with the current encryption key,
+// holding the token refresh mutex of the user.
+func (p *Plugin) resealOAuthUserInfo(key, keyID string) error {
+	value, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return appErr
+	}
+	if value == nil || isSealedWith(value, keyID) {
+		return nil
+	}
+	info, err := p.decodeOAuthUserInfo(value)
+	if err != nil {
+		return err
+	}
+
+	mutex, err := p.lockTokenRefresh(info.UserID)
+	if err != nil {
+		return err
+	}
+	defer mutex.Unlock()
+
+	// A value replaced in the meantime was written with the current key, and is left alone.
+	oldValue, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return appErr
+	}
+	if oldValue == nil || isSealedWith(oldValue, keyID) {
+		return nil
+	}
+	if info, err = p.decodeOAuthUserInfo(oldValue); err != nil {
+		return err
+	}
+	encoded, err := p.encodeOAuthUserInfo(info)
+	if err != nil {
+		return err
+	}
+
+	if _, appErr := p.API.KVSetWithOptions(key, encoded, model.PluginKVSetOptions{Atomic: true, OldValue: oldValue}); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// resealSuperUserToken seals the account level token with the current encryption key, holding
+// its token refresh mutex.
+func (p *Plugin) resealSuperUserToken(keyID string) error {
+	mutex, err := p.lockTokenRefresh(superUserTokenMutexName)
+	if err != nil {
+		return err
+	}
+	defer mutex.Unlock()
+
+	oldValue, appErr := p.API.KVGet(zoomSuperUserTokenKey)
+	if appErr != nil {
+		return appErr
+	}
+	if len(oldValue) == 0 || isSealedWith(oldValue, keyID) {
+		return nil
+	}
+
+	token, err := p.decodeSuperUserToken(oldValue)
+	if err != nil {
+		return err
+	}
+	_, err = p.compareAndSetSuperUserToken(token, oldValue)
+	return err
+}
+
+func isSealedWith(raw []byte, keyID string) bool {
+	sealed, ok := parseSealedValue(raw)
+	return ok && sealed.KeyID == keyID
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	oldEncryptionKey = "4Su-mLR7N6VwC6aXjYhQoT0shtS9fKz+"
+	newEncryptionKey = "Xf1p-Q8bZ2vLk9sT4mWc7yRn0aJd3hGe"
+)
+
+func TestSealAndOpen(t *testing.T) {
+	raw, err := seal(oldEncryptionKey, []byte("secret refresh token"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret refresh token")
+
+	sealed, ok := parseSealedValue(raw)
+	require.True(t, ok)
+	assert.Equal(t, encryptionKeyID(oldEncryptionKey), sealed.KeyID)
+
+	plaintext, err := sealed.open(oldEncryptionKey)
+	require.NoError(t, err)
+	assert.Equal(t, "secret refresh token", string(plaintext))
+
+	_, err = sealed.open(newEncryptionKey)
+	assert.Equal(t, errUnknownEncryptionKey, err)
+
+	sealed.Ciphertext[len(sealed.Ciphertext)-1] ^= 1
+	_, err = sealed.open(oldEncryptionKey)
+	assert.Error(t, err, "tampered values are refused")
+
+	_, ok = parseSealedValue([]byte(`{"access_token":"token"}`))
+	assert.False(t, ok, "values stored before tokens were sealed are told apart")
+}
+
+// encryptionTestPlugin returns a plugin using key, backed by an in-memory KV store.
+func encryptionTestPlugin(key string) (*Plugin, *memoryKVStore) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	api.On("KVList", 0, tokenRefreshKeysPerPage).Return(func(int, int) []string {
+		kv.lock.Lock()
+		defer kv.lock.Unlock()
+		keys := make([]string, 0, len(kv.values))
+		for key := range kv.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}, nil)
+
+	p := webinarTestPlugin(api)
+	setEncryptionKey(p, key)
+	return p, kv
+}
+
+func setEncryptionKey(p *Plugin, key string) {
+	config := *p.getConfiguration()
+	config.EncryptionKey = key
+	p.setConfiguration(&config)
+}
+
+func TestStoreOAuthUserInfoSealsWholeToken(t *testing.T) {
+	p, kv := encryptionTestPlugin(oldEncryptionKey)
+	info := &zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		OAuthToken: &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"},
+	}
+	require.NoError(t, p.storeOAuthUserInfo(info))
+
+	for _, key := range []string{zoomUserByMMID + "user-id", zoomUserByZoomID + "zoom-id"} {
+		raw := kv.get(key)
+		assert.NotContains(t, string(raw), "refresh-token")
+		assert.NotContains(t, string(raw), "zoom-id")
+		assert.True(t, isSealedWith(raw, encryptionKeyID(oldEncryptionKey)))
+	}
+
+	require.NoError(t, p.setSuperUserToken(&oauth2.Token{AccessToken: "access-token", RefreshToken: "super-refresh-token"}))
+	assert.NotContains(t, string(kv.get(zoomSuperUserTokenKey)), "super-refresh-token")
+
+	fetched, err := p.fetchOAuthUserInfo(zoomUserByZoomID, "zoom-id")
+	require.NoError(t, err)
+	assert.Equal(t, info, fetched)
+}
+
+func TestEncryptionKeyRotation(t *testing.T) {
+	p, kv := encryptionTestPlugin(oldEncryptionKey)
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		OAuthToken: &oauth2.Token{AccessToken: "access-token", RefreshToken: "refresh-token"},
+	}))
+	require.NoError(t, p.setSuperUserToken(&oauth2.Token{AccessToken: "super-access-token"}))
+	require.NoError(t, p.syncEncryptionKey(""))
+	assert.Nil(t, kv.get(encryptionKeyringKey))
+
+	// Every server of the cluster retires the previous key when the setting changes.
+	setEncryptionKey(p, newEncryptionKey)
+	require.NoError(t, p.syncEncryptionKey(oldEncryptionKey))
+	keyring := kv.get(encryptionKeyringKey)
+	require.NotNil(t, keyring)
+	assert.NotContains(t, string(keyring), oldEncryptionKey)
+	require.NoError(t, p.syncEncryptionKey(oldEncryptionKey))
+	require.NoError(t, p.retireEncryptionKey(oldEncryptionKey, newEncryptionKey))
+	assert.Equal(t, keyring, kv.get(encryptionKeyringKey))
+	assert.NotContains(t, string(kv.get(activeEncryptionKeyKey)), newEncryptionKey)
+
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, "user-id")
+	require.NoError(t, err, "tokens sealed with the retired key are still opened")
+	assert.Equal(t, "refresh-token", info.OAuthToken.RefreshToken)
+
+	p.runTokenEncryptionMigration()
+
+	newKeyID := encryptionKeyID(newEncryptionKey)
+	assert.True(t, isSealedWith(kv.get(zoomUserByMMID+"user-id"), newKeyID))
+	assert.True(t, isSealedWith(kv.get(zoomUserByZoomID+"zoom-id"), newKeyID))
+	assert.True(t, isSealedWith(kv.get(zoomSuperUserTokenKey), newKeyID))
+	assert.Equal(t, newKeyID, string(kv.get(tokenEncryptionMigratedKey)))
+
+	token, err := p.getSuperuserToken()
+	require.NoError(t, err)
+	assert.Equal(t, "super-access-token", token.AccessToken)
+
+	// Reverting to the previous key retires the new one in turn.
+	setEncryptionKey(p, oldEncryptionKey)
+	require.NoError(t, p.syncEncryptionKey(newEncryptionKey))
+	retired, err := p.getRetiredEncryptionKeys()
+	require.NoError(t, err)
+	assert.Equal(t, []string{newEncryptionKey}, retired)
+	_, err = p.fetchOAuthUserInfo(zoomUserByMMID, "user-id")
+	assert.NoError(t, err)
+}
+
+func TestEncryptionKeyChangedWhileStopped(t *testing.T) {
+	p, kv := encryptionTestPlugin(oldEncryptionKey)
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		OAuthToken: &oauth2.Token{AccessToken: "access-token"},
+	}))
+	require.NoError(t, p.syncEncryptionKey(""))
+
+	setEncryptionKey(p, newEncryptionKey)
+	require.NoError(t, p.syncEncryptionKey(""))
+	assert.Nil(t, kv.get(encryptionKeyringKey), "the replaced key isn't known")
+	_, err := p.fetchOAuthUserInfo(zoomUserByMMID, "user-id")
+	assert.ErrorIs(t, err, errUnknownEncryptionKey)
+
+	config := *p.getConfiguration()
+	config.PreviousEncryptionKey = oldEncryptionKey
+	p.setConfiguration(&config)
+	require.NoError(t, p.syncEncryptionKey(""))
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, "user-id")
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", info.OAuthToken.AccessToken)
+	assert.NotContains(t, string(kv.get(activeEncryptionKeyKey)), newEncryptionKey)
+}
+
+func TestTokenEncryptionMigrationOfLegacyTokens(t *testing.T) {
+	p, kv := encryptionTestPlugin(oldEncryptionKey)
+
+	accessToken, err := encryptLegacy([]byte(oldEncryptionKey), "access-token")
+	require.NoError(t, err)
+	legacyInfo, err := json.Marshal(zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		OAuthToken: &oauth2.Token{AccessToken: accessToken, RefreshToken: "refresh-token", Expiry: time.Now().Add(time.Hour)},
+	})
+	require.NoError(t, err)
+	legacySuperUserToken, err := json.Marshal(&oauth2.Token{AccessToken: "super-access-token", RefreshToken: "super-refresh-token"})
+	require.NoError(t, err)
+	kv.values[zoomUserByMMID+"user-id"] = legacyInfo
+	kv.values[zoomUserByZoomID+"zoom-id"] = legacyInfo
+	kv.values[zoomSuperUserTokenKey] = legacySuperUserToken
+	kv.values[zoomChannelSettings] = []byte("{}")
+
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, "user-id")
+	require.NoError(t, err, "tokens in the legacy format are read")
+	assert.Equal(t, "access-token", info.OAuthToken.AccessToken)
+
+	p.runTokenEncryptionMigration()
+
+	keyID := encryptionKeyID(oldEncryptionKey)
+	for _, key := range []string{zoomUserByMMID + "user-id", zoomUserByZoomID + "zoom-id", zoomSuperUserTokenKey} {
+		assert.True(t, isSealedWith(kv.get(key), keyID), key)
+		assert.NotContains(t, string(kv.get(key)), "refresh-token", key)
+	}
+	assert.Equal(t, "{}", string(kv.get(zoomChannelSettings)))
+
+	info, err = p.fetchOAuthUserInfo(zoomUserByZoomID, "zoom-id")
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", info.OAuthToken.AccessToken)
+	assert.Equal(t, "refresh-token", info.OAuthToken.RefreshToken)
+
+	token, err := p.getSuperuserToken()
+	require.NoError(t, err)
+	assert.Equal(t, "super-refresh-token", token.RefreshToken)
+}
+
+func TestScheduleTokenEncryptionMigration(t *testing.T) {
+	api := &plugintest.API{}
+	p := webinarTestPlugin(api)
+	scheduler := &testJobScheduler{}
+	p.jobScheduler = scheduler
+
+	p.scheduleTokenEncryptionMigration()
+	p.scheduleTokenEncryptionMigration()
+
+	require.Len(t, scheduler.scheduled, 1)
+	assert.Equal(t, tokenEncryptionJobKeyPrefix+encryptionKeyID(testConfig.EncryptionKey), scheduler.scheduled[0].key)
+}
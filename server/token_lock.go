@@ -0,0 +1,207 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	tokenRefreshMutexKeyPrefix = "zoom_token_refresh_"
+	superUserTokenMutexName    = "super_user"
+
+	// tokenExpiryMargin is how long before its expiry a token is refreshed. It outlasts the
+	// requests made with the token, so the HTTP clients of the zoom package never refresh a token
+	// on their own without storing the result.
+	tokenExpiryMargin = 5 * time.Minute
+
+	// tokenStoreMaxRetries bounds the attempts to store a refreshed token into stored info that
+	// changed in the meantime.
+	tokenStoreMaxRetries = 5
+)
+
+// errConnectionBroken is returned for users whose refresh token was refused for good, until they
+// connect again.
+var errConnectionBroken = errors.New("the Zoom connection stopped working")
+
+// isFresh reports whether a token can be used without refreshing it. Tokens without an expiry
+// never expire, as in oauth2.
+func isFresh(token *oauth2.Token) bool {
+	return token.AccessToken != "" && (token.Expiry.IsZero() || time.Until(token.Expiry) > tokenExpiryMargin)
+}
+
+// lockTokenRefresh takes the cluster-wide mutex serializing the refreshes of a token and every
+// other write of it. Zoom rotates refresh tokens, so a refresh token redeemed twice, or a rotated
+// one overwritten, disconnects its owner.
+func (p *Plugin) lockTokenRefresh(name string) (*cluster.Mutex, error) {
+	mutex, err := cluster.NewMutex(p.API, tokenRefreshMutexKeyPrefix+name)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the token refresh mutex")
+	}
+	mutex.Lock()
+	return mutex, nil
+}
+
+// getFreshOAuthUserInfo returns the info of a user with a token that is valid for a while,
+// refreshing it first if needed. It returns errConnectionBroken for broken connections, as the
+// HTTP clients of the zoom package would refresh their stale token without storing the result.
+func (p *Plugin) getFreshOAuthUserInfo(userID string) (*zoom.OAuthUserInfo, error) {
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if info.BrokenAt != 0 {
+		return nil, errConnectionBroken
+	}
+	if isFresh(info.OAuthToken) {
+		return info, nil
+	}
+
+	return p.refreshOAuthUserInfo(userID, isFresh)
+}
+
+// refreshOAuthUserInfo refreshes the token of a user unless it is fresh by the time the mutex is
+// held, in which case another request or server refreshed it and its result is reused.
+func (p *Plugin) refreshOAuthUserInfo(userID string, fresh func(*oauth2.Token) bool) (*zoom.OAuthUserInfo, error) {
+	mutex, err := p.lockTokenRefresh(userID)
+	if err != nil {
+		return nil, err
+	}
+	defer mutex.Unlock()
+
+	oldValue, appErr := p.API.KVGet(zoomUserByMMID + userID)
+	if appErr != nil || oldValue == nil {
+		return nil, errors.New("must connect user account to Zoom first")
+	}
+	info, err := p.decodeOAuthUserInfo(oldValue)
+	if err != nil {
+		return nil, err
+	}
+	if fresh(info.OAuthToken) {
+		return info, nil
+	}
+
+	token, err := p.refreshToken(info.OAuthToken)
+	if err != nil {
+		return nil, err
+	}
+
+	info.OAuthToken = token
+	info.RefreshedAt = model.GetMillis()
+	info, err = p.storeRefreshedOAuthUserInfo(info, oldValue)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not store the refreshed token")
+	}
+	return info, nil
+}
+
+// storeRefreshedOAuthUserInfo stores the info of a user with a refreshed token, written with a
+// compare-and-set against oldValue. Zoom already rotated the refresh token, so when the stored
+// info changed anyway, it is read again and the refreshed token is stored into it rather than
+// dropped. The token refresh mutex of the user must be held.
+func (p *Plugin) storeRefreshedOAuthUserInfo(info *zoom.OAuthUserInfo, oldValue []byte) (*zoom.OAuthUserInfo, error) {
+	for i := 0; i < tokenStoreMaxRetries; i++ {
+		ok, err := p.compareAndStoreOAuthUserInfo(info, oldValue)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return info, nil
+		}
+
+		var appErr *model.AppError
+		if oldValue, appErr = p.API.KVGet(zoomUserByMMID + info.UserID); appErr != nil {
+			return nil, appErr
+		}
+		if oldValue == nil {
+			continue
+		}
+		current, err := p.decodeOAuthUserInfo(oldValue)
+		if err != nil || current.ZoomID != info.ZoomID {
+			continue
+		}
+		current.OAuthToken = info.OAuthToken
+		current.RefreshedAt = info.RefreshedAt
+		current.BrokenAt = 0
+		info = current
+	}
+
+	return nil, errors.New("storeRefreshedOAuthUserInfo: too many concurrent updates")
+}
+
+// getFreshSuperUserToken returns the account level token, refreshing it first if needed.
+func (p *Plugin) getFreshSuperUserToken() (*oauth2.Token, error) {
+	token, err := p.getSuperuserToken()
+	if err != nil || token == nil || isFresh(token) {
+		return token, err
+	}
+
+	return p.refreshSuperUserTokenLocked(isFresh)
+}
+
+// refreshSuperUserTokenLocked refreshes the account level token like refreshOAuthUserInfo does
+// the token of a user.
+func (p *Plugin) refreshSuperUserTokenLocked(fresh func(*oauth2.Token) bool) (*oauth2.Token, error) {
+	mutex, err := p.lockTokenRefresh(superUserTokenMutexName)
+	if err != nil {
+		return nil, err
+	}
+	defer mutex.Unlock()
+
+	oldValue, appErr := p.API.KVGet(zoomSuperUserTokenKey)
+	if appErr != nil {
+		return nil, appErr
+	}
+	token, err := p.decodeSuperUserToken(oldValue)
+	if err != nil || token == nil || fresh(token) {
+		return token, err
+	}
+
+	refreshed, err := p.refreshToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zoom already rotated the refresh token, so it is stored even if the stored token changed.
+	ok, err := p.compareAndSetSuperUserToken(refreshed, oldValue)
+	if err == nil && !ok {
+		err = p.storeSuperUserTokenLocked(refreshed)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not store the refreshed token")
+	}
+	return refreshed, nil
+}
+
+// RefreshZoomSuperUserToken implements zoom.PluginAPI.
+func (p *Plugin) RefreshZoomSuperUserToken() (*oauth2.Token, error) {
+	if p.getConfiguration().ServerToServerApp {
+		return p.getAccountToken()
+	}
+
+	token, err := p.getFreshSuperUserToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get token")
+	}
+	if token == nil {
+		return nil, errors.New("the Zoom app is not connected")
+	}
+	return token, nil
+}
+
+// RefreshZoomOAuthUserToken implements zoom.PluginAPI.
+func (p *Plugin) RefreshZoomOAuthUserToken(userID string) (*oauth2.Token, error) {
+	info, err := p.getFreshOAuthUserInfo(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get token")
+	}
+	return info.OAuthToken, nil
+}
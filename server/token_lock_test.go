@@ -0,0 +1,205 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// allowTokenRefreshMutex lets the token refresh mutexes be taken without contention.
+func allowTokenRefreshMutex(api *plugintest.API) {
+	isMutexKey := mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "mutex_"+tokenRefreshMutexKeyPrefix) })
+	api.On("KVSetWithOptions", isMutexKey, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+}
+
+// memoryKVStore backs the KV calls of the token refresh with a map, honoring compare-and-set
+// writes and the cluster mutexes like the server does.
+type memoryKVStore struct {
+	lock   sync.Mutex
+	values map[string][]byte
+}
+
+func (s *memoryKVStore) get(key string) []byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.values[key]
+}
+
+func (s *memoryKVStore) set(key string, value []byte, options model.PluginKVSetOptions) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if options.Atomic && string(s.values[key]) != string(options.OldValue) {
+		return false
+	}
+	if value == nil {
+		delete(s.values, key)
+	} else {
+		s.values[key] = value
+	}
+	return true
+}
+
+func (s *memoryKVStore) mock(api *plugintest.API) {
+	api.On("KVGet", mock.AnythingOfType("string")).Return(func(key string) []byte { return s.get(key) }, nil)
+	api.On("KVSet", mock.AnythingOfType("string"), mock.Anything).Run(func(args mock.Arguments) {
+		s.set(args.String(0), args.Get(1).([]byte), model.PluginKVSetOptions{})
+	}).Return(nil)
+	api.On("KVSetWithOptions", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(
+		func(key string, value []byte, options model.PluginKVSetOptions) bool {
+			return s.set(key, value, options)
+		},
+		nil,
+	)
+}
+
+func TestRefreshOAuthUserTokenOnce(t *testing.T) {
+	var refreshes int32
+	ts := newRefreshTokenServer(t, &refreshes)
+	defer ts.Close()
+
+	config := *testConfig
+	config.EncryptionKey = "4Su-mLR7N6VwC6aXjYhQoT0shtS9fKz+"
+	config.ZoomURL = ts.URL
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := webinarTestPlugin(api)
+	p.setConfiguration(&config)
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		OAuthToken: &oauth2.Token{AccessToken: "expired", RefreshToken: "first", Expiry: time.Now().Add(-time.Minute)},
+	}))
+
+	var wg sync.WaitGroup
+	tokens := make([]*oauth2.Token, 5)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := p.RefreshZoomOAuthUserToken("user-id")
+			assert.NoError(t, err)
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes), "the refresh token is redeemed once")
+	for _, token := range tokens {
+		require.NotNil(t, token)
+		assert.Equal(t, "rotated-first", token.RefreshToken)
+	}
+
+	stored, err := p.decodeOAuthUserInfo(kv.get(zoomUserByZoomID + "zoom-id"))
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-first", stored.OAuthToken.RefreshToken)
+}
+
+func TestRefreshOAuthUserTokenKeepsRotatedToken(t *testing.T) {
+	ts := newRefreshTokenServer(t, new(int32))
+	defer ts.Close()
+
+	config := *testConfig
+	config.ZoomURL = ts.URL
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := webinarTestPlugin(api)
+	p.setConfiguration(&config)
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		ZoomEmail:  "old@example.com",
+		OAuthToken: &oauth2.Token{AccessToken: "expired", RefreshToken: "first", Expiry: time.Now().Add(-time.Minute)},
+	}))
+
+	// The info is rewritten while the token is being refreshed, by a server not holding the mutex.
+	info, err := p.refreshOAuthUserInfo("user-id", func(token *oauth2.Token) bool {
+		encoded, err := p.encodeOAuthUserInfo(&zoom.OAuthUserInfo{
+			UserID:     "user-id",
+			ZoomID:     "zoom-id",
+			ZoomEmail:  "new@example.com",
+			OAuthToken: token,
+		})
+		require.NoError(t, err)
+		kv.values[zoomUserByMMID+"user-id"] = encoded
+		return false
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-first", info.OAuthToken.RefreshToken)
+
+	stored, err := p.fetchOAuthUserInfo(zoomUserByMMID, "user-id")
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-first", stored.OAuthToken.RefreshToken, "the rotated token isn't dropped")
+	assert.Equal(t, "new@example.com", stored.ZoomEmail)
+}
+
+func TestRefreshSuperUserTokenKeepsRotatedToken(t *testing.T) {
+	ts := newRefreshTokenServer(t, new(int32))
+	defer ts.Close()
+
+	config := *testConfig
+	config.AccountLevelApp = true
+	config.ZoomURL = ts.URL
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := webinarTestPlugin(api)
+	p.setConfiguration(&config)
+
+	expired, err := json.Marshal(&oauth2.Token{AccessToken: "expired", RefreshToken: "first", Expiry: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	kv.values[zoomSuperUserTokenKey] = expired
+
+	// The token is rewritten while it is being refreshed, by a server not holding the mutex.
+	refreshed, err := p.refreshSuperUserTokenLocked(func(token *oauth2.Token) bool {
+		rewritten, err := json.Marshal(&oauth2.Token{AccessToken: token.AccessToken, RefreshToken: token.RefreshToken})
+		require.NoError(t, err)
+		kv.values[zoomSuperUserTokenKey] = rewritten
+		return false
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-first", refreshed.RefreshToken)
+
+	token, err := p.getSuperuserToken()
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-first", token.RefreshToken, "the rotated token isn't dropped")
+}
+
+func TestGetFreshOAuthUserInfoOfBrokenConnection(t *testing.T) {
+	api := &plugintest.API{}
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := webinarTestPlugin(api)
+	require.NoError(t, p.storeOAuthUserInfo(&zoom.OAuthUserInfo{
+		UserID:     "user-id",
+		ZoomID:     "zoom-id",
+		OAuthToken: &oauth2.Token{AccessToken: "expired", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Minute)},
+		BrokenAt:   1700000000000,
+	}))
+
+	_, err := p.getFreshOAuthUserInfo("user-id")
+	assert.Equal(t, errConnectionBroken, err)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	tokenRefreshJobKeyPrefix = "token_refresh_"
+
+	// tokenRefreshInterval is how often the stored tokens are checked. Tokens expiring before the
+	// next check are refreshed, which also keeps refresh tokens from expiring unused.
+	tokenRefreshInterval = 24 * time.Hour
+
+	tokenRefreshKeysPerPage = 100
+	adminsPerPage           = 100
+
+	// zoomSuperUserTokenBrokenKey records when the account level token stopped working, so the
+	// admins are told once. It is cleared when an admin connects the app again.
+	zoomSuperUserTokenBrokenKey = "zoomSuperUserTokenBroken_"
+
+	reconnectUserMessage  = "Your Zoom connection stopped working, so you can't start Zoom meetings from Mattermost until you reconnect. [Click here to reconnect your Zoom account.](%s/plugins/zoom/oauth2/connect)"
+	reconnectAdminMessage = "The Zoom account level connection stopped working, so nobody can start Zoom meetings from Mattermost until a system admin reconnects it. [Click here to reconnect the Zoom app.](%s/plugins/zoom/oauth2/connect)"
+)
+
+// errTokenRevoked is returned when Zoom refuses the refresh token for good, as opposed to
+// failures worth retrying at the next check.
+var errTokenRevoked = errors.New("the refresh token was revoked or has expired")
+
+// tokenRefreshJobKey names the check running at runAt. Runs are aligned on the interval so the
+// servers of a cluster schedule the same job.
+func tokenRefreshJobKey(runAt time.Time) string {
+	return tokenRefreshJobKeyPrefix + strconv.FormatInt(runAt.Unix(), 10)
+}
+
+// scheduleTokenRefresh schedules the next check of the stored tokens, unless one is scheduled.
+func (p *Plugin) scheduleTokenRefresh(now time.Time) {
+	if p.jobScheduler == nil {
+		return
+	}
+
+	jobs, err := p.jobScheduler.ListScheduledJobs()
+	if err != nil {
+		p.API.LogWarn("could not list the scheduled jobs", "error", err.Error())
+		return
+	}
+	for _, job := range jobs {
+		if strings.HasPrefix(job.Key, tokenRefreshJobKeyPrefix) && job.RunAt.After(now) {
+			return
+		}
+	}
+
+	runAt := now.Truncate(tokenRefreshInterval).Add(tokenRefreshInterval)
+	if _, err := p.jobScheduler.ScheduleOnce(tokenRefreshJobKey(runAt), runAt, nil); err != nil {
+		p.API.LogWarn("could not schedule the token refresh", "error", err.Error())
+	}
+}
+
+// runTokenRefreshJob refreshes the stored tokens ahead of their expiry, and tells the owners of
+// the connections that stopped working to reconnect.
+func (p *Plugin) runTokenRefreshJob() {
+	now := time.Now()
+	defer p.scheduleTokenRefresh(now)
+
+	config := p.getConfiguration()
+	switch {
+	case config.ServerToServerApp:
+		// Server-to-Server tokens are minted when needed.
+	case config.AccountLevelApp:
+		p.refreshSuperUserToken(now)
+	default:
+		p.refreshOAuthUserTokens(now)
+	}
+}
+
+// needsRefresh reports whether a token expires before the next check.
+func needsRefresh(token *oauth2.Token, now time.Time) bool {
+	return token.Expiry.IsZero() || token.Expiry.Before(now.Add(tokenRefreshInterval))
+}
+
+// refreshToken exchanges the refresh token for a new token, even when the access token is valid.
+func (p *Plugin) refreshToken(token *oauth2.Token) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	refreshed, err := p.getOAuthConfig().TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.Response != nil &&
+			retrieveErr.Response.StatusCode >= http.StatusBadRequest && retrieveErr.Response.StatusCode < http.StatusInternalServerError {
+			return nil, errors.Wrap(errTokenRevoked, err.Error())
+		}
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+// refreshOAuthUserTokens refreshes the tokens of the users in the index of connected users.
+func (p *Plugin) refreshOAuthUserTokens(now time.Time) {
+	index, err := p.getCompleteConnectedUsersIndex()
+	if err != nil {
+		p.API.LogWarn("could not list the connected users", "error", err.Error())
+		return
+	}
+
+	for userID := range index.Users {
+		p.refreshOAuthUserToken(userID, now)
+	}
+}
+
+func (p *Plugin) refreshOAuthUserToken(userID string, now time.Time) {
+	info, err := p.fetchOAuthUserInfo(zoomUserByMMID, userID)
+	if err != nil {
+		p.API.LogWarn("could not read the stored Zoom token", "user_id", userID, "error", err.Error())
+		return
+	}
+	if info.BrokenAt != 0 || !needsRefresh(info.OAuthToken, now) {
+		return
+	}
+
+	_, err = p.refreshOAuthUserInfo(userID, func(token *oauth2.Token) bool { return !needsRefresh(token, now) })
+	if errors.Is(err, errTokenRevoked) {
+		p.markOAuthUserTokenBroken(info, now)
+		return
+	}
+	if err != nil {
+		p.API.LogWarn("could not refresh the Zoom token", "user_id", userID, "error", err.Error())
+	}
+}
+
+// markOAuthUserTokenBroken records that the token of a user was refused, holding the token
+// refresh mutex of the user and writing with a compare-and-set against the value read.
+func (p *Plugin) markOAuthUserTokenBroken(info *zoom.OAuthUserInfo, now time.Time) {
+	mutex, err := p.lockTokenRefresh(info.UserID)
+	if err != nil {
+		p.API.LogWarn("could not mark the Zoom connection as broken", "user_id", info.UserID, "error", err.Error())
+		return
+	}
+	defer mutex.Unlock()
+
+	// The token may have been refreshed when the user started a meeting in the meantime.
+	oldValue, appErr := p.API.KVGet(zoomUserByMMID + info.UserID)
+	if appErr != nil || oldValue == nil {
+		return
+	}
+	current, err := p.decodeOAuthUserInfo(oldValue)
+	if err != nil || current.OAuthToken.RefreshToken != info.OAuthToken.RefreshToken {
+		return
+	}
+
+	current.BrokenAt = model.GetMillisForTime(now)
+	ok, err := p.compareAndStoreOAuthUserInfo(current, oldValue)
+	if err != nil {
+		p.API.LogWarn("could not mark the Zoom connection as broken", "user_id", info.UserID, "error", err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+
+	p.API.LogWarn("the Zoom connection of a user stopped working", "user_id", info.UserID)
+	if err := p.sendDirectMessage(info.UserID, fmt.Sprintf(reconnectUserMessage, p.siteURL)); err != nil {
+		p.API.LogWarn("could not ask the user to reconnect to Zoom", "user_id", info.UserID, "error", err.Error())
+	}
+}
+
+func (p *Plugin) refreshSuperUserToken(now time.Time) {
+	token, err := p.getSuperuserToken()
+	if err != nil {
+		p.API.LogWarn("could not read the account level Zoom token", "error", err.Error())
+		return
+	}
+	if token == nil || !needsRefresh(token, now) {
+		return
+	}
+
+	if p.isSuperUserTokenBroken() {
+		return
+	}
+
+	_, err = p.refreshSuperUserTokenLocked(func(token *oauth2.Token) bool { return !needsRefresh(token, now) })
+	if errors.Is(err, errTokenRevoked) {
+		p.markSuperUserTokenBroken(token, now)
+		return
+	}
+	if err != nil {
+		p.API.LogWarn("could not refresh the account level Zoom token", "error", err.Error())
+	}
+}
+
+func (p *Plugin) markSuperUserTokenBroken(token *oauth2.Token, now time.Time) {
+	// The token may have been refreshed when a meeting was started in the meantime.
+	current, err := p.getSuperuserToken()
+	if err != nil || current == nil || current.RefreshToken != token.RefreshToken {
+		return
+	}
+
+	if appErr := p.API.KVSet(zoomSuperUserTokenBrokenKey, []byte(strconv.FormatInt(model.GetMillisForTime(now), 10))); appErr != nil {
+		p.API.LogWarn("could not mark the account level Zoom connection as broken", "error", appErr.Error())
+		return
+	}
+
+	p.API.LogWarn("the account level Zoom connection stopped working")
+	p.notifySystemAdmins(fmt.Sprintf(reconnectAdminMessage, p.siteURL))
+}
+
+// clearSuperUserTokenBroken forgets that the account level token stopped working.
+func (p *Plugin) clearSuperUserTokenBroken() {
+	if appErr := p.API.KVDelete(zoomSuperUserTokenBrokenKey); appErr != nil {
+		p.API.LogWarn("could not clear the account level Zoom token health", "error", appErr.Error())
+	}
+}
+
+func (p *Plugin) notifySystemAdmins(message string) {
+	for page := 0; ; page++ {
+		admins, appErr := p.API.GetUsers(&model.UserGetOptions{Role: model.SystemAdminRoleId, Active: true, Page: page, PerPage: adminsPerPage})
+		if appErr != nil {
+			p.API.LogWarn("could not list the system admins", "error", appErr.Error())
+			return
+		}
+
+		for _, admin := range admins {
+			if err := p.sendDirectMessage(admin.Id, message); err != nil {
+				p.API.LogWarn("could not notify the system admin", "user_id", admin.Id, "error", err.Error())
+			}
+		}
+
+		if len(admins) < adminsPerPage {
+			return
+		}
+	}
+}
+
+// isSuperUserTokenBroken reports whether the account level token stopped working.
+func (p *Plugin) isSuperUserTokenBroken() bool {
+	broken, appErr := p.API.KVGet(zoomSuperUserTokenBrokenKey)
+	return appErr == nil && broken != nil
+}
+
+// newReconnectState stores the OAuth state of a user following a reconnect link. The connection
+// is confirmed in the direct channel with the bot, where the link was sent.
+func (p *Plugin) newReconnectState(userID string) (string, error) {
+	channel, appErr := p.API.GetDirectChannel(userID, p.botUserID)
+	if appErr != nil {
+		return "", errors.Wrap(appErr, "could not get the direct channel with the bot")
+	}
+	if appErr = p.storeOAuthUserState(userID, channel.Id, true); appErr != nil {
+		return "", errors.Wrap(appErr, "could not store the OAuth user state")
+	}
+	state, appErr := p.fetchOAuthUserState(userID)
+	if appErr != nil {
+		return "", errors.Wrap(appErr, "could not fetch the OAuth user state")
+	}
+	return state, nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// newRefreshTokenServer stands in for the Zoom token endpoint, counting the refreshes. It revokes
+// the refresh token "revoked" and rotates any other.
+func newRefreshTokenServer(t *testing.T, refreshes *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/oauth/token", r.URL.Path)
+		assert.Equal(t, "refresh_token", r.FormValue("grant_type"))
+		atomic.AddInt32(refreshes, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "new-access-token",
+			"refresh_token": "rotated-" + r.FormValue("refresh_token"),
+			"token_type":    "bearer",
+			"expires_in":    3600,
+		}))
+	}))
+}
+
+func TestScheduleTokenRefresh(t *testing.T) {
+	api := &plugintest.API{}
+	p := webinarTestPlugin(api)
+	scheduler := &testJobScheduler{}
+	p.jobScheduler = scheduler
+
+	now := time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC)
+	p.scheduleTokenRefresh(now)
+	p.scheduleTokenRefresh(now)
+
+	require.Len(t, scheduler.scheduled, 1)
+	runAt := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	assert.True(t, runAt.Equal(scheduler.scheduled[0].runAt))
+	assert.Equal(t, tokenRefreshJobKey(runAt), scheduler.scheduled[0].key)
+}
+
+func TestRefreshOAuthUserTokens(t *testing.T) {
+	ts := newRefreshTokenServer(t, new(int32))
+	defer ts.Close()
+
+	config := *testConfig
+	config.EncryptionKey = "4Su-mLR7N6VwC6aXjYhQoT0shtS9fKz+"
+	config.ZoomURL = ts.URL
+
+	storedInfo := func(userID, refreshToken string, expiry time.Time) []byte {
+		accessToken, err := encryptLegacy([]byte(config.EncryptionKey), "access-token")
+		require.NoError(t, err)
+		b, err := json.Marshal(zoom.OAuthUserInfo{
+			UserID:     userID,
+			ZoomID:     "zoom-" + userID,
+			OAuthToken: &oauth2.Token{AccessToken: accessToken, RefreshToken: refreshToken, Expiry: expiry},
+		})
+		require.NoError(t, err)
+		return b
+	}
+
+	now := time.Now()
+	stored := map[string]*zoom.OAuthUserInfo{}
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	index, err := json.Marshal(connectedUsersIndex{
+		Users: map[string]connectedUser{
+			"active-id":  {ZoomID: "zoom-active-id"},
+			"revoked-id": {ZoomID: "zoom-revoked-id"},
+			"fresh-id":   {ZoomID: "zoom-fresh-id"},
+		},
+		Complete: true,
+	})
+	require.NoError(t, err)
+	api.On("KVGet", connectedUsersKey).Return(index, nil)
+	api.On("KVGet", zoomUserByMMID+"active-id").Return(storedInfo("active-id", "active", now.Add(time.Hour)), nil)
+	api.On("KVGet", zoomUserByMMID+"revoked-id").Return(storedInfo("revoked-id", "revoked", now.Add(-time.Hour)), nil)
+	api.On("KVGet", zoomUserByMMID+"fresh-id").Return(storedInfo("fresh-id", "fresh", now.Add(48*time.Hour)), nil)
+	var p *Plugin
+	store := func(args mock.Arguments) {
+		info, err := p.decodeOAuthUserInfo(args.Get(1).([]byte))
+		require.NoError(t, err)
+		stored[args.String(0)] = info
+	}
+	isTokenKey := mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "zoomtoken") })
+	api.On("KVSet", isTokenKey, mock.Anything).Run(store).Return(nil)
+	api.On("KVSetWithOptions", isTokenKey, mock.Anything, mock.Anything).Run(store).Return(true, nil)
+	allowTokenRefreshMutex(api)
+	api.On("GetDirectChannel", "revoked-id", "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == "dm-id" && strings.Contains(post.Message, "reconnect your Zoom account")
+	})).Return(&model.Post{}, nil).Once()
+	p = webinarTestPlugin(api)
+	p.setConfiguration(&config)
+
+	p.refreshOAuthUserTokens(now)
+
+	require.Contains(t, stored, zoomUserByMMID+"active-id")
+	assert.Equal(t, "rotated-active", stored[zoomUserByMMID+"active-id"].OAuthToken.RefreshToken)
+	assert.Zero(t, stored[zoomUserByMMID+"active-id"].BrokenAt)
+	assert.Contains(t, stored, zoomUserByZoomID+"zoom-active-id")
+
+	require.Contains(t, stored, zoomUserByMMID+"revoked-id")
+	assert.Equal(t, "revoked", stored[zoomUserByMMID+"revoked-id"].OAuthToken.RefreshToken)
+	assert.Equal(t, model.GetMillisForTime(now), stored[zoomUserByMMID+"revoked-id"].BrokenAt)
+
+	assert.NotContains(t, stored, zoomUserByMMID+"fresh-id", "tokens valid until the next check are left alone")
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "KVList", mock.Anything, mock.Anything)
+}
+
+func TestRefreshSuperUserTokenRevoked(t *testing.T) {
+	ts := newRefreshTokenServer(t, new(int32))
+	defer ts.Close()
+
+	config := *testConfig
+	config.AccountLevelApp = true
+	config.ZoomURL = ts.URL
+
+	token, err := json.Marshal(&oauth2.Token{AccessToken: "access-token", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", zoomSuperUserTokenKey).Return(token, nil)
+	api.On("KVGet", zoomSuperUserTokenBrokenKey).Return(nil, nil)
+	api.On("KVSet", zoomSuperUserTokenBrokenKey, mock.Anything).Return(nil).Once()
+	allowTokenRefreshMutex(api)
+	api.On("GetUsers", &model.UserGetOptions{Role: model.SystemAdminRoleId, Active: true, Page: 0, PerPage: adminsPerPage}).Return([]*model.User{{Id: "admin-id"}}, nil)
+	api.On("GetDirectChannel", "admin-id", "bot-id").Return(&model.Channel{Id: "dm-id"}, nil)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.ChannelId == "dm-id" && strings.Contains(post.Message, "reconnect the Zoom app")
+	})).Return(&model.Post{}, nil).Once()
+	p := webinarTestPlugin(api)
+	p.setConfiguration(&config)
+
+	p.runTokenRefreshJob()
+
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "KVSet", zoomSuperUserTokenKey, mock.Anything)
+}
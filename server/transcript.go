@@ -0,0 +1,167 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// transcriptPartMaxRunes leaves room under the post size limit for the part header.
+const transcriptPartMaxRunes = model.PostMessageMaxRunesV2 - 100
+
+var transcriptSpeakerEscaper = strings.NewReplacer("*", `\*`, "_", `\_`, "`", "\\`")
+
+// postTranscriptText posts a WebVTT transcript as speaker-grouped Markdown in the thread of
+// the meeting post, over as many posts as it takes, and adds it to the transcript search index.
+// A summary of the meeting follows when summaries are enabled.
+func (p *Plugin) postTranscriptText(data []byte, meeting *zoom.RecordingWebhookObject, post *model.Post) {
+	cues, err := zoom.ParseWebVTT(data)
+	if err != nil {
+		p.API.LogDebug("The transcript is not WebVTT, only the file is posted", "post_id", post.Id, "err", err.Error())
+		return
+	}
+
+	segments := zoom.GroupTranscript(cues)
+	if len(segments) == 0 {
+		return
+	}
+
+	if err := p.indexTranscript(meeting, post, segments); err != nil {
+		p.API.LogWarn("Could not index the transcript", "post_id", post.Id, "err", err.Error())
+	}
+
+	mentions := p.transcriptSpeakerMentions(meeting.UUID, post)
+	parts := transcriptMarkdownParts(segments, mentions, transcriptPartMaxRunes)
+	for i, part := range parts {
+		header := "#### Transcript"
+		if len(parts) > 1 {
+			header += fmt.Sprintf(" (%d/%d)", i+1, len(parts))
+		}
+
+		if _, appErr := p.API.CreatePost(&model.Post{
+			UserId:    p.botUserID,
+			ChannelId: post.ChannelId,
+			RootId:    post.Id,
+			Message:   header + "\n\n" + part,
+		}); appErr != nil {
+			p.API.LogWarn("Could not post the transcript", "post_id", post.Id, "part", i+1, "err", appErr.Error())
+			return
+		}
+	}
+
+	if p.getConfiguration().EnableMeetingSummaries {
+		if err := p.postMeetingSummary(meeting, post, segments, mentions); err != nil {
+			p.API.LogWarn("Could not post the meeting summary", "post_id", post.Id, "err", err.Error())
+		}
+	}
+}
+
+// transcriptSpeakerMentions maps the lowercased names of the meeting participants to mentions
+// of the Mattermost users matched by email. Names shared by different users are left out.
+func (p *Plugin) transcriptSpeakerMentions(meetingUUID string, post *model.Post) map[string]string {
+	mentions := map[string]string{}
+	if meetingUUID == "" {
+		return mentions
+	}
+
+	client, err := p.getMeetingPostClient([]*model.Post{post})
+	if err != nil {
+		p.API.LogDebug("Could not get a Zoom client to match the transcript speakers", "err", err.Error())
+		return mentions
+	}
+
+	records, err := client.GetPastMeetingParticipants(meetingUUID)
+	if err != nil {
+		p.API.LogDebug("Could not fetch the meeting participants to match the transcript speakers", "meeting_uuid", meetingUUID, "err", err.Error())
+		return mentions
+	}
+
+	attendees := buildAttendance(records)
+	p.mapAttendees(attendees)
+
+	ambiguous := map[string]bool{}
+	for _, a := range attendees {
+		if a.Name == "" || a.Username == "" {
+			continue
+		}
+
+		name := strings.ToLower(a.Name)
+		if mention, ok := mentions[name]; ok && mention != "@"+a.Username {
+			ambiguous[name] = true
+		}
+		mentions[name] = "@" + a.Username
+	}
+	for name := range ambiguous {
+		delete(mentions, name)
+	}
+
+	return mentions
+}
+
+// transcriptMarkdownParts renders the segments as Markdown and packs them into parts of at most
+// maxRunes runes. Segments too long for a part of their own are split between words.
+func transcriptMarkdownParts(segments []zoom.TranscriptSegment, mentions map[string]string, maxRunes int) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		runes   int
+	)
+	add := func(paragraph string) {
+		n := len([]rune(paragraph))
+		if runes > 0 && runes+2+n > maxRunes {
+			parts = append(parts, current.String())
+			current.Reset()
+			runes = 0
+		}
+		if runes > 0 {
+			current.WriteString("\n\n")
+			runes += 2
+		}
+		current.WriteString(paragraph)
+		runes += n
+	}
+
+	for _, segment := range segments {
+		speaker, ok := mentions[strings.ToLower(segment.Speaker)]
+		if !ok {
+			speaker = transcriptSpeakerEscaper.Replace(segment.Speaker)
+		}
+
+		for _, paragraph := range splitTranscriptText(segment.Markdown(speaker), maxRunes) {
+			add(paragraph)
+		}
+	}
+	if runes > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+// splitTranscriptText splits text into chunks of at most maxRunes runes, between words where possible.
+func splitTranscriptText(text string, maxRunes int) []string {
+	var chunks []string
+	runes := []rune(text)
+	for len(runes) > maxRunes {
+		cut := maxRunes
+		for i := maxRunes; i > 0; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	if len(runes) > 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	// transcriptIndexKeyPrefix is followed by the ID of the meeting post the transcript was posted to.
+	transcriptIndexKeyPrefix   = "transcript_index_"
+	transcriptIndexKeysPerPage = 1000
+
+	// transcriptChannelIndexKeyPrefix is followed by the ID of a channel, and indexes the meeting
+	// posts of the channel with an indexed transcript. transcriptIndexCompleteKey is set once the
+	// transcripts indexed before the channel indexes existed were added to them.
+	transcriptChannelIndexKeyPrefix = "transcript_channel_"
+	transcriptChannelIndexRetries   = 5
+	transcriptIndexCompleteKey      = "transcript_index_complete"
+
+	transcriptSearchMaxResults = 20
+	transcriptSnippetRunes     = 160
+)
+
+// transcriptIndexEntry is the parsed transcript of a meeting, as posted to the thread of one meeting post.
+type transcriptIndexEntry struct {
+	MeetingID   int                      `json:"meeting_id"`
+	MeetingUUID string                   `json:"meeting_uuid"`
+	Topic       string                   `json:"topic"`
+	PostID      string                   `json:"post_id"`
+	ChannelID   string                   `json:"channel_id"`
+	IndexedAt   int64                    `json:"indexed_at"`
+	Segments    []transcriptIndexSegment `json:"segments"`
+}
+
+// transcriptChannelIndex maps the IDs of the meeting posts of a channel with an indexed
+// transcript to when it was indexed.
+type transcriptChannelIndex map[string]int64
+
+type transcriptIndexSegment struct {
+	Speaker string `json:"speaker,omitempty"`
+	// Start is the offset into the recording in milliseconds.
+	Start int64  `json:"start"`
+	Text  string `json:"text"`
+}
+
+// errTranscriptChannelForbidden is returned when searching the transcripts of a channel the user
+// can't read.
+var errTranscriptChannelForbidden = errors.New("the user can't read the channel")
+
+// transcriptSearchResult is a transcript segment matching a search.
+type transcriptSearchResult struct {
+	MeetingID   int    `json:"meeting_id"`
+	MeetingUUID string `json:"meeting_uuid"`
+	Topic       string `json:"topic"`
+	PostID      string `json:"post_id"`
+	ChannelID   string `json:"channel_id"`
+	Permalink   string `json:"permalink"`
+	Speaker     string `json:"speaker"`
+	Start       int64  `json:"start"`
+	Timestamp   string `json:"timestamp"`
+	Snippet     string `json:"snippet"`
+}
+
+// indexTranscript stores the segments of a transcript for search. Indexing the same meeting
+// post again replaces its entry.
+func (p *Plugin) indexTranscript(meeting *zoom.RecordingWebhookObject, post *model.Post, segments []zoom.TranscriptSegment) error {
+	entry := transcriptIndexEntry{
+		MeetingID:   meeting.ID,
+		MeetingUUID: meeting.UUID,
+		Topic:       meeting.Topic,
+		PostID:      post.Id,
+		ChannelID:   post.ChannelId,
+		IndexedAt:   model.GetMillis(),
+		Segments:    transcriptIndexSegments(segments),
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if appErr := p.API.KVSet(transcriptIndexKeyPrefix+post.Id, data); appErr != nil {
+		return appErr
+	}
+	return p.addToTranscriptChannelIndex(post.ChannelId, transcriptChannelIndex{post.Id: entry.IndexedAt})
+}
+
+func (p *Plugin) getTranscriptChannelIndex(channelID string) (transcriptChannelIndex, []byte, error) {
+	data, appErr := p.API.KVGet(transcriptChannelIndexKeyPrefix + channelID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	index := transcriptChannelIndex{}
+	if data != nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, nil, errors.Wrap(err, "corrupted transcript channel index")
+		}
+	}
+	return index, data, nil
+}
+
+// addToTranscriptChannelIndex adds meeting posts to the index of a channel with a compare-and-set
+// write.
+func (p *Plugin) addToTranscriptChannelIndex(channelID string, posts transcriptChannelIndex) error {
+	for i := 0; i < transcriptChannelIndexRetries; i++ {
+		index, oldData, err := p.getTranscriptChannelIndex(channelID)
+		if err != nil {
+			return err
+		}
+		for postID, indexedAt := range posts {
+			index[postID] = indexedAt
+		}
+
+		newData, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(transcriptChannelIndexKeyPrefix+channelID, newData, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldData,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("addToTranscriptChannelIndex: too many concurrent updates")
+}
+
+func transcriptIndexSegments(segments []zoom.TranscriptSegment) []transcriptIndexSegment {
+	indexed := make([]transcriptIndexSegment, 0, len(segments))
+	for _, segment := range segments {
+		indexed = append(indexed, transcriptIndexSegment{
+			Speaker: segment.Speaker,
+			Start:   segment.Start.Milliseconds(),
+			Text:    segment.Text,
+		})
+	}
+	return indexed
+}
+
+// permalink returns the link to a post that redirects to it in its team.
+func (p *Plugin) permalink(postID string) string {
+	return fmt.Sprintf("%s/_redirect/pl/%s", p.siteURL, postID)
+}
+
+// searchTranscripts returns the transcript segments of the meetings of a channel containing every
+// one of the terms, newest meeting first. It returns errTranscriptChannelForbidden when the user
+// can't read the channel.
+func (p *Plugin) searchTranscripts(userID, channelID, terms string) ([]transcriptSearchResult, error) {
+	words := strings.Fields(strings.ToLower(terms))
+	if len(words) == 0 {
+		return nil, errors.New("no search terms")
+	}
+	if !p.API.HasPermissionToChannel(userID, channelID, model.PermissionReadChannel) {
+		return nil, errTranscriptChannelForbidden
+	}
+
+	entries, err := p.listChannelTranscripts(channelID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].IndexedAt > entries[j].IndexedAt })
+
+	results := []transcriptSearchResult{}
+	for _, entry := range entries {
+		for _, segment := range entry.Segments {
+			snippet, ok := transcriptSnippet(segment.Text, words, transcriptSnippetRunes)
+			if !ok {
+				continue
+			}
+
+			results = append(results, transcriptSearchResult{
+				MeetingID:   entry.MeetingID,
+				MeetingUUID: entry.MeetingUUID,
+				Topic:       entry.Topic,
+				PostID:      entry.PostID,
+				ChannelID:   entry.ChannelID,
+				Permalink:   p.permalink(entry.PostID),
+				Speaker:     segment.Speaker,
+				Start:       segment.Start,
+				Timestamp:   zoom.FormatTranscriptTime(time.Duration(segment.Start) * time.Millisecond),
+				Snippet:     snippet,
+			})
+			if len(results) == transcriptSearchMaxResults {
+				return results, nil
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// listChannelTranscripts returns the indexed transcripts of the meetings of a channel.
+func (p *Plugin) listChannelTranscripts(channelID string) ([]*transcriptIndexEntry, error) {
+	if err := p.completeTranscriptChannelIndexes(); err != nil {
+		return nil, errors.Wrap(err, "could not index the transcripts by channel")
+	}
+
+	index, _, err := p.getTranscriptChannelIndex(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*transcriptIndexEntry, 0, len(index))
+	for postID := range index {
+		entry, err := p.getTranscriptIndexEntry(transcriptIndexKeyPrefix + postID)
+		if err != nil {
+			p.API.LogWarn("Could not read the transcript index entry", "post_id", postID, "err", err.Error())
+			continue
+		}
+		if entry != nil && entry.ChannelID == channelID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (p *Plugin) getTranscriptIndexEntry(key string) (*transcriptIndexEntry, error) {
+	data, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var entry transcriptIndexEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// completeTranscriptChannelIndexes adds the transcripts indexed before the channel indexes
+// existed to them, listing every key of the plugin once.
+func (p *Plugin) completeTranscriptChannelIndexes() error {
+	complete, appErr := p.API.KVGet(transcriptIndexCompleteKey)
+	if appErr != nil {
+		return appErr
+	}
+	if complete != nil {
+		return nil
+	}
+
+	byChannel := map[string]transcriptChannelIndex{}
+	for page := 0; ; page++ {
+		keys, appErr := p.API.KVList(page, transcriptIndexKeysPerPage)
+		if appErr != nil {
+			return appErr
+		}
+
+		for _, key := range keys {
+			if !strings.HasPrefix(key, transcriptIndexKeyPrefix) {
+				continue
+			}
+
+			entry, err := p.getTranscriptIndexEntry(key)
+			if err != nil {
+				p.API.LogWarn("Could not read the transcript index entry", "key", key, "err", err.Error())
+				continue
+			}
+			if entry == nil {
+				continue
+			}
+			if byChannel[entry.ChannelID] == nil {
+				byChannel[entry.ChannelID] = transcriptChannelIndex{}
+			}
+			byChannel[entry.ChannelID][entry.PostID] = entry.IndexedAt
+		}
+
+		if len(keys) < transcriptIndexKeysPerPage {
+			break
+		}
+	}
+
+	for channelID, posts := range byChannel {
+		if err := p.addToTranscriptChannelIndex(channelID, posts); err != nil {
+			return err
+		}
+	}
+	if appErr := p.API.KVSet(transcriptIndexCompleteKey, []byte("1")); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// transcriptSnippet returns the text around the first of the words when the text contains all
+// of them, shortened to at most maxRunes runes. The words must be lowercase.
+func transcriptSnippet(text string, words []string, maxRunes int) (string, bool) {
+	lower := strings.ToLower(text)
+	first := -1
+	for _, word := range words {
+		i := strings.Index(lower, word)
+		if i < 0 {
+			return "", false
+		}
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+
+	// strings.ToLower maps rune for rune, so rune offsets into lower match those into text.
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text, true
+	}
+
+	start := utf8.RuneCountInString(lower[:first]) - maxRunes/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxRunes
+	if end > len(runes) {
+		end = len(runes)
+		start = end - maxRunes
+	}
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet, true
+}
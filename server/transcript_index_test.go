@@ -0,0 +1,145 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestTranscriptSnippet(t *testing.T) {
+	snippet, ok := transcriptSnippet("Let's review the Q3 Budget now.", []string{"budget", "q3"}, 100)
+	assert.True(t, ok)
+	assert.Equal(t, "Let's review the Q3 Budget now.", snippet)
+
+	_, ok = transcriptSnippet("Let's review the Q3 Budget now.", []string{"budget", "q4"}, 100)
+	assert.False(t, ok, "every word must match")
+
+	text := strings.Repeat("a ", 50) + "budget" + strings.Repeat(" b", 50)
+	snippet, ok = transcriptSnippet(text, []string{"budget"}, 40)
+	assert.True(t, ok)
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "budget")
+	assert.LessOrEqual(t, len([]rune(snippet)), 42)
+}
+
+// setupTranscriptIndexAPI returns an API with two transcripts indexed before the channel indexes
+// existed, and a third indexed since.
+func setupTranscriptIndexAPI(t *testing.T) (*plugintest.API, *memoryKVStore) {
+	entries := []transcriptIndexEntry{
+		{
+			MeetingID: 123, MeetingUUID: "uuid-1", Topic: "Planning", PostID: "post-1", ChannelID: "public-channel", IndexedAt: 1,
+			Segments: []transcriptIndexSegment{
+				{Speaker: "Alice", Start: 1500, Text: "Welcome to planning."},
+				{Speaker: "Bob", Start: 3723000, Text: "The budget is approved."},
+			},
+		},
+		{
+			MeetingID: 456, MeetingUUID: "uuid-2", Topic: "Finance", PostID: "post-2", ChannelID: "private-channel", IndexedAt: 2,
+			Segments: []transcriptIndexSegment{{Speaker: "Carol", Start: 0, Text: "The budget is secret."}},
+		},
+	}
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		kv.values[transcriptIndexKeyPrefix+entry.PostID] = data
+	}
+	api.On("KVList", 0, transcriptIndexKeysPerPage).Return([]string{"webhook_event_1", transcriptIndexKeyPrefix + "post-1", transcriptIndexKeyPrefix + "post-2"}, nil).Once()
+	api.On("HasPermissionToChannel", "user-id", "public-channel", model.PermissionReadChannel).Return(true)
+	api.On("HasPermissionToChannel", "user-id", "private-channel", model.PermissionReadChannel).Return(false)
+
+	p := Plugin{}
+	p.SetAPI(api)
+	require.NoError(t, p.indexTranscript(
+		&zoom.RecordingWebhookObject{ID: 789, UUID: "uuid-3", Topic: "Retro"},
+		&model.Post{Id: "post-3", ChannelId: "public-channel"},
+		[]zoom.TranscriptSegment{{Speaker: "Dan", Text: "The budget is spent."}},
+	))
+	return api, kv
+}
+
+func TestSearchTranscripts(t *testing.T) {
+	api, _ := setupTranscriptIndexAPI(t)
+	p := Plugin{siteURL: "https://mm.example.com"}
+	p.SetAPI(api)
+
+	results, err := p.searchTranscripts("user-id", "public-channel", "BUDGET")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Dan", results[0].Speaker, "newest meeting first")
+	assert.Equal(t, transcriptSearchResult{
+		MeetingID:   123,
+		MeetingUUID: "uuid-1",
+		Topic:       "Planning",
+		PostID:      "post-1",
+		ChannelID:   "public-channel",
+		Permalink:   "https://mm.example.com/_redirect/pl/post-1",
+		Speaker:     "Bob",
+		Start:       3723000,
+		Timestamp:   "1:02:03",
+		Snippet:     "The budget is approved.",
+	}, results[1])
+
+	results, err = p.searchTranscripts("user-id", "public-channel", "budget rejected")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = p.searchTranscripts("user-id", "private-channel", "budget")
+	assert.Equal(t, errTranscriptChannelForbidden, err)
+
+	_, err = p.searchTranscripts("user-id", "public-channel", "  ")
+	assert.Error(t, err)
+
+	// The keys of the plugin are listed once, to index the transcripts indexed before.
+	api.AssertExpectations(t)
+}
+
+func TestHandleSearchTranscripts(t *testing.T) {
+	api, _ := setupTranscriptIndexAPI(t)
+	api.On("GetLicense").Return(nil)
+
+	p := Plugin{siteURL: "https://mm.example.com"}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(&plugin.Context{}, w, httptest.NewRequest(http.MethodGet, pathSearchTranscripts+"?terms=planning", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, pathSearchTranscripts+"?terms=planning&channel_id=private-channel", nil)
+	r.Header.Set(MattermostUserIDHeader, "user-id")
+	p.ServeHTTP(&plugin.Context{}, w, r)
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, pathSearchTranscripts+"?terms=planning&channel_id=public-channel", nil)
+	r.Header.Set(MattermostUserIDHeader, "user-id")
+	p.ServeHTTP(&plugin.Context{}, w, r)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var results []transcriptSearchResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&results))
+	require.Len(t, results, 1)
+	assert.Equal(t, "Alice", results[0].Speaker)
+	assert.Equal(t, "0:01", results[0].Timestamp)
+}
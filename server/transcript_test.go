@@ -0,0 +1,116 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const testTranscript = "\ufeffWEBVTT\r\n" +
+	"\r\n" +
+	"1\r\n" +
+	"00:00:01.500 --> 00:00:04.000\r\n" +
+	"Alice Smith: Good morning everyone.\r\n" +
+	"\r\n" +
+	"2\r\n" +
+	"00:00:04.000 --> 00:00:06.250 align:start\r\n" +
+	"Alice Smith: Let's get started.\r\n" +
+	"\r\n" +
+	"NOTE this block is skipped\r\n" +
+	"\r\n" +
+	"3\r\n" +
+	"01:02:03.000 --> 01:02:05.000\r\n" +
+	"<v Bob_Jones>Sounds good,\r\n" +
+	"thanks.</v>\r\n" +
+	"\r\n" +
+	"4\r\n" +
+	"01:02:06.000 --> 01:02:07.000\r\n" +
+	"No speaker here\r\n"
+
+func TestParseWebVTT(t *testing.T) {
+	cues, err := zoom.ParseWebVTT([]byte(testTranscript))
+	require.NoError(t, err)
+	assert.Equal(t, []zoom.TranscriptCue{
+		{Start: 1500 * time.Millisecond, End: 4 * time.Second, Speaker: "Alice Smith", Text: "Good morning everyone."},
+		{Start: 4 * time.Second, End: 6250 * time.Millisecond, Speaker: "Alice Smith", Text: "Let's get started."},
+		{Start: time.Hour + 2*time.Minute + 3*time.Second, End: time.Hour + 2*time.Minute + 5*time.Second, Speaker: "Bob_Jones", Text: "Sounds good, thanks."},
+		{Start: time.Hour + 2*time.Minute + 6*time.Second, End: time.Hour + 2*time.Minute + 7*time.Second, Text: "No speaker here"},
+	}, cues)
+
+	_, err = zoom.ParseWebVTT([]byte("Alice: plain text"))
+	assert.Error(t, err)
+
+	_, err = zoom.ParseWebVTT([]byte("WEBVTT\n\n00:01.000 --> soon\nAlice: hi\n"))
+	assert.Error(t, err)
+}
+
+func TestTranscriptMarkdownParts(t *testing.T) {
+	cues, err := zoom.ParseWebVTT([]byte(testTranscript))
+	require.NoError(t, err)
+	segments := zoom.GroupTranscript(cues)
+	require.Len(t, segments, 3)
+
+	parts := transcriptMarkdownParts(segments, map[string]string{"alice smith": "@alice"}, transcriptPartMaxRunes)
+	assert.Equal(t, []string{
+		"**@alice** `0:01`\nGood morning everyone. Let's get started.\n\n" +
+			"**Bob\\_Jones** `1:02:03`\nSounds good, thanks.\n\n" +
+			"**Unknown speaker** `1:02:06`\nNo speaker here",
+	}, parts)
+}
+
+func TestTranscriptMarkdownPartsAreSplit(t *testing.T) {
+	words := strings.Repeat("word ", 100)
+	segments := []zoom.TranscriptSegment{
+		{Speaker: "Alice", Text: words},
+		{Speaker: "Bob", Text: "short"},
+		{Speaker: "Alice", Text: words},
+	}
+
+	parts := transcriptMarkdownParts(segments, nil, 120)
+	require.Greater(t, len(parts), 3)
+	for _, part := range parts {
+		assert.LessOrEqual(t, len([]rune(part)), 120)
+		assert.False(t, strings.HasSuffix(part, "wor"), "parts are split between words")
+	}
+	joined := strings.Join(parts, " ")
+	assert.Equal(t, 200, strings.Count(joined, "word"))
+	assert.Contains(t, joined, "**Bob** `0:00`\nshort")
+}
+
+func TestPostTranscriptText(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+		return post.RootId == "post-id" && post.ChannelId == "channel-id" && strings.HasPrefix(post.Message, "#### Transcript\n\n**Alice Smith** `0:01`")
+	})).Return(&model.Post{}, nil).Once()
+	api.On("KVSet", transcriptIndexKeyPrefix+"post-id", mock.MatchedBy(func(data []byte) bool {
+		var entry transcriptIndexEntry
+		return json.Unmarshal(data, &entry) == nil && entry.MeetingID == 123 && entry.ChannelID == "channel-id" && len(entry.Segments) == 3 &&
+			entry.Segments[1] == transcriptIndexSegment{Speaker: "Bob_Jones", Start: 3723000, Text: "Sounds good, thanks."}
+	})).Return(nil).Once()
+	api.On("KVGet", transcriptChannelIndexKeyPrefix+"channel-id").Return(nil, nil)
+	api.On("KVSetWithOptions", transcriptChannelIndexKeyPrefix+"channel-id", mock.MatchedBy(func(data []byte) bool {
+		var index transcriptChannelIndex
+		return json.Unmarshal(data, &index) == nil && index["post-id"] > 0
+	}), model.PluginKVSetOptions{Atomic: true}).Return(true, nil).Once()
+
+	p := Plugin{botUserID: "bot-id"}
+	p.SetAPI(api)
+
+	// Without a meeting UUID the speakers can't be matched to users.
+	p.postTranscriptText([]byte(testTranscript), &zoom.RecordingWebhookObject{ID: 123, Topic: "Standup"}, &model.Post{Id: "post-id", ChannelId: "channel-id", UserId: "user-id"})
+	api.AssertExpectations(t)
+}
@@ -26,6 +26,11 @@ import (
 
 const bearerString = "Bearer "
 const maxDownloadSize = 10 << 20 // 10MB
+const meetingSummaryTimeFormat = "Mon Jan 2 15:04:05 -0700 MST 2006"
+
+// maxScheduleVariance is how far the real start of a meeting can be from a scheduled start
+// for it to be considered the same occurrence.
+const maxScheduleVariance = 12 * time.Hour
 
 func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	if !p.verifyMattermostWebhookSecret(r) {
@@ -69,19 +74,84 @@ func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	switch webhook.Event {
+	// Zoom expects the validation response in the request itself.
+	if webhook.Event == zoom.EventTypeValidateWebhook {
+		p.handleValidateZoomWebhook(w, r, b)
+		return
+	}
+
+	handler := p.webhookHandler(webhook.Event)
+	if handler == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Zoom delivers events at least once. Repeated deliveries are acknowledged without
+	// being processed again.
+	deliveryKey := webhookDeliveryKey(b)
+	if deliveryKey != "" {
+		first, claimErr := p.claimOnce(deliveryKey, webhookDeliveryTTL)
+		if claimErr != nil {
+			p.API.LogWarn("Could not record the webhook delivery", "event", string(webhook.Event), "err", claimErr.Error())
+			deliveryKey = ""
+		} else if !first {
+			p.API.LogDebug("Ignoring a repeated webhook delivery", "event", string(webhook.Event))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	// Handlers run inline only until the queue is started in OnActivate.
+	if p.webhookQueue == nil {
+		result := &webhookResult{header: w.Header()}
+		handler(result, r, b)
+		if deliveryKey != "" && isRetryableWebhookStatus(result.status) {
+			// Let Zoom's retry of the delivery through.
+			p.releaseClaim(deliveryKey)
+		}
+		if result.status != 0 {
+			w.WriteHeader(result.status)
+		}
+		if _, err := w.Write(result.body.Bytes()); err != nil {
+			p.API.LogWarn("failed to write response", "error", err.Error())
+		}
+		return
+	}
+
+	if err := p.webhookQueue.Enqueue(webhook.Event, b); err != nil {
+		p.API.LogError("Could not queue the webhook", "event", string(webhook.Event), "err", err.Error())
+		if deliveryKey != "" {
+			p.releaseClaim(deliveryKey)
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// webhookHandler returns the handler of a webhook event, or nil for events the plugin ignores.
+func (p *Plugin) webhookHandler(event zoom.EventType) func(http.ResponseWriter, *http.Request, []byte) {
+	switch event {
 	case zoom.EventTypeMeetingStarted:
-		p.handleMeetingStarted(w, r, b)
+		return p.handleMeetingStarted
 	case zoom.EventTypeMeetingEnded:
-		p.handleMeetingEnded(w, r, b)
-	case zoom.EventTypeValidateWebhook:
-		p.handleValidateZoomWebhook(w, r, b)
+		return p.handleMeetingEnded
 	case zoom.EventTypeRecordingCompleted:
-		p.handleRecordingCompleted(w, r, b)
+		return p.handleRecordingCompleted
 	case zoom.EventTypeTranscriptCompleted:
-		p.handleTranscriptCompleted(w, r, b)
+		return p.handleTranscriptCompleted
+	case zoom.EventTypeParticipantJoined, zoom.EventTypeParticipantLeft:
+		return p.handleParticipantEvent
+	case zoom.EventTypeWebinarStarted:
+		return p.handleWebinarStarted
+	case zoom.EventTypeWebinarEnded:
+		return p.handleWebinarEnded
+	case zoom.EventTypeWebinarRegistrationCreated:
+		return p.handleWebinarRegistrationCreated
+	case zoom.EventTypeMeetingRegistrationCreated:
+		return p.handleMeetingRegistrationCreated
 	default:
-		w.WriteHeader(http.StatusOK)
+		return nil
 	}
 }
 
@@ -114,15 +184,17 @@ func (p *Plugin) handleMeetingStarted(w http.ResponseWriter, _ *http.Request, bo
 		return
 	}
 
+	if entry.IsSubscription {
+		p.postMeetingToSubscribers(w, entry, meetingID, webhook.Payload.Object)
+		return
+	}
+
 	channelID := entry.ChannelID
 
-	if entry.IsSubscription && entry.CreatedBy != "" {
-		if !p.API.HasPermissionToChannel(entry.CreatedBy, channelID, model.PermissionCreatePost) {
-			p.API.LogWarn("handleMeetingStarted: subscription creator lost channel access, skipping post",
-				"meeting_id", meetingID,
-				"channel_id", channelID,
-				"created_by", entry.CreatedBy,
-			)
+	// Meetings scheduled via /zoom schedule already have a card waiting in the
+	// channel. Flip it to started rather than posting a second card.
+	if entry.IsScheduled && entry.PostID != "" {
+		if p.startScheduledMeetingPost(entry.PostID, webhook.Payload.Object.UUID) {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
@@ -131,22 +203,19 @@ func (p *Plugin) handleMeetingStarted(w http.ResponseWriter, _ *http.Request, bo
 	// For ad-hoc meetings (started via /zoom start), a post already exists.
 	// Don't create a duplicate — just update the stored UUID mapping so that
 	// meeting.ended can find the post later.
-	// Subscription meetings should always create a new post.
-	if !entry.IsSubscription {
-		if existingPostID, err := p.findMeetingPostByMeetingID(meetingID); err == nil {
-			if webhook.Payload.Object.UUID == "" {
-				p.API.LogWarn("handleMeetingStarted: skipping UUID mapping — webhook UUID is empty",
-					"meeting_id", meetingID,
-					"post_id", existingPostID,
-				)
-			} else if appErr := p.storeMeetingPostID(webhook.Payload.Object.UUID, existingPostID); appErr != nil {
-				p.API.LogWarn("failed to store UUID mapping for existing post",
-					"error", appErr.Error(),
-				)
-			}
-			w.WriteHeader(http.StatusOK)
-			return
+	if existingPostID, err := p.findMeetingPostByMeetingID(meetingID); err == nil {
+		if webhook.Payload.Object.UUID == "" {
+			p.API.LogWarn("handleMeetingStarted: skipping UUID mapping — webhook UUID is empty",
+				"meeting_id", meetingID,
+				"post_id", existingPostID,
+			)
+		} else if appErr := p.storeMeetingPostID(webhook.Payload.Object.UUID, existingPostID); appErr != nil {
+			p.API.LogWarn("failed to store UUID mapping for existing post",
+				"error", appErr.Error(),
+			)
 		}
+		w.WriteHeader(http.StatusOK)
+		return
 	}
 
 	botUser, appErr := p.API.GetUser(p.botUserID)
@@ -156,13 +225,131 @@ func (p *Plugin) handleMeetingStarted(w http.ResponseWriter, _ *http.Request, bo
 		return
 	}
 
-	if postMeetingErr := p.postMeeting(botUser, meetingID, webhook.Payload.Object.UUID, channelID, "", webhook.Payload.Object.Topic, ""); postMeetingErr != nil {
+	if _, postMeetingErr := p.postMeeting(botUser, meetingID, webhook.Payload.Object.UUID, channelID, "", webhook.Payload.Object.Topic, ""); postMeetingErr != nil {
 		p.API.LogError("Failed to post the zoom message in the channel", "err", postMeetingErr.Error())
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// postMeetingToSubscribers posts a new meeting card in every channel subscribed to the meeting.
+// It only fails the webhook when no card could be posted, so Zoom's retry doesn't duplicate
+// the cards that were.
+func (p *Plugin) postMeetingToSubscribers(w http.ResponseWriter, entry *meetingChannelEntry, meetingID int, meeting zoom.MeetingWebhookObject) {
+	var channelIDs []string
+	for _, subscription := range entry.Subscriptions {
+		if p.subscriberCanPost(meetingID, subscription) {
+			channelIDs = append(channelIDs, subscription.ChannelID)
+		}
+	}
+	if len(channelIDs) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	botUser, appErr := p.API.GetUser(p.botUserID)
+	if appErr != nil {
+		p.API.LogError("Failed to get bot user", "err", appErr.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	props := p.subscriptionScheduleProps(entry, meetingID, meeting)
+
+	posted, failed := 0, 0
+	for _, channelID := range channelIDs {
+		// A card posted by an earlier run of this event is kept as is.
+		if p.hasMeetingPostInChannel(channelID, meetingID, meeting.UUID) {
+			posted++
+			continue
+		}
+
+		if _, err := p.postMeetingWithProps(botUser, meetingID, meeting.UUID, channelID, "", meeting.Topic, "", props); err != nil {
+			p.API.LogError("Failed to post the zoom message in the channel", "channel_id", channelID, "err", err.Error())
+			failed++
+			continue
+		}
+		posted++
+	}
+
+	if failed > 0 && posted == 0 {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// subscriptionScheduleProps records the scheduled start of the meeting occurrence next to its
+// real start, so the summary of a subscription post can show lateness and overrun.
+func (p *Plugin) subscriptionScheduleProps(entry *meetingChannelEntry, meetingID int, meeting zoom.MeetingWebhookObject) model.StringInterface {
+	start := time.Now()
+	props := model.StringInterface{}
+	if !meeting.StartTime.IsZero() {
+		start = meeting.StartTime
+		props["meeting_actual_start_time"] = start.UnixMilli()
+	}
+
+	userIDs := make([]string, 0, len(entry.Subscriptions))
+	for _, subscription := range entry.Subscriptions {
+		userIDs = append(userIDs, subscription.CreatedBy)
+	}
+	client, err := p.getFirstActiveClient(userIDs)
+	if err != nil {
+		p.API.LogDebug("Could not get a Zoom client for the meeting schedule", "meeting_id", meetingID, "err", err.Error())
+		return props
+	}
+
+	scheduled, err := client.GetMeeting(meetingID)
+	if err != nil {
+		p.API.LogDebug("Could not fetch the meeting schedule", "meeting_id", meetingID, "err", err.Error())
+		return props
+	}
+	if scheduled.Type != zoom.MeetingTypeScheduled && scheduled.Type != zoom.MeetingTypeRecurringWithFixedTime {
+		return props
+	}
+
+	if scheduledStart, ok := closestStartTime(meetingStartTimes(scheduled), start); ok {
+		props["meeting_start_time"] = scheduledStart.UnixMilli()
+		props["meeting_duration"] = scheduled.Duration
+		props["meeting_timezone"] = scheduled.Timezone
+	}
+	return props
+}
+
+// closestStartTime returns the start time nearest to the real start of a meeting, if any is
+// close enough to be the same occurrence.
+func closestStartTime(starts []int64, start time.Time) (time.Time, bool) {
+	var (
+		closest time.Time
+		found   bool
+	)
+	for _, s := range starts {
+		candidate := time.Unix(s, 0)
+		if start.Sub(candidate).Abs() > maxScheduleVariance {
+			continue
+		}
+		if !found || start.Sub(candidate).Abs() < start.Sub(closest).Abs() {
+			closest, found = candidate, true
+		}
+	}
+	return closest, found
+}
+
+// subscriberCanPost checks that the creator of a subscription can still post in its channel.
+func (p *Plugin) subscriberCanPost(meetingID int, subscription meetingSubscription) bool {
+	if subscription.CreatedBy == "" || p.API.HasPermissionToChannel(subscription.CreatedBy, subscription.ChannelID, model.PermissionCreatePost) {
+		return true
+	}
+
+	p.API.LogWarn("subscription creator lost channel access, skipping post",
+		"meeting_id", meetingID,
+		"channel_id", subscription.ChannelID,
+		"created_by", subscription.CreatedBy,
+	)
+	return false
+}
+
 func (p *Plugin) handleMeetingEnded(w http.ResponseWriter, _ *http.Request, body []byte) {
 	var webhook zoom.MeetingWebhook
 	if err := json.Unmarshal(body, &webhook); err != nil {
@@ -171,47 +358,86 @@ func (p *Plugin) handleMeetingEnded(w http.ResponseWriter, _ *http.Request, body
 		return
 	}
 
-	webhookMeetingID := webhook.Payload.Object.ID
-	webhookUUID := webhook.Payload.Object.UUID
+	// An unparsable meeting ID leaves only the UUID to find the post by.
+	meetingID, _ := strconv.Atoi(webhook.Payload.Object.ID)
 
-	postID, err := p.fetchMeetingPostID(webhookUUID)
+	posts, err := p.resolveMeetingPosts(webhook.Payload.Object.UUID, meetingID, true)
 	if err != nil {
-		// The UUID Zoom sends at meeting.ended can differ from the one at creation
-		// (e.g. PMI meetings, or recurring meetings get a new UUID per occurrence).
-		// Fall back to finding the post via the meeting_channel mapping and recent posts.
-		meetingIDInt, atoiErr := strconv.Atoi(webhookMeetingID)
-		if atoiErr != nil {
-			http.Error(w, "meeting post not found", http.StatusNotFound)
-			return
+		p.API.LogWarn("could not find meeting post",
+			"meeting_id", webhook.Payload.Object.ID,
+			"error", err.Error(),
+		)
+		http.Error(w, "meeting post not found", http.StatusNotFound)
+		return
+	}
+
+	var ended []*model.Post
+	for _, post := range posts {
+		if post.Props["meeting_status"] == zoom.WebhookStatusEnded {
+			continue
 		}
 
-		postID, err = p.findMeetingPostByMeetingID(meetingIDInt)
-		if err != nil {
-			p.API.LogWarn("could not find meeting post",
-				"meeting_id", meetingIDInt,
-				"error", err.Error(),
-			)
-			http.Error(w, "meeting post not found", http.StatusNotFound)
+		if err := p.endMeetingPost(post, webhook.Payload.Object); err != nil {
+			p.client.Log.Warn("Could not update the post", "post_id", post.Id, "err", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		ended = append(ended, post)
 	}
 
-	post, err := p.client.Post.GetPost(postID)
-	if err != nil {
-		p.client.Log.Warn("Could not get meeting post by id", "post_id", postID, "err", err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if len(ended) == 0 {
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	if post.Props["meeting_status"] == zoom.WebhookStatusEnded {
-		w.WriteHeader(http.StatusOK)
-		return
+	p.postAttendanceReports(meetingID, webhook.Payload.Object.UUID, ended)
+
+	// Only the cards of meetings started in a DM or GM carry invitations.
+	for _, post := range ended {
+		if post.Props["meeting_invitees"] != nil {
+			p.expireMeetingInvitations(post.Id)
+		}
+	}
+
+	// NOTE: We intentionally do NOT delete the meeting_channel mapping here.
+	// Recording and transcript webhooks arrive after meeting.ended and need
+	// the mapping to locate the post. The entry is small and gets overwritten
+	// if the same meeting ID is reused.
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ended[0]); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+}
+
+// endMeetingPost marks a meeting card as ended and adds the meeting summary. The summary uses
+// the start and end times reported by Zoom, as the post may have been created before the meeting
+// started and the webhook may arrive late or be retried.
+func (p *Plugin) endMeetingPost(post *model.Post, meeting zoom.MeetingWebhookObject) error {
+	start := time.UnixMilli(post.CreateAt)
+	if actualStart, ok := post.Props["meeting_actual_start_time"].(float64); ok && actualStart > 0 {
+		start = time.UnixMilli(int64(actualStart))
+	}
+	if !meeting.StartTime.IsZero() {
+		start = meeting.StartTime
+	}
+
+	end := time.Now()
+	if !meeting.EndTime.IsZero() {
+		end = meeting.EndTime
+	}
+	if end.Before(start) {
+		end = start
 	}
 
-	start := time.Unix(0, post.CreateAt*int64(time.Millisecond))
-	end := model.GetMillis()
-	length := int(math.Ceil(float64((end-post.CreateAt)/1000) / 60))
-	startText := start.Format("Mon Jan 2 15:04:05 -0700 MST 2006")
+	timezone := meeting.Timezone
+	if timezone == "" {
+		timezone = getString("meeting_timezone", post.Props)
+	}
+	location := meetingLocation(timezone)
+
+	length := int(math.Ceil(end.Sub(start).Minutes()))
+	startText := start.In(location).Format(meetingSummaryTimeFormat)
 	topic, ok := post.Props["meeting_topic"].(string)
 	if !ok {
 		topic = defaultMeetingTopic
@@ -222,37 +448,77 @@ func (p *Plugin) handleMeetingEnded(w http.ResponseWriter, _ *http.Request, body
 		meetingID = 0
 	}
 
+	noun := "Meeting"
+	if isWebinarPost(post) {
+		noun = "Webinar"
+	}
+
+	summary := fmt.Sprintf(
+		"%s ID: %d\n\n##### %s Summary\n\nDate: %s\n\n%s Length: %d minute(s)",
+		noun,
+		int(meetingID),
+		noun,
+		startText,
+		noun,
+		length,
+	)
+
+	scheduledDuration := meeting.Duration
+	if duration, ok := post.Props["meeting_duration"].(float64); ok && duration > 0 {
+		scheduledDuration = int(duration)
+	}
+	if scheduled, ok := post.Props["meeting_start_time"].(float64); ok && scheduled > 0 {
+		summary += scheduleVarianceSummary(time.UnixMilli(int64(scheduled)), scheduledDuration, start, length, location)
+	}
+
 	slackAttachment := model.SlackAttachment{
-		Fallback: fmt.Sprintf("Meeting %s has ended: started at %s, length: %d minute(s).", post.Props["meeting_id"], startText, length),
+		Fallback: fmt.Sprintf("%s %s has ended: started at %s, length: %d minute(s).", noun, post.Props["meeting_id"], startText, length),
 		Title:    topic,
-		Text: fmt.Sprintf(
-			"Meeting ID: %d\n\n##### Meeting Summary\n\nDate: %s\n\nMeeting Length: %d minute(s)",
-			int(meetingID),
-			startText,
-			length,
-		),
+		Text:     summary,
 	}
 
-	post.Message = "The meeting has ended."
+	post.Message = fmt.Sprintf("The %s has ended.", strings.ToLower(noun))
 	post.Props["meeting_status"] = zoom.WebhookStatusEnded
-	post.Props["meeting_end_time"] = end
+	post.Props["meeting_actual_start_time"] = start.UnixMilli()
+	post.Props["meeting_end_time"] = end.UnixMilli()
 	post.Props["attachments"] = []*model.SlackAttachment{&slackAttachment}
+	// Nobody is in an ended meeting, whatever participant events are still to come.
+	delete(post.Props, meetingParticipantsProp)
+	delete(post.Props, meetingParticipantCountProp)
 
-	if err = p.client.Post.UpdatePost(post); err != nil {
-		p.client.Log.Warn("Could not update the post", "post_id", postID, "err", err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	return p.client.Post.UpdatePost(post)
+}
+
+// scheduleVarianceSummary describes how late a meeting started and how far it overran its
+// scheduled duration.
+func scheduleVarianceSummary(scheduledStart time.Time, scheduledDuration int, start time.Time, length int, location *time.Location) string {
+	// A card reused for a later occurrence of a recurring meeting still carries the first
+	// occurrence's time, which says nothing about this one.
+	if start.Sub(scheduledStart).Abs() > maxScheduleVariance {
+		return ""
 	}
 
-	// NOTE: We intentionally do NOT delete the meeting_channel mapping here.
-	// Recording and transcript webhooks arrive after meeting.ended and need
-	// the mapping to locate the post. The entry is small and gets overwritten
-	// if the same meeting ID is reused.
+	summary := fmt.Sprintf("\n\nScheduled: %s", scheduledStart.In(location).Format(meetingSummaryTimeFormat))
+	if late := int(start.Sub(scheduledStart).Minutes()); late > 0 {
+		summary += fmt.Sprintf("\n\nStarted %d minute(s) late", late)
+	}
+	if overrun := length - scheduledDuration; scheduledDuration > 0 && overrun > 0 {
+		summary += fmt.Sprintf("\n\nRan %d minute(s) over the scheduled %d minute(s)", overrun, scheduledDuration)
+	}
+	return summary
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(post); err != nil {
-		p.API.LogWarn("failed to write response", "error", err.Error())
+// meetingLocation returns the location of a Zoom timezone ID, defaulting to UTC.
+func meetingLocation(timezone string) *time.Location {
+	if timezone == "" {
+		return time.UTC
 	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
 }
 
 func (p *Plugin) findMeetingPostByMeetingID(meetingID int) (string, error) {
@@ -263,20 +529,38 @@ func (p *Plugin) findMeetingPostByMeetingID(meetingID int) (string, error) {
 // associated with meetingID for a custom_zoom post matching that ID.
 // When activeOnly is true, posts already marked as ENDED are skipped.
 func (p *Plugin) findMeetingPostByMeetingIDWithFilter(meetingID int, activeOnly bool) (string, error) {
-	channelID, appErr := p.fetchChannelForMeeting(meetingID)
-	if appErr != nil || channelID == "" {
+	entry, appErr := p.getMeetingChannelEntry(meetingID)
+	if appErr != nil || entry == nil {
 		return "", errors.Errorf("no channel found for meeting %d", meetingID)
 	}
 
+	// Scheduled cards can be older than the recent-posts window below, so check
+	// the stored card first.
+	if entry.PostID != "" {
+		post, err := p.client.Post.GetPost(entry.PostID)
+		if err == nil && post.DeleteAt == 0 && !(activeOnly && post.Props["meeting_status"] == zoom.WebhookStatusEnded) {
+			return post.Id, nil
+		}
+	}
+
+	post, err := p.findMeetingPostInChannel(entry.ChannelID, meetingID, "", activeOnly)
+	if err != nil {
+		return "", err
+	}
+	return post.Id, nil
+}
+
+// findMeetingPostInChannel searches recent posts in channelID for a custom_zoom post of
+// meetingID, preferring the post of the meetingUUID occurrence and then the most recent one.
+// When activeOnly is true, posts already marked as ENDED are skipped.
+func (p *Plugin) findMeetingPostInChannel(channelID string, meetingID int, meetingUUID string, activeOnly bool) (*model.Post, error) {
 	since := model.GetMillis() - meetingPostIDTTL*1000
 	postList, appErr := p.API.GetPostsSince(channelID, since)
 	if appErr != nil {
-		return "", errors.Wrap(appErr, "could not get recent posts for channel")
+		return nil, errors.Wrap(appErr, "could not get recent posts for channel")
 	}
 
-	// Prefer the most recently created matching post.
-	var bestPostID string
-	var bestCreateAt int64
+	var best *model.Post
 	for _, post := range postList.Posts {
 		if post.Type != "custom_zoom" {
 			continue
@@ -288,28 +572,52 @@ func (p *Plugin) findMeetingPostByMeetingIDWithFilter(meetingID int, activeOnly
 		if activeOnly && post.Props["meeting_status"] == zoom.WebhookStatusEnded {
 			continue
 		}
-		if post.CreateAt > bestCreateAt {
-			bestPostID = post.Id
-			bestCreateAt = post.CreateAt
+		if meetingUUID != "" && post.Props["meeting_uuid"] == meetingUUID {
+			return post, nil
+		}
+		if best == nil || post.CreateAt > best.CreateAt {
+			best = post
 		}
 	}
 
-	if bestPostID != "" {
-		return bestPostID, nil
+	if best != nil {
+		return best, nil
+	}
+
+	return nil, errors.Errorf("no meeting post found for meeting %d in channel %s (active_only=%v)", meetingID, channelID, activeOnly)
+}
+
+// hasMeetingPostInChannel reports whether the channel already has a card for the meeting instance.
+func (p *Plugin) hasMeetingPostInChannel(channelID string, meetingID int, meetingUUID string) bool {
+	if meetingUUID == "" {
+		return false
 	}
 
-	return "", errors.Errorf("no meeting post found for meeting %d in channel %s (active_only=%v)", meetingID, channelID, activeOnly)
+	post, err := p.findMeetingPostInChannel(channelID, meetingID, meetingUUID, false)
+	return err == nil && post.Props["meeting_uuid"] == meetingUUID
 }
 
-// resolveRecordingMeetingPost finds the meeting post by UUID first, falling
-// back to a meeting-ID-based search when the UUID doesn't match (PMI /
-// recurring meetings get a new UUID per occurrence).
-func (p *Plugin) resolveRecordingMeetingPost(webhookUUID string, meetingID int) (*model.Post, error) {
+// resolveMeetingPosts finds the meeting posts a webhook applies to. A subscribed meeting has
+// a post in every subscribed channel whose subscriber can still post there. Other meetings
+// have a single post, found by UUID first and falling back to a meeting-ID-based search when
+// the UUID doesn't match (PMI / recurring meetings get a new UUID per occurrence).
+func (p *Plugin) resolveMeetingPosts(webhookUUID string, meetingID int, activeOnly bool) ([]*model.Post, error) {
+	if meetingID > 0 {
+		entry, appErr := p.getMeetingChannelEntry(meetingID)
+		if appErr != nil {
+			p.API.LogWarn("could not get the meeting channel entry", "meeting_id", meetingID, "error", appErr.Error())
+		}
+		if entry != nil && entry.IsSubscription {
+			return p.resolveSubscribedMeetingPosts(entry, webhookUUID, meetingID, activeOnly)
+		}
+	}
+
 	postID, err := p.fetchMeetingPostID(webhookUUID)
 	if err != nil {
-		// Recording/transcript webhooks arrive after meeting.ended, so the post
-		// is already marked ENDED. Use activeOnly=false to include ended posts.
-		postID, err = p.findMeetingPostByMeetingIDWithFilter(meetingID, false)
+		if meetingID <= 0 {
+			return nil, errors.Wrapf(err, "could not find meeting post for uuid=%s", webhookUUID)
+		}
+		postID, err = p.findMeetingPostByMeetingIDWithFilter(meetingID, activeOnly)
 		if err != nil {
 			return nil, errors.Wrapf(err, "could not find meeting post for uuid=%s meeting_id=%d", webhookUUID, meetingID)
 		}
@@ -320,7 +628,28 @@ func (p *Plugin) resolveRecordingMeetingPost(webhookUUID string, meetingID int)
 		return nil, errors.Wrap(getErr, "could not get meeting post by id")
 	}
 
-	return post, nil
+	return []*model.Post{post}, nil
+}
+
+func (p *Plugin) resolveSubscribedMeetingPosts(entry *meetingChannelEntry, webhookUUID string, meetingID int, activeOnly bool) ([]*model.Post, error) {
+	var posts []*model.Post
+	for _, subscription := range entry.Subscriptions {
+		if !p.subscriberCanPost(meetingID, subscription) {
+			continue
+		}
+
+		post, err := p.findMeetingPostInChannel(subscription.ChannelID, meetingID, webhookUUID, activeOnly)
+		if err != nil {
+			p.API.LogDebug("no meeting post in subscribed channel", "meeting_id", meetingID, "channel_id", subscription.ChannelID, "error", err.Error())
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	if len(posts) == 0 {
+		return nil, errors.Errorf("no meeting post found in the channels subscribed to meeting %d", meetingID)
+	}
+	return posts, nil
 }
 
 func (p *Plugin) isZoomDownloadURL(rawURL string) bool {
@@ -350,9 +679,14 @@ func (p *Plugin) isZoomDownloadURL(rawURL string) bool {
 	return false
 }
 
-// downloadZoomFile fetches a file from Zoom using the given download token,
-// retrying up to maxRetries times on failure, then uploads it to the channel.
-func (p *Plugin) downloadZoomFile(downloadURL, downloadToken, channelID, filename string, maxRetries int) (*model.FileInfo, error) {
+// errZoomDownloadUnavailable is returned when a download from Zoom failed in a way that may pass
+// on a later attempt.
+var errZoomDownloadUnavailable = errors.New("the Zoom download is unavailable")
+
+// openZoomDownload requests a file from Zoom. It doesn't wait to retry failed downloads, which
+// would hold a worker of the webhook queue: the handlers fail instead, and the queue attempts the
+// event again later. The caller must close the body of the response.
+func (p *Plugin) openZoomDownload(client *http.Client, downloadURL, downloadToken string) (*http.Response, error) {
 	if !p.isZoomDownloadURL(downloadURL) {
 		return nil, errors.Errorf("refusing to download from untrusted URL: %s", downloadURL)
 	}
@@ -363,38 +697,28 @@ func (p *Plugin) downloadZoomFile(downloadURL, downloadToken, channelID, filenam
 	}
 	request.Header.Set("Authorization", bearerString+downloadToken)
 
-	var response *http.Response
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Duration(1<<attempt) * time.Second)
-		}
-		response, err = p.downloadClient.Do(request)
-		if err != nil {
-			continue
-		}
-		if response.StatusCode != http.StatusOK {
-			response.Body.Close()
-			response = nil
-			continue
-		}
-		break
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(errZoomDownloadUnavailable, err.Error())
 	}
-
-	if response == nil {
-		if err != nil {
-			return nil, err
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		if isRetryableWebhookStatus(response.StatusCode) {
+			return nil, errors.Wrapf(errZoomDownloadUnavailable, "download failed with status %d", response.StatusCode)
 		}
-		return nil, errors.New("download failed with non-200 status")
+		return nil, errors.Errorf("download failed with status %d", response.StatusCode)
 	}
-	defer response.Body.Close()
 
-	data, err := io.ReadAll(io.LimitReader(response.Body, maxDownloadSize+1))
+	return response, nil
+}
+
+// downloadZoomFile fetches a file from Zoom using the given download token, then uploads it to
+// the channel.
+func (p *Plugin) downloadZoomFile(downloadURL, downloadToken, channelID, filename string) (*model.FileInfo, error) {
+	data, err := p.downloadZoomFileData(downloadURL, downloadToken)
 	if err != nil {
 		return nil, err
 	}
-	if int64(len(data)) > maxDownloadSize {
-		return nil, errors.Errorf("download exceeds maximum size of %d bytes", maxDownloadSize)
-	}
 
 	fileInfo, appErr := p.API.UploadFile(data, channelID, filename)
 	if appErr != nil {
@@ -404,13 +728,41 @@ func (p *Plugin) downloadZoomFile(downloadURL, downloadToken, channelID, filenam
 	return fileInfo, nil
 }
 
-func (p *Plugin) handleTranscript(recording zoom.RecordingFile, postID, channelID, downloadToken string) error {
-	fileInfo, err := p.downloadZoomFile(recording.DownloadURL, downloadToken, channelID, "transcription.txt", 5)
+// downloadZoomFileData downloads a file from Zoom into memory, up to maxDownloadSize.
+func (p *Plugin) downloadZoomFileData(downloadURL, downloadToken string) ([]byte, error) {
+	response, err := p.openZoomDownload(p.downloadClient, downloadURL, downloadToken)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxDownloadSize {
+		return nil, errors.Errorf("download exceeds maximum size of %d bytes", maxDownloadSize)
+	}
+
+	return data, nil
+}
+
+// handleTranscript replies to the meeting post with the transcript file, followed by the
+// transcript as readable thread posts when the file is WebVTT.
+func (p *Plugin) handleTranscript(webhook *zoom.RecordingWebhook, recording zoom.RecordingFile, post *model.Post) error {
+	postID, channelID := post.Id, post.ChannelId
+	data, err := p.downloadZoomFileData(recording.DownloadURL, webhook.DownloadToken)
 	if err != nil {
 		p.API.LogWarn("Unable to download transcription", "err", err.Error())
 		return err
 	}
 
+	fileInfo, appErr := p.API.UploadFile(data, channelID, "transcription.txt")
+	if appErr != nil {
+		p.API.LogWarn("Unable to upload transcription", "err", appErr.Error())
+		return appErr
+	}
+
 	newPost := &model.Post{
 		UserId:    p.botUserID,
 		ChannelId: channelID,
@@ -426,9 +778,51 @@ func (p *Plugin) handleTranscript(recording zoom.RecordingFile, postID, channelI
 		return appErr
 	}
 
+	// The file is posted already, so a transcript that can't be rendered doesn't fail the webhook.
+	p.postTranscriptText(data, &webhook.Payload.Object, post)
 	return nil
 }
 
+// postTranscript replies to the meeting post with the transcript as the channel's recording
+// policy asks, unless an earlier run of the webhook already did.
+func (p *Plugin) postTranscript(webhook *zoom.RecordingWebhook, transcript zoom.RecordingFile, post *model.Post) error {
+	policy, err := p.getChannelRecordingPolicy(post.ChannelId)
+	if err != nil {
+		return errors.Wrap(err, "could not get the channel recording policy")
+	}
+
+	key := ""
+	if transcript.ID != "" {
+		key = recordingPostedKey(post.Id, transcript.ID)
+	}
+
+	switch p.recordingActionFor(policy, transcript) {
+	case recordingActionUpload:
+		return p.doOnce(key, recordingPostedTTL, func() error {
+			return p.handleTranscript(webhook, transcript, post)
+		})
+	case recordingActionLink:
+		msg := p.recordingLinkMessage(webhook, transcript)
+		if msg == "" {
+			return nil
+		}
+		return p.doOnce(key, recordingPostedTTL, func() error {
+			_, appErr := p.API.CreatePost(&model.Post{
+				UserId:    p.botUserID,
+				ChannelId: post.ChannelId,
+				RootId:    post.Id,
+				Message:   msg,
+			})
+			if appErr != nil {
+				return appErr
+			}
+			return nil
+		})
+	default:
+		return nil
+	}
+}
+
 func (p *Plugin) handleTranscriptCompleted(w http.ResponseWriter, _ *http.Request, body []byte) {
 	var webhook zoom.RecordingWebhook
 	if err := json.Unmarshal(body, &webhook); err != nil {
@@ -437,7 +831,9 @@ func (p *Plugin) handleTranscriptCompleted(w http.ResponseWriter, _ *http.Reques
 		return
 	}
 
-	post, err := p.resolveRecordingMeetingPost(webhook.Payload.Object.UUID, webhook.Payload.Object.ID)
+	// Recording/transcript webhooks arrive after meeting.ended, so the posts
+	// are already marked ENDED. Use activeOnly=false to include ended posts.
+	posts, err := p.resolveMeetingPosts(webhook.Payload.Object.UUID, webhook.Payload.Object.ID, false)
 	if err != nil {
 		p.API.LogWarn("Could not resolve meeting post for transcript", "error", err.Error())
 		http.Error(w, "meeting post not found", http.StatusNotFound)
@@ -452,15 +848,18 @@ func (p *Plugin) handleTranscriptCompleted(w http.ResponseWriter, _ *http.Reques
 	}
 
 	if lastTranscriptionIdx != -1 {
-		err := p.handleTranscript(webhook.Payload.Object.RecordingFiles[lastTranscriptionIdx], post.Id, post.ChannelId, webhook.DownloadToken)
-		if err != nil {
-			http.Error(w, "failed to process transcript", http.StatusInternalServerError)
-			return
+		transcript := webhook.Payload.Object.RecordingFiles[lastTranscriptionIdx]
+		for _, post := range posts {
+			if err := p.postTranscript(&webhook, transcript, post); err != nil {
+				p.API.LogWarn("Could not post the transcript", "post_id", post.Id, "error", err.Error())
+				http.Error(w, "failed to process transcript", http.StatusInternalServerError)
+				return
+			}
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(post); err != nil {
+	if err := json.NewEncoder(w).Encode(posts[0]); err != nil {
 		p.API.LogWarn("failed to write response", "error", err.Error())
 	}
 }
@@ -473,7 +872,7 @@ func (p *Plugin) handleRecordingCompleted(w http.ResponseWriter, _ *http.Request
 		return
 	}
 
-	post, err := p.resolveRecordingMeetingPost(webhook.Payload.Object.UUID, webhook.Payload.Object.ID)
+	posts, err := p.resolveMeetingPosts(webhook.Payload.Object.UUID, webhook.Payload.Object.ID, false)
 	if err != nil {
 		p.API.LogWarn("handleRecordingCompleted: could not resolve meeting post", "error", err.Error())
 		http.Error(w, "meeting post not found", http.StatusNotFound)
@@ -483,63 +882,141 @@ func (p *Plugin) handleRecordingCompleted(w http.ResponseWriter, _ *http.Request
 	recordings := make(map[time.Time][]zoom.RecordingFile)
 
 	for _, recording := range webhook.Payload.Object.RecordingFiles {
-		switch {
-		case recording.RecordingType == zoom.RecordingTypeChat:
-			recordings[recording.RecordingStart] = append(recordings[recording.RecordingStart], recording)
-		case strings.EqualFold(recording.FileType, zoom.RecordingFileTypeMP4):
+		// Transcripts are posted when recording.transcript_completed arrives.
+		if kind, ok := recordingKindOf(recording); ok && kind != recordingKindTranscript {
 			recordings[recording.RecordingStart] = append(recordings[recording.RecordingStart], recording)
 		}
 	}
 
-	for _, recordingGroup := range recordings {
-		newPost := &model.Post{
-			UserId:    p.botUserID,
-			ChannelId: post.ChannelId,
-			RootId:    post.Id,
-			Message:   "",
-			FileIds:   []string{},
+	for _, post := range posts {
+		if err := p.postRecordings(&webhook, recordings, post); err != nil {
+			p.API.LogWarn("handleRecordingCompleted: failed to post recordings", "post_id", post.Id, "error", err.Error())
+			http.Error(w, "failed to process recording webhook", http.StatusInternalServerError)
+			return
 		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(posts[0]); err != nil {
+		p.API.LogWarn("failed to write response", "error", err.Error())
+	}
+}
+
+// postRecordings replies to the meeting post with the recording files allowed by the channel's
+// recording policy, one reply per recording start. Recordings already posted by an earlier run
+// of the webhook are skipped.
+func (p *Plugin) postRecordings(webhook *zoom.RecordingWebhook, recordings map[time.Time][]zoom.RecordingFile, post *model.Post) error {
+	policy, err := p.getChannelRecordingPolicy(post.ChannelId)
+	if err != nil {
+		return errors.Wrap(err, "could not get the channel recording policy")
+	}
+
+	for _, recordingGroup := range recordings {
+		var fileIDs []string
 		for _, recording := range recordingGroup {
-			if recording.RecordingType == zoom.RecordingTypeChat {
-				fileInfo, chatErr := p.downloadZoomFile(recording.DownloadURL, webhook.DownloadToken, post.ChannelId, "Chat-history.txt", 5)
-				if chatErr != nil {
-					p.API.LogWarn("handleRecordingCompleted: failed to download/upload chat", "error", chatErr.Error())
-					http.Error(w, "failed to process recording webhook", http.StatusInternalServerError)
-					return
-				}
+			if recording.ID != "" {
+				fileIDs = append(fileIDs, recording.ID)
+			}
+		}
+
+		key := ""
+		if len(fileIDs) > 0 {
+			key = recordingPostedKey(post.Id, fileIDs...)
+		}
+
+		err := p.doOnce(key, recordingPostedTTL, func() error {
+			return p.postRecordingGroup(webhook, recordingGroup, policy, post)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postRecordingGroup posts the files of a single recording start as one reply. Uploaded videos
+// and audio files are linked as well, and are only linked when they can't be uploaded.
+func (p *Plugin) postRecordingGroup(webhook *zoom.RecordingWebhook, recordingGroup []zoom.RecordingFile, policy recordingPolicy, post *model.Post) error {
+	newPost := &model.Post{
+		UserId:    p.botUserID,
+		ChannelId: post.ChannelId,
+		RootId:    post.Id,
+		Message:   "",
+		FileIds:   []string{},
+	}
+	for _, recording := range recordingGroup {
+		action := p.recordingActionFor(policy, recording)
+		if action == recordingActionIgnore {
+			continue
+		}
+
+		if action == recordingActionUpload && recording.RecordingType == zoom.RecordingTypeChat {
+			fileInfo, chatErr := p.downloadZoomFile(recording.DownloadURL, webhook.DownloadToken, post.ChannelId, "Chat-history.txt")
+			if chatErr != nil {
+				return errors.Wrap(chatErr, "failed to download/upload chat")
+			}
+
+			newPost.FileIds = append(newPost.FileIds, fileInfo.Id)
+			newPost.AddProp("captions", []any{map[string]any{"file_id": fileInfo.Id}})
+			newPost.Type = "custom_zoom_chat"
+			continue
+		}
 
+		if action == recordingActionUpload {
+			fileInfo, err := p.uploadRecordingFile(recording, webhook.DownloadToken, post.ChannelId)
+			if errors.Is(err, errZoomDownloadUnavailable) {
+				return errors.Wrap(err, "could not upload the recording")
+			}
+			if err != nil {
+				p.API.LogWarn("handleRecordingCompleted: could not upload the recording", "recording_id", recording.ID, "error", err.Error())
+			} else {
 				newPost.FileIds = append(newPost.FileIds, fileInfo.Id)
-				newPost.AddProp("captions", []any{map[string]any{"file_id": fileInfo.Id}})
-				newPost.Type = "custom_zoom_chat"
-			} else if strings.EqualFold(recording.FileType, zoom.RecordingFileTypeMP4) && recording.PlayURL != "" {
-				if !p.isZoomDownloadURL(recording.PlayURL) {
-					p.API.LogWarn("handleRecordingCompleted: refusing to post untrusted play URL", "url", recording.PlayURL)
-					continue
-				}
-				msg := "Here's the zoom meeting recording:\n**Link:** [Meeting Recording](" + recording.PlayURL + ")"
-				if webhook.Payload.Object.Password != "" && p.getConfiguration().EnablePostingRecordingPassword {
-					msg += "\n**Password:** `" + webhook.Payload.Object.Password + "`"
-				}
-				if newPost.Message != "" {
-					newPost.Message += "\n\n"
-				}
-				newPost.Message += msg
 			}
 		}
 
-		if newPost.Message != "" || len(newPost.FileIds) > 0 {
-			if _, appErr := p.API.CreatePost(newPost); appErr != nil {
-				p.API.LogWarn("handleRecordingCompleted: could not create post", "err", appErr)
-				http.Error(w, "failed to create recording post", http.StatusInternalServerError)
-				return
+		if msg := p.recordingLinkMessage(webhook, recording); msg != "" {
+			if newPost.Message != "" {
+				newPost.Message += "\n\n"
 			}
+			newPost.Message += msg
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(post); err != nil {
-		p.API.LogWarn("failed to write response", "error", err.Error())
+	if newPost.Message == "" && len(newPost.FileIds) == 0 {
+		return nil
+	}
+
+	if _, appErr := p.API.CreatePost(newPost); appErr != nil {
+		return errors.Wrap(appErr, "could not create post")
+	}
+	return nil
+}
+
+// recordingLinkMessage returns the message linking to a recording file, or "" when the file
+// can't be linked.
+func (p *Plugin) recordingLinkMessage(webhook *zoom.RecordingWebhook, recording zoom.RecordingFile) string {
+	if recording.PlayURL == "" {
+		return ""
+	}
+	if !p.isZoomDownloadURL(recording.PlayURL) {
+		p.API.LogWarn("refusing to post untrusted play URL", "url", recording.PlayURL)
+		return ""
+	}
+
+	intro, label := "Here's the zoom meeting recording:", "Meeting Recording"
+	switch kind, _ := recordingKindOf(recording); kind {
+	case recordingKindAudio:
+		intro, label = "Here's the zoom meeting audio recording:", "Audio Recording"
+	case recordingKindTranscript:
+		intro, label = "Here's the zoom meeting transcription:", "Transcript"
+	}
+
+	msg := intro + "\n**Link:** [" + label + "](" + recording.PlayURL + ")"
+	if webhook.Payload.Object.Password != "" && p.getConfiguration().EnablePostingRecordingPassword {
+		msg += "\n**Password:** `" + webhook.Payload.Object.Password + "`"
 	}
+	return msg
 }
 
 func (p *Plugin) verifyMattermostWebhookSecret(r *http.Request) bool {
@@ -0,0 +1,116 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	webhookDeliveryKeyPrefix = "webhook_seen_"
+	recordingPostedKeyPrefix = "recording_posted_"
+
+	// webhookDeliveryTTL covers Zoom's retries of a delivery, which stop after a few hours.
+	webhookDeliveryTTL = 24 * 60 * 60
+
+	// recordingPostedTTL covers the window in which recording webhooks of a meeting arrive.
+	recordingPostedTTL = 7 * 24 * 60 * 60
+)
+
+// webhookDelivery holds the fields identifying a Zoom event across its deliveries.
+type webhookDelivery struct {
+	Event     zoom.EventType `json:"event"`
+	EventTime int64          `json:"event_ts"`
+	Payload   struct {
+		Object struct {
+			UUID        string                  `json:"uuid"`
+			Participant zoom.WebhookParticipant `json:"participant"`
+			Registrant  zoom.WebhookRegistrant  `json:"registrant"`
+		} `json:"object"`
+	} `json:"payload"`
+}
+
+// webhookDeliveryKey returns the key recording that an event was received, or "" when the
+// event can't be told apart from other events of the meeting. Participant and registration
+// events also carry the participant or registrant, as several of them can share a timestamp.
+func webhookDeliveryKey(body []byte) string {
+	var delivery webhookDelivery
+	if err := json.Unmarshal(body, &delivery); err != nil {
+		return ""
+	}
+	if delivery.Event == "" || delivery.EventTime == 0 || delivery.Payload.Object.UUID == "" {
+		return ""
+	}
+
+	parts := []string{string(delivery.Event), delivery.Payload.Object.UUID, fmt.Sprint(delivery.EventTime)}
+	if delivery.Event == zoom.EventTypeParticipantJoined || delivery.Event == zoom.EventTypeParticipantLeft {
+		parts = append(parts, participantID(delivery.Payload.Object.Participant))
+	}
+	if delivery.Event == zoom.EventTypeMeetingRegistrationCreated || delivery.Event == zoom.EventTypeWebinarRegistrationCreated {
+		parts = append(parts, delivery.Payload.Object.Registrant.ID, delivery.Payload.Object.Registrant.Email)
+	}
+	return hashedKey(webhookDeliveryKeyPrefix, parts...)
+}
+
+// recordingPostedKey returns the key recording that files of a recording were posted under a meeting post.
+func recordingPostedKey(postID string, fileIDs ...string) string {
+	return hashedKey(recordingPostedKeyPrefix, append([]string{postID}, fileIDs...)...)
+}
+
+// hashedKey keeps keys built from Zoom identifiers, which can be long and contain any
+// character, within the KV key limits.
+func hashedKey(prefix string, parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return prefix + hex.EncodeToString(hash[:16])
+}
+
+// claimOnce records the key for ttl seconds. It returns false when the key was already recorded.
+func (p *Plugin) claimOnce(key string, ttl int64) (bool, error) {
+	ok, appErr := p.API.KVSetWithOptions(key, []byte("1"), model.PluginKVSetOptions{
+		Atomic:          true,
+		OldValue:        nil,
+		ExpireInSeconds: ttl,
+	})
+	if appErr != nil {
+		return false, appErr
+	}
+	return ok, nil
+}
+
+// doOnce runs fn unless it already succeeded for the key in the last ttl seconds. An empty key
+// always runs fn.
+func (p *Plugin) doOnce(key string, ttl int64, fn func() error) error {
+	if key == "" {
+		return fn()
+	}
+
+	first, err := p.claimOnce(key, ttl)
+	if err != nil {
+		return err
+	}
+	if !first {
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		p.releaseClaim(key)
+		return err
+	}
+	return nil
+}
+
+// releaseClaim forgets a key recorded by claimOnce, so the work it guards can be done again.
+func (p *Plugin) releaseClaim(key string) {
+	if appErr := p.API.KVDelete(key); appErr != nil {
+		p.API.LogWarn("Could not release the claim", "key", key, "err", appErr.Error())
+	}
+}
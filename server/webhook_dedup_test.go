@@ -0,0 +1,94 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestWebhookDeliveryKey(t *testing.T) {
+	ended := webhookDeliveryKey([]byte(`{"event":"meeting.ended","event_ts":1772632860000,"payload":{"object":{"id":"123","uuid":"abc"}}}`))
+	assert.True(t, strings.HasPrefix(ended, webhookDeliveryKeyPrefix))
+	assert.Equal(t, ended, webhookDeliveryKey([]byte(`{"payload":{"object":{"uuid":"abc","id":"123"}},"event_ts":1772632860000,"event":"meeting.ended"}`)))
+	assert.NotEqual(t, ended, webhookDeliveryKey([]byte(`{"event":"meeting.ended","event_ts":1772632860001,"payload":{"object":{"id":"123","uuid":"abc"}}}`)))
+	assert.NotEqual(t, ended, webhookDeliveryKey([]byte(`{"event":"meeting.started","event_ts":1772632860000,"payload":{"object":{"id":"123","uuid":"abc"}}}`)))
+
+	assert.Empty(t, webhookDeliveryKey([]byte(`{"event":"meeting.ended","payload":{"object":{"id":"123","uuid":"abc"}}}`)))
+	assert.Empty(t, webhookDeliveryKey([]byte(`{"event":"meeting.ended","event_ts":1772632860000,"payload":{"object":{"id":"123"}}}`)))
+
+	joined := func(participantUUID string) string {
+		return webhookDeliveryKey([]byte(`{"event":"meeting.participant_joined","event_ts":1772632860000,"payload":{"object":{"id":"123","uuid":"abc","participant":{"participant_uuid":"` + participantUUID + `"}}}}`))
+	}
+	assert.NotEqual(t, joined("p1"), joined("p2"))
+}
+
+func TestHandleWebhookIgnoresRepeatedDeliveries(t *testing.T) {
+	body := `{"event":"meeting.ended","event_ts":1772632860000,"payload":{"object":{"id":"123","uuid":"abc"}}}`
+	key := webhookDeliveryKey([]byte(body))
+
+	api := &plugintest.API{}
+	api.On("GetLicense").Return(nil)
+	api.On("KVSetWithOptions", key, []byte("1"), model.PluginKVSetOptions{Atomic: true, ExpireInSeconds: webhookDeliveryTTL}).Return(true, nil).Once()
+	api.On("KVSetWithOptions", key, []byte("1"), model.PluginKVSetOptions{Atomic: true, ExpireInSeconds: webhookDeliveryTTL}).Return(false, nil).Once()
+	allowFlexibleLogging(api)
+
+	p := Plugin{}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+	queue := &recordingWebhookQueue{}
+	p.webhookQueue = queue
+
+	for i := 0; i < 2; i++ {
+		w := sendSignedWebhook(t, &p, body)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	}
+
+	assert.Equal(t, []recordedWebhook{{event: zoom.EventTypeMeetingEnded, body: body}}, queue.queued)
+	api.AssertExpectations(t)
+}
+
+func TestHandleWebhookReleasesFailedDeliveries(t *testing.T) {
+	body := `{"event":"meeting.started","event_ts":1772632860000,"payload":{"object":{"id":"123","uuid":"abc"}}}`
+	key := webhookDeliveryKey([]byte(body))
+
+	api := &plugintest.API{}
+	api.On("GetLicense").Return(nil)
+	api.On("KVSetWithOptions", key, mock.Anything, mock.Anything).Return(true, nil).Once()
+	api.On("KVGet", "meeting_channel_123").Return(nil, &model.AppError{Message: "unavailable"})
+	api.On("KVDelete", key).Return(nil).Once()
+	allowFlexibleLogging(api)
+
+	p := Plugin{}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+
+	w := sendSignedWebhook(t, &p, body)
+	require.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	api.AssertExpectations(t)
+}
+
+func TestPostTranscriptIsPostedOnce(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("KVGet", zoomChannelSettings).Return([]byte{}, nil)
+	api.On("KVSetWithOptions", recordingPostedKey("post-id", "file-id"), mock.Anything, mock.Anything).Return(false, nil)
+
+	p := Plugin{}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+
+	recording := zoom.RecordingFile{ID: "file-id", RecordingType: zoom.RecordingTypeAudioTranscript, DownloadURL: "https://zoom.us/rec/download/abc"}
+	require.NoError(t, p.postTranscript(&zoom.RecordingWebhook{DownloadToken: "token"}, recording, &model.Post{Id: "post-id", ChannelId: "channel-id"}))
+	api.AssertNotCalled(t, "CreatePost", mock.Anything)
+}
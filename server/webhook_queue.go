@@ -0,0 +1,581 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	webhookEventKeyPrefix      = "webhook_event_"
+	webhookDeadLetterKeyPrefix = "webhook_dead_"
+	webhookEventMutexKeyPrefix = "webhook_queue_"
+	// webhookMeetingMutexKeyPrefix serializes the events of a meeting, which are processed in the
+	// order they were received.
+	webhookMeetingMutexKeyPrefix = "webhook_queue_meeting_"
+
+	// webhookIndexKey indexes the pending events with the time of their next attempt, so they are
+	// found without listing every key of the plugin.
+	webhookIndexKey        = "webhook_pending"
+	webhookIndexMaxRetries = 5
+	webhookSweepJobKey     = "webhook_sweep"
+
+	webhookWorkers       = 4
+	webhookQueueSize     = 256
+	webhookMaxAttempts   = 5
+	webhookRetryDelay    = 30 * time.Second
+	webhookSweepInterval = 30 * time.Second
+	webhookKeysPerPage   = 1000
+
+	// webhookDeadLetterTTL bounds how long failed events are kept for inspection and replay.
+	webhookDeadLetterTTL = 30 * 24 * 60 * 60
+)
+
+// webhookQueuer accepts verified webhooks for processing outside of the Zoom request.
+type webhookQueuer interface {
+	Enqueue(event zoom.EventType, body []byte) error
+	Stop()
+}
+
+// webhookEvent is a verified Zoom webhook stored until it has been processed.
+type webhookEvent struct {
+	ID    string         `json:"id"`
+	Event zoom.EventType `json:"event"`
+	// MeetingID is the meeting or webinar the event is about, if any.
+	MeetingID     string          `json:"meeting_id,omitempty"`
+	Body          json.RawMessage `json:"body"`
+	ReceivedAt    int64           `json:"received_at"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt int64           `json:"next_attempt_at"`
+	LastError     string          `json:"last_error,omitempty"`
+}
+
+// webhookIndex is the index of the pending events, mapping their IDs to their NextAttemptAt.
+type webhookIndex struct {
+	Events map[string]int64 `json:"events"`
+
+	// Meetings lists the pending events of each meeting in the order they were received.
+	Meetings map[string][]string `json:"meetings,omitempty"`
+
+	// Complete is set once the events stored before the index existed were added to it.
+	Complete bool `json:"complete"`
+}
+
+// webhookQueue keeps webhooks in the KV store and processes them with a pool of workers.
+// Pending events are swept periodically by one node of the cluster, so events left behind by a
+// restart, a full queue or a retry are picked up. A cluster mutex per meeting, or per event for
+// events about no meeting, keeps nodes from processing an event twice.
+type webhookQueue struct {
+	p *Plugin
+
+	sweepJob *cluster.Job
+
+	ids chan string
+
+	// queued holds the IDs waiting in ids or being processed, so the sweep doesn't queue them twice.
+	queuedLock sync.Mutex
+	queued     map[string]bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newWebhookQueue(p *Plugin) *webhookQueue {
+	return &webhookQueue{
+		p:      p,
+		ids:    make(chan string, webhookQueueSize),
+		queued: map[string]bool{},
+		done:   make(chan struct{}),
+	}
+}
+
+// Start runs the workers and the sweep of pending events.
+func (q *webhookQueue) Start() {
+	for i := 0; i < webhookWorkers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+
+	job, err := cluster.Schedule(q.p.API, webhookSweepJobKey, cluster.MakeWaitForInterval(webhookSweepInterval), q.sweep)
+	if err != nil {
+		q.p.API.LogError("Could not schedule the sweep of the pending webhook events", "err", err.Error())
+		return
+	}
+	q.sweepJob = job
+}
+
+// Stop waits for the events being processed. Queued events stay in the KV store.
+func (q *webhookQueue) Stop() {
+	if q.sweepJob != nil {
+		if err := q.sweepJob.Close(); err != nil {
+			q.p.API.LogWarn("Could not stop the sweep of the pending webhook events", "err", err.Error())
+		}
+	}
+	close(q.done)
+	q.wg.Wait()
+}
+
+// Enqueue stores the webhook and queues it for processing.
+func (q *webhookQueue) Enqueue(event zoom.EventType, body []byte) error {
+	webhook := &webhookEvent{
+		ID:         model.NewId(),
+		Event:      event,
+		MeetingID:  webhookMeetingID(body),
+		Body:       body,
+		ReceivedAt: model.GetMillis(),
+	}
+	if err := q.p.storePendingWebhookEvent(webhook); err != nil {
+		return err
+	}
+
+	q.push(webhook.ID)
+	return nil
+}
+
+// push queues an event without blocking. Events that don't fit are left to the sweep.
+func (q *webhookQueue) push(id string) {
+	q.queuedLock.Lock()
+	defer q.queuedLock.Unlock()
+
+	if q.queued[id] {
+		return
+	}
+
+	select {
+	case q.ids <- id:
+		q.queued[id] = true
+	default:
+	}
+}
+
+func (q *webhookQueue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.done:
+			return
+		case id := <-q.ids:
+			q.p.processWebhookEvent(id)
+
+			q.queuedLock.Lock()
+			delete(q.queued, id)
+			q.queuedLock.Unlock()
+		}
+	}
+}
+
+// sweep queues the pending events that are due.
+func (q *webhookQueue) sweep() {
+	index, err := q.p.getCompleteWebhookIndex()
+	if err != nil {
+		q.p.API.LogWarn("Could not list the pending webhook events", "err", err.Error())
+		return
+	}
+
+	now := model.GetMillis()
+	for id, nextAttemptAt := range index.Events {
+		if nextAttemptAt <= now {
+			q.push(id)
+		}
+	}
+}
+
+// processWebhookEvent processes a pending event. The events of a meeting are processed in the
+// order they were received, so the event is held back while an earlier one of its meeting is
+// waiting for a retry, and earlier ones are processed first.
+func (p *Plugin) processWebhookEvent(id string) {
+	event, err := p.getWebhookEvent(webhookEventKeyPrefix, id)
+	if err != nil {
+		p.API.LogWarn("Could not get the webhook event", "id", id, "err", err.Error())
+		return
+	}
+	if event == nil || event.MeetingID == "" {
+		p.withWebhookMutex(webhookEventMutexKeyPrefix+id, func() {
+			p.processPendingWebhookEvent(id)
+		})
+		return
+	}
+
+	p.withWebhookMutex(webhookMeetingMutexKeyPrefix+event.MeetingID, func() {
+		for {
+			index, _, err := p.getWebhookIndex()
+			if err != nil {
+				p.API.LogWarn("Could not get the index of the pending webhook events", "err", err.Error())
+				return
+			}
+			pending := index.Meetings[event.MeetingID]
+			if len(pending) == 0 {
+				p.processPendingWebhookEvent(id)
+				return
+			}
+			if !p.processPendingWebhookEvent(pending[0]) {
+				return
+			}
+		}
+	})
+}
+
+func (p *Plugin) withWebhookMutex(key string, f func()) {
+	mutex, err := cluster.NewMutex(p.API, key)
+	if err != nil {
+		p.API.LogWarn("Could not create the webhook event mutex", "key", key, "err", err.Error())
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	f()
+}
+
+// processPendingWebhookEvent runs the handler of a pending event, and reports whether the event
+// left the queue. Events failing with a server error are retried with an exponential backoff,
+// other failures go straight to the dead-letter list. Events about meetings that were never
+// posted to Mattermost are dropped.
+func (p *Plugin) processPendingWebhookEvent(id string) bool {
+	// Another node may have processed or rescheduled the event in the meantime.
+	event, err := p.getWebhookEvent(webhookEventKeyPrefix, id)
+	if err != nil {
+		p.API.LogWarn("Could not get the webhook event", "id", id, "err", err.Error())
+		return false
+	}
+	if event == nil {
+		if err := p.removeFromWebhookIndex(id); err != nil {
+			p.API.LogWarn("Could not update the index of the pending webhook events", "id", id, "err", err.Error())
+			return false
+		}
+		return true
+	}
+	if event.NextAttemptAt > model.GetMillis() {
+		return false
+	}
+
+	status, message := p.runWebhookHandler(event)
+	if status < http.StatusMultipleChoices || status == http.StatusNotFound {
+		if err := p.deletePendingWebhookEvent(id); err != nil {
+			p.API.LogWarn("Could not delete the processed webhook event", "id", id, "err", err.Error())
+			return false
+		}
+		return true
+	}
+
+	event.Attempts++
+	event.LastError = message
+	if !isRetryableWebhookStatus(status) || event.Attempts >= webhookMaxAttempts {
+		p.API.LogWarn("Webhook event failed", "id", id, "event", string(event.Event), "attempts", event.Attempts, "err", message)
+		if err := p.deadLetterWebhookEvent(event); err != nil {
+			p.API.LogError("Could not move the webhook event to the dead-letter list", "id", id, "err", err.Error())
+			return false
+		}
+		return true
+	}
+
+	event.NextAttemptAt = model.GetMillis() + (webhookRetryDelay << (event.Attempts - 1)).Milliseconds()
+	if err := p.storePendingWebhookEvent(event); err != nil {
+		p.API.LogWarn("Could not reschedule the webhook event", "id", id, "err", err.Error())
+	}
+	return false
+}
+
+// webhookMeetingID returns the ID of the meeting or webinar a webhook is about, or an empty
+// string. Zoom sends it as a number or a string depending on the event.
+func webhookMeetingID(body []byte) string {
+	var webhook struct {
+		Payload struct {
+			Object struct {
+				ID json.RawMessage `json:"id"`
+			} `json:"object"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return ""
+	}
+
+	id := strings.Trim(string(webhook.Payload.Object.ID), `"`)
+	if id == "null" {
+		return ""
+	}
+	return id
+}
+
+// runWebhookHandler runs the handler of an event and returns the status and error it responded with.
+func (p *Plugin) runWebhookHandler(event *webhookEvent) (int, string) {
+	handler := p.webhookHandler(event.Event)
+	if handler == nil {
+		return http.StatusOK, ""
+	}
+
+	result := &webhookResult{header: http.Header{}}
+	handler(result, nil, event.Body)
+	if result.status == 0 {
+		result.status = http.StatusOK
+	}
+	return result.status, strings.TrimSpace(result.body.String())
+}
+
+// isRetryableWebhookStatus reports whether a handler failed in a way that may pass on a later attempt.
+func isRetryableWebhookStatus(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+func (p *Plugin) deadLetterWebhookEvent(event *webhookEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if appErr := p.API.KVSetWithExpiry(webhookDeadLetterKeyPrefix+event.ID, data, webhookDeadLetterTTL); appErr != nil {
+		return appErr
+	}
+	return p.deletePendingWebhookEvent(event.ID)
+}
+
+// replayWebhookEvent queues a dead-lettered event again.
+func (p *Plugin) replayWebhookEvent(id string) error {
+	event, err := p.getWebhookEvent(webhookDeadLetterKeyPrefix, id)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return errors.Errorf("no failed webhook event with ID %s", id)
+	}
+	if p.webhookQueue == nil {
+		return errors.New("the webhook queue is not running")
+	}
+
+	if err := p.webhookQueue.Enqueue(event.Event, event.Body); err != nil {
+		return errors.Wrap(err, "could not queue the webhook event")
+	}
+	if appErr := p.API.KVDelete(webhookDeadLetterKeyPrefix + id); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+// storePendingWebhookEvent stores an event waiting to be processed and indexes it.
+func (p *Plugin) storePendingWebhookEvent(event *webhookEvent) error {
+	if err := p.storeWebhookEvent(webhookEventKeyPrefix, event); err != nil {
+		return err
+	}
+	return p.updateWebhookIndex(func(index *webhookIndex) bool {
+		index.Events[event.ID] = event.NextAttemptAt
+		if event.MeetingID != "" && !containsKey(index.Meetings[event.MeetingID], event.ID) {
+			if index.Meetings == nil {
+				index.Meetings = map[string][]string{}
+			}
+			index.Meetings[event.MeetingID] = append(index.Meetings[event.MeetingID], event.ID)
+		}
+		return true
+	})
+}
+
+func (p *Plugin) deletePendingWebhookEvent(id string) error {
+	if appErr := p.API.KVDelete(webhookEventKeyPrefix + id); appErr != nil {
+		return appErr
+	}
+	return p.removeFromWebhookIndex(id)
+}
+
+func (p *Plugin) storeWebhookEvent(prefix string, event *webhookEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if appErr := p.API.KVSet(prefix+event.ID, data); appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func (p *Plugin) getWebhookEvent(prefix, id string) (*webhookEvent, error) {
+	data, appErr := p.API.KVGet(prefix + id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var event webhookEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (p *Plugin) getWebhookIndex() (*webhookIndex, []byte, error) {
+	raw, appErr := p.API.KVGet(webhookIndexKey)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	index := &webhookIndex{}
+	if raw != nil {
+		if err := json.Unmarshal(raw, index); err != nil {
+			return nil, nil, errors.Wrap(err, "corrupted webhook event index")
+		}
+	}
+	if index.Events == nil {
+		index.Events = map[string]int64{}
+	}
+	return index, raw, nil
+}
+
+// updateWebhookIndex applies mutate to the index of the pending events with a compare-and-set
+// write. mutate returns false to leave the index alone.
+func (p *Plugin) updateWebhookIndex(mutate func(*webhookIndex) bool) error {
+	for i := 0; i < webhookIndexMaxRetries; i++ {
+		index, oldRaw, err := p.getWebhookIndex()
+		if err != nil {
+			return err
+		}
+		if !mutate(index) {
+			return nil
+		}
+
+		newRaw, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVSetWithOptions(webhookIndexKey, newRaw, model.PluginKVSetOptions{
+			Atomic:   true,
+			OldValue: oldRaw,
+		})
+		if appErr != nil {
+			return appErr
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("updateWebhookIndex: too many concurrent updates")
+}
+
+func (p *Plugin) removeFromWebhookIndex(id string) error {
+	return p.updateWebhookIndex(func(index *webhookIndex) bool {
+		_, changed := index.Events[id]
+		delete(index.Events, id)
+		for meetingID, ids := range index.Meetings {
+			if !containsKey(ids, id) {
+				continue
+			}
+			remaining := make([]string, 0, len(ids)-1)
+			for _, pending := range ids {
+				if pending != id {
+					remaining = append(remaining, pending)
+				}
+			}
+			if len(remaining) == 0 {
+				delete(index.Meetings, meetingID)
+			} else {
+				index.Meetings[meetingID] = remaining
+			}
+			changed = true
+		}
+		return changed
+	})
+}
+
+// getCompleteWebhookIndex returns the index of the pending events. The events stored before the
+// index existed are added to it first, listing every key of the plugin once.
+func (p *Plugin) getCompleteWebhookIndex() (*webhookIndex, error) {
+	index, _, err := p.getWebhookIndex()
+	if err != nil || index.Complete {
+		return index, err
+	}
+
+	events, err := p.listWebhookEvents(webhookEventKeyPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not index the pending webhook events")
+	}
+	err = p.updateWebhookIndex(func(index *webhookIndex) bool {
+		if index.Complete {
+			return false
+		}
+		for _, event := range events {
+			if _, ok := index.Events[event.ID]; !ok {
+				index.Events[event.ID] = event.NextAttemptAt
+			}
+		}
+		index.Complete = true
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index, _, err = p.getWebhookIndex()
+	return index, err
+}
+
+// listWebhookEvents returns the stored events with the given prefix, oldest first, listing every
+// key of the plugin.
+func (p *Plugin) listWebhookEvents(prefix string) ([]*webhookEvent, error) {
+	var events []*webhookEvent
+	for page := 0; ; page++ {
+		keys, appErr := p.API.KVList(page, webhookKeysPerPage)
+		if appErr != nil {
+			return nil, appErr
+		}
+
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			event, err := p.getWebhookEvent(prefix, strings.TrimPrefix(key, prefix))
+			if err != nil {
+				p.API.LogWarn("Could not read the webhook event", "key", key, "err", err.Error())
+				continue
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
+
+		if len(keys) < webhookKeysPerPage {
+			break
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ReceivedAt < events[j].ReceivedAt })
+	return events, nil
+}
+
+// webhookResult captures the response of a webhook handler run from the queue.
+type webhookResult struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *webhookResult) Header() http.Header {
+	return r.header
+}
+
+func (r *webhookResult) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *webhookResult) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
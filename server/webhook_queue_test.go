@@ -0,0 +1,231 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+// inlineWebhookQueue processes webhooks as they are queued, so tests can observe the outcome.
+type inlineWebhookQueue struct {
+	p *Plugin
+}
+
+func (q *inlineWebhookQueue) Enqueue(event zoom.EventType, body []byte) error {
+	status, message := q.p.runWebhookHandler(&webhookEvent{Event: event, Body: body})
+	if status >= http.StatusMultipleChoices && status != http.StatusNotFound {
+		return errors.New(message)
+	}
+	return nil
+}
+
+func (q *inlineWebhookQueue) Stop() {}
+
+type recordedWebhook struct {
+	event zoom.EventType
+	body  string
+}
+
+type recordingWebhookQueue struct {
+	queued []recordedWebhook
+}
+
+func (q *recordingWebhookQueue) Enqueue(event zoom.EventType, body []byte) error {
+	q.queued = append(q.queued, recordedWebhook{event: event, body: string(body)})
+	return nil
+}
+
+func (q *recordingWebhookQueue) Stop() {}
+
+func TestHandleWebhookQueuesEvents(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetLicense").Return(nil)
+	allowFlexibleLogging(api)
+
+	p := Plugin{}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+	queue := &recordingWebhookQueue{}
+	p.webhookQueue = queue
+
+	body := `{"event":"meeting.ended","payload":{"object":{"id":"123","uuid":"abc"}}}`
+	w := sendSignedWebhook(t, &p, body)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	w = sendSignedWebhook(t, &p, `{"event":"meeting.sharing_started","payload":{}}`)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	assert.Equal(t, []recordedWebhook{{event: zoom.EventTypeMeetingEnded, body: body}}, queue.queued)
+}
+
+func TestProcessWebhookEvent(t *testing.T) {
+	setup := func(event *webhookEvent) (*Plugin, *plugintest.API) {
+		api := &plugintest.API{}
+		api.On("KVSetWithOptions", "mutex_webhook_queue_event-id", mock.Anything, mock.Anything).Return(true, nil)
+		data, _ := json.Marshal(event)
+		api.On("KVGet", "webhook_event_event-id").Return(data, nil)
+		index, _ := json.Marshal(&webhookIndex{Events: map[string]int64{"event-id": 0}, Complete: true})
+		api.On("KVGet", webhookIndexKey).Return(index, nil)
+		allowFlexibleLogging(api)
+
+		p := &Plugin{}
+		p.setConfiguration(testConfig)
+		p.SetAPI(api)
+		return p, api
+	}
+
+	t.Run("processed events are removed", func(t *testing.T) {
+		p, api := setup(&webhookEvent{ID: "event-id", Event: "meeting.sharing_started", Body: json.RawMessage(`{}`)})
+		api.On("KVDelete", "webhook_event_event-id").Return(nil).Once()
+		api.On("KVSetWithOptions", webhookIndexKey, []byte(`{"events":{},"complete":true}`), mock.Anything).Return(true, nil).Once()
+
+		p.processWebhookEvent("event-id")
+		api.AssertExpectations(t)
+	})
+
+	t.Run("server errors are retried later", func(t *testing.T) {
+		p, api := setup(&webhookEvent{ID: "event-id", Event: zoom.EventTypeMeetingStarted, Body: json.RawMessage(`{"payload":{"object":{"id":"123"}}}`)})
+		api.On("KVGet", "meeting_channel_123").Return(nil, &model.AppError{Message: "unavailable"})
+
+		var stored webhookEvent
+		api.On("KVSet", "webhook_event_event-id", mock.Anything).Run(func(args mock.Arguments) {
+			require.NoError(t, json.Unmarshal(args.Get(1).([]byte), &stored))
+		}).Return(nil).Once()
+		var index webhookIndex
+		api.On("KVSetWithOptions", webhookIndexKey, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			require.NoError(t, json.Unmarshal(args.Get(1).([]byte), &index))
+		}).Return(true, nil).Once()
+
+		p.processWebhookEvent("event-id")
+		api.AssertExpectations(t)
+		assert.Equal(t, 1, stored.Attempts)
+		assert.Greater(t, stored.NextAttemptAt, model.GetMillis())
+		assert.Equal(t, "internal error", stored.LastError)
+		assert.Equal(t, stored.NextAttemptAt, index.Events["event-id"], "the retry time is indexed")
+	})
+
+	t.Run("events are dead-lettered after the last attempt", func(t *testing.T) {
+		p, api := setup(&webhookEvent{ID: "event-id", Event: zoom.EventTypeMeetingStarted, Body: json.RawMessage(`{"payload":{"object":{"id":"123"}}}`), Attempts: webhookMaxAttempts - 1})
+		api.On("KVGet", "meeting_channel_123").Return(nil, &model.AppError{Message: "unavailable"})
+		api.On("KVSetWithExpiry", "webhook_dead_event-id", mock.Anything, int64(webhookDeadLetterTTL)).Return(nil).Once()
+		api.On("KVDelete", "webhook_event_event-id").Return(nil).Once()
+		api.On("KVSetWithOptions", webhookIndexKey, []byte(`{"events":{},"complete":true}`), mock.Anything).Return(true, nil).Once()
+
+		p.processWebhookEvent("event-id")
+		api.AssertExpectations(t)
+	})
+
+	t.Run("malformed events are dead-lettered right away", func(t *testing.T) {
+		p, api := setup(&webhookEvent{ID: "event-id", Event: zoom.EventTypeMeetingStarted, Body: json.RawMessage(`{"payload":{"object":{"id":"not-a-number"}}}`)})
+		api.On("KVSetWithExpiry", "webhook_dead_event-id", mock.Anything, int64(webhookDeadLetterTTL)).Return(nil).Once()
+		api.On("KVDelete", "webhook_event_event-id").Return(nil).Once()
+		api.On("KVSetWithOptions", webhookIndexKey, []byte(`{"events":{},"complete":true}`), mock.Anything).Return(true, nil).Once()
+
+		p.processWebhookEvent("event-id")
+		api.AssertExpectations(t)
+	})
+
+	t.Run("events not due yet are skipped", func(t *testing.T) {
+		p, api := setup(&webhookEvent{ID: "event-id", Event: zoom.EventTypeMeetingStarted, NextAttemptAt: model.GetMillis() + 60000})
+
+		p.processWebhookEvent("event-id")
+		api.AssertNotCalled(t, "KVDelete", mock.Anything)
+		api.AssertNotCalled(t, "KVSet", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProcessWebhookEventsOfAMeetingInOrder(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	api.On("KVDelete", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		kv.set(args.String(0), nil, model.PluginKVSetOptions{})
+	}).Return(nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	body := json.RawMessage(`{"payload":{"object":{"id":123}}}`)
+	assert.Equal(t, "123", webhookMeetingID(body))
+	started := &webhookEvent{ID: "started-id", Event: "meeting.sharing_started", MeetingID: "123", Body: body, NextAttemptAt: model.GetMillis() + 60000}
+	require.NoError(t, p.storePendingWebhookEvent(started))
+	require.NoError(t, p.storePendingWebhookEvent(&webhookEvent{ID: "ended-id", Event: "meeting.sharing_ended", MeetingID: "123", Body: body}))
+	require.NoError(t, p.storePendingWebhookEvent(&webhookEvent{ID: "other-id", Event: "meeting.sharing_ended", MeetingID: "456", Body: body}))
+
+	// The event waits for the earlier event of its meeting, which is waiting for a retry.
+	p.processWebhookEvent("ended-id")
+	p.processWebhookEvent("other-id")
+	index, _, err := p.getWebhookIndex()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"123": {"started-id", "ended-id"}}, index.Meetings)
+	assert.NotNil(t, kv.get(webhookEventKeyPrefix+"ended-id"))
+
+	// Once the earlier event is due, both are processed in order.
+	started.NextAttemptAt = 0
+	require.NoError(t, p.storePendingWebhookEvent(started))
+	p.processWebhookEvent("ended-id")
+	index, _, err = p.getWebhookIndex()
+	require.NoError(t, err)
+	assert.Empty(t, index.Events)
+	assert.Empty(t, index.Meetings)
+	assert.Nil(t, kv.get(webhookEventKeyPrefix+"started-id"))
+	assert.Nil(t, kv.get(webhookEventKeyPrefix+"ended-id"))
+}
+
+func TestWebhookQueueSweep(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	kv := &memoryKVStore{values: map[string][]byte{}}
+	kv.mock(api)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	// An event stored before the index existed is found once, by listing the keys of the plugin.
+	legacy, err := json.Marshal(&webhookEvent{ID: "legacy-id", Event: zoom.EventTypeMeetingEnded})
+	require.NoError(t, err)
+	kv.values[webhookEventKeyPrefix+"legacy-id"] = legacy
+	api.On("KVList", 0, webhookKeysPerPage).Return([]string{webhookEventKeyPrefix + "legacy-id"}, nil).Once()
+
+	require.NoError(t, p.storePendingWebhookEvent(&webhookEvent{ID: "due-id", Event: zoom.EventTypeMeetingEnded}))
+	require.NoError(t, p.storePendingWebhookEvent(&webhookEvent{ID: "later-id", Event: zoom.EventTypeMeetingEnded, NextAttemptAt: model.GetMillis() + 60000}))
+
+	queue := newWebhookQueue(p)
+	queue.sweep()
+	queue.sweep()
+
+	assert.Len(t, queue.ids, 2)
+	assert.Equal(t, map[string]bool{"due-id": true, "legacy-id": true}, queue.queued)
+	api.AssertExpectations(t)
+}
+
+func TestReplayWebhookEvent(t *testing.T) {
+	api := &plugintest.API{}
+	data, _ := json.Marshal(&webhookEvent{ID: "event-id", Event: zoom.EventTypeMeetingEnded, Body: json.RawMessage(`{"event":"meeting.ended"}`), Attempts: 5})
+	api.On("KVGet", "webhook_dead_event-id").Return(data, nil)
+	api.On("KVGet", "webhook_dead_missing").Return(nil, (*model.AppError)(nil))
+	api.On("KVDelete", "webhook_dead_event-id").Return(nil).Once()
+
+	p := Plugin{}
+	p.SetAPI(api)
+	queue := &recordingWebhookQueue{}
+	p.webhookQueue = queue
+
+	require.NoError(t, p.replayWebhookEvent("event-id"))
+	assert.Equal(t, []recordedWebhook{{event: zoom.EventTypeMeetingEnded, body: `{"event":"meeting.ended"}`}}, queue.queued)
+
+	assert.Error(t, p.replayWebhookEvent("missing"))
+	api.AssertExpectations(t)
+}
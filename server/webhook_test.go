@@ -16,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -39,6 +40,24 @@ func allowFlexibleLogging(api *plugintest.API) {
 	}
 }
 
+// sendSignedWebhook delivers requestBody to the webhook endpoint with a valid Zoom signature.
+func sendSignedWebhook(t *testing.T, p *Plugin, requestBody string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	ts := fmt.Sprintf("%d", time.Now().Unix())
+	h := hmac.New(sha256.New, []byte(p.getConfiguration().ZoomWebhookSecret))
+	_, _ = h.Write([]byte("v0:" + ts + ":" + requestBody))
+
+	w := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/webhook?secret="+p.getConfiguration().WebhookSecret, bytes.NewBufferString(requestBody))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("x-zm-signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+	request.Header.Add("x-zm-request-timestamp", ts)
+
+	p.ServeHTTP(&plugin.Context{}, w, request)
+	return w
+}
+
 var testConfig = &configuration{
 	OAuthClientID:     "clientid",
 	OAuthClientSecret: "clientsecret",
@@ -454,6 +473,8 @@ func TestWebhookHandleTranscriptCompleted(t *testing.T) {
 	api.On("GetLicense").Return(nil)
 	api.On("GetPost", "post-id").Return(&model.Post{Id: "post-id", ChannelId: "channel-id"}, nil)
 	api.On("KVGet", "post_meeting_321").Return([]byte("post-id"), nil)
+	api.On("KVGet", "meeting_channel_123").Return(nil, (*model.AppError)(nil))
+	api.On("KVGet", zoomChannelSettings).Return([]byte{}, nil)
 	allowFlexibleLogging(api)
 	api.On("UploadFile", []byte("/test"), "channel-id", "transcription.txt").Return(&model.FileInfo{Id: "file-id"}, nil)
 	p.client = pluginapi.NewClient(api, nil)
@@ -535,6 +556,8 @@ func TestWebhookHandleRecordingCompleted(t *testing.T) {
 	api.On("GetLicense").Return(nil)
 	api.On("GetPost", "post-id").Return(&model.Post{Id: "post-id", ChannelId: "channel-id"}, nil)
 	api.On("KVGet", "post_meeting_321").Return([]byte("post-id"), nil)
+	api.On("KVGet", "meeting_channel_123").Return(nil, (*model.AppError)(nil))
+	api.On("KVGet", zoomChannelSettings).Return([]byte{}, nil)
 	allowFlexibleLogging(api)
 	api.On("UploadFile", []byte("/chat_file"), "channel-id", "Chat-history.txt").Return(&model.FileInfo{Id: "file-id"}, nil)
 	p.client = pluginapi.NewClient(api, nil)
@@ -617,3 +640,182 @@ func TestIsZoomDownloadURL(t *testing.T) {
 		})
 	}
 }
+
+func TestWebhookFansOutToSubscribedChannels(t *testing.T) {
+	meetingEntry, _ := json.Marshal(meetingChannelEntry{
+		ChannelID:      "channel-a",
+		IsSubscription: true,
+		CreatedBy:      "user-a",
+		Subscriptions: []meetingSubscription{
+			{ChannelID: "channel-a", CreatedBy: "user-a"},
+			{ChannelID: "channel-b", CreatedBy: "user-b"},
+			{ChannelID: "channel-c", CreatedBy: "user-c"},
+		},
+	})
+
+	setupAPI := func() *plugintest.API {
+		api := &plugintest.API{}
+		api.On("GetLicense").Return(nil)
+		api.On("KVGet", "meeting_channel_123").Return(meetingEntry, nil)
+		api.On("HasPermissionToChannel", "user-a", "channel-a", model.PermissionCreatePost).Return(true)
+		api.On("HasPermissionToChannel", "user-b", "channel-b", model.PermissionCreatePost).Return(true)
+		api.On("HasPermissionToChannel", "user-c", "channel-c", model.PermissionCreatePost).Return(false)
+		allowFlexibleLogging(api)
+		return api
+	}
+
+	t.Run("meeting.started posts in every channel the subscriber can post to", func(t *testing.T) {
+		api := setupAPI()
+		p := Plugin{botUserID: "test-bot-id"}
+		p.setConfiguration(testConfig)
+
+		api.On("GetUser", "test-bot-id").Return(&model.User{Id: "test-bot-id"}, nil)
+		api.On("KVGet", "zoomtoken_test-bot-id").Return(nil, &model.AppError{})
+		api.On("GetUser", mock.AnythingOfType("string")).Return(nil, &model.AppError{Message: "not found"})
+		api.On("GetPostsSince", mock.AnythingOfType("string"), mock.AnythingOfType("int64")).Return(model.NewPostList(), nil)
+		api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.ChannelId == "channel-a" && post.Props["meeting_actual_start_time"] == int64(1772632860000)
+		})).Return(&model.Post{Id: "post-a"}, nil).Once()
+		api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool { return post.ChannelId == "channel-b" })).Return(&model.Post{Id: "post-b"}, nil).Once()
+		api.On("KVSetWithExpiry", "post_meeting_abc", mock.AnythingOfType("[]uint8"), int64(meetingPostIDTTL)).Return(nil)
+		api.On("PublishWebSocketEvent", "meeting_started", mock.Anything, mock.AnythingOfType("*model.WebsocketBroadcast")).Return()
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"payload":{"object": {"id": "123", "uuid": "abc", "topic": "All hands", "start_time": "2026-03-04T14:01:00Z"}},"event":"meeting.started"}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("meeting.started keeps the cards posted by an earlier run", func(t *testing.T) {
+		api := setupAPI()
+		p := Plugin{botUserID: "test-bot-id"}
+		p.setConfiguration(testConfig)
+
+		posted := model.NewPostList()
+		posted.AddPost(&model.Post{
+			Id:        "post-a",
+			ChannelId: "channel-a",
+			Type:      "custom_zoom",
+			Props:     model.StringInterface{"meeting_id": float64(123), "meeting_uuid": "abc"},
+		})
+		api.On("GetUser", "test-bot-id").Return(&model.User{Id: "test-bot-id"}, nil)
+		api.On("GetUser", mock.AnythingOfType("string")).Return(nil, &model.AppError{Message: "not found"})
+		api.On("GetPostsSince", "channel-a", mock.AnythingOfType("int64")).Return(posted, nil)
+		api.On("GetPostsSince", "channel-b", mock.AnythingOfType("int64")).Return(model.NewPostList(), nil)
+		api.On("KVGet", "zoomtoken_test-bot-id").Return(nil, &model.AppError{})
+		api.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool { return post.ChannelId == "channel-b" })).Return(&model.Post{Id: "post-b"}, nil).Once()
+		api.On("KVSetWithExpiry", "post_meeting_abc", mock.AnythingOfType("[]uint8"), int64(meetingPostIDTTL)).Return(nil)
+		api.On("PublishWebSocketEvent", "meeting_started", mock.Anything, mock.AnythingOfType("*model.WebsocketBroadcast")).Return()
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"payload":{"object": {"id": "123", "uuid": "abc", "topic": "All hands"}},"event":"meeting.started"}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		api.AssertExpectations(t)
+		api.AssertNumberOfCalls(t, "CreatePost", 1)
+	})
+
+	t.Run("meeting.ended updates the post in every subscribed channel", func(t *testing.T) {
+		api := setupAPI()
+		p := Plugin{botUserID: "test-bot-id"}
+		p.setConfiguration(testConfig)
+
+		meetingPost := func(id, channelID, uuid string) *model.Post {
+			return &model.Post{
+				Id:        id,
+				ChannelId: channelID,
+				Type:      "custom_zoom",
+				Props: model.StringInterface{
+					"meeting_id":     float64(123),
+					"meeting_uuid":   uuid,
+					"meeting_status": zoom.WebhookStatusStarted,
+				},
+			}
+		}
+		api.On("GetPostsSince", "channel-a", mock.AnythingOfType("int64")).Return(&model.PostList{Posts: map[string]*model.Post{
+			"old-a":  meetingPost("old-a", "channel-a", "previous"),
+			"post-a": meetingPost("post-a", "channel-a", "abc"),
+		}}, nil)
+		api.On("GetPostsSince", "channel-b", mock.AnythingOfType("int64")).Return(&model.PostList{Posts: map[string]*model.Post{
+			"post-b": meetingPost("post-b", "channel-b", "abc"),
+		}}, nil)
+		api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool { return post.Id == "post-a" })).Return(&model.Post{}, nil).Once()
+		api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool { return post.Id == "post-b" })).Return(&model.Post{}, nil).Once()
+		p.SetAPI(api)
+		p.client = pluginapi.NewClient(api, nil)
+
+		w := sendSignedWebhook(t, &p, `{"payload":{"object": {"id": "123", "uuid": "abc"}},"event":"meeting.ended"}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		api.AssertExpectations(t)
+		api.AssertNotCalled(t, "GetPostsSince", "channel-c", mock.Anything)
+	})
+}
+
+func TestEndMeetingPostUsesZoomTimes(t *testing.T) {
+	api := &plugintest.API{}
+	p := Plugin{botUserID: "test-bot-id"}
+	p.setConfiguration(testConfig)
+
+	var updated *model.Post
+	api.On("UpdatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		updated = args.Get(0).(*model.Post).Clone()
+	}).Return(&model.Post{}, nil).Once()
+	allowFlexibleLogging(api)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+
+	scheduled := time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC)
+	post := &model.Post{
+		Id: "post-id",
+		// The card was posted when the meeting was scheduled, well before it started.
+		CreateAt: scheduled.Add(-48 * time.Hour).UnixMilli(),
+		Props: model.StringInterface{
+			"meeting_id":         float64(123),
+			"meeting_status":     zoom.WebhookStatusStarted,
+			"meeting_start_time": float64(scheduled.UnixMilli()),
+			"meeting_duration":   float64(30),
+			meetingParticipantsProp: []any{
+				map[string]any{"id": "guest-uuid", "name": "Guest", "join_time": float64(1)},
+			},
+			meetingParticipantCountProp: float64(1),
+		},
+	}
+
+	err := p.endMeetingPost(post, zoom.MeetingWebhookObject{
+		StartTime: scheduled.Add(7 * time.Minute),
+		EndTime:   scheduled.Add(52 * time.Minute),
+		Timezone:  "America/New_York",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, updated)
+
+	assert.Equal(t, scheduled.Add(7*time.Minute).UnixMilli(), updated.Props["meeting_actual_start_time"])
+	assert.Equal(t, scheduled.Add(52*time.Minute).UnixMilli(), updated.Props["meeting_end_time"])
+	assert.NotContains(t, updated.Props, meetingParticipantsProp)
+	assert.NotContains(t, updated.Props, meetingParticipantCountProp)
+
+	attachments := updated.Props["attachments"].([]*model.SlackAttachment)
+	require.Len(t, attachments, 1)
+	assert.Contains(t, attachments[0].Text, "Date: Wed Mar 4 09:07:00 -0500 EST 2026")
+	assert.Contains(t, attachments[0].Text, "Meeting Length: 45 minute(s)")
+	assert.Contains(t, attachments[0].Text, "Scheduled: Wed Mar 4 09:00:00 -0500 EST 2026")
+	assert.Contains(t, attachments[0].Text, "Started 7 minute(s) late")
+	assert.Contains(t, attachments[0].Text, "Ran 15 minute(s) over the scheduled 30 minute(s)")
+}
+
+func TestClosestStartTime(t *testing.T) {
+	start := time.Date(2026, time.March, 11, 14, 5, 0, 0, time.UTC)
+	starts := []int64{
+		time.Date(2026, time.March, 4, 14, 0, 0, 0, time.UTC).Unix(),
+		time.Date(2026, time.March, 11, 14, 0, 0, 0, time.UTC).Unix(),
+		time.Date(2026, time.March, 18, 14, 0, 0, 0, time.UTC).Unix(),
+	}
+
+	closest, ok := closestStartTime(starts, start)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2026, time.March, 11, 14, 0, 0, 0, time.UTC), closest.UTC())
+
+	_, ok = closestStartTime(starts[:1], start)
+	assert.False(t, ok)
+}
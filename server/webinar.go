@@ -0,0 +1,514 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+const (
+	defaultWebinarTopic = "Zoom Webinar"
+
+	webinarRegistrantsTimeLayout = "2006-01-02 15:04 MST"
+)
+
+var webinarApprovalTypes = map[string]zoom.WebinarApprovalType{
+	registrationAutomatic: zoom.WebinarApprovalAutomatic,
+	registrationManual:    zoom.WebinarApprovalManual,
+	registrationNone:      zoom.WebinarApprovalNoRegistration,
+}
+
+// webinarHostLinks are the links of a webinar only its host gets to see: the start link, and the
+// join link of every panelist, which the host shares with them.
+type webinarHostLinks struct {
+	StartURL  string                `json:"start_url"`
+	Panelists []webinarPanelistLink `json:"panelists"`
+}
+
+type webinarPanelistLink struct {
+	Name    string `json:"name"`
+	JoinURL string `json:"join_url"`
+}
+
+// isWebinarPost reports whether a card was posted for a webinar rather than a meeting.
+func isWebinarPost(post *model.Post) bool {
+	webinar, _ := post.Props["meeting_webinar"].(bool)
+	return webinar
+}
+
+func (p *Plugin) runWebinarCommand(args *model.CommandArgs, params []string, user *model.User) (string, error) {
+	switch {
+	case len(params) == 0 || params[0] == webinarActionCreate:
+		return p.runCreateWebinarCommand(args, user)
+	case params[0] == webinarActionRegistrants && len(params) == 2:
+		return p.runWebinarRegistrantsCommand(user, params[1])
+	default:
+		return strings.ReplaceAll(webinarHelpText, "|", "`"), nil
+	}
+}
+
+// runCreateWebinarCommand opens the dialog used to create a Zoom webinar in the current channel.
+func (p *Plugin) runCreateWebinarCommand(args *model.CommandArgs, user *model.User) (string, error) {
+	restrict, err := p.isChannelRestrictedForMeetings(args.ChannelId)
+	if err != nil {
+		p.client.Log.Error("Unable to check channel preference", "ChannelID", args.ChannelId, "Error", err.Error())
+		return "Error occurred while creating the webinar", nil
+	}
+
+	if restrict {
+		return "Creating Zoom meeting is disabled for this channel.", nil
+	}
+
+	if _, appErr := p.API.GetChannelMember(args.ChannelId, user.Id); appErr != nil {
+		return fmt.Sprintf("We could not get the channel members (channelId: %v)", args.ChannelId), nil
+	}
+
+	if _, authErr := p.authenticateAndFetchZoomUser(user); authErr != nil {
+		// the user state will be needed later while connecting the user to Zoom via OAuth
+		if appErr := p.storeOAuthUserState(user.Id, args.ChannelId, true); appErr != nil {
+			p.API.LogWarn("failed to store user state")
+		}
+		return authErr.Message, authErr.Err
+	}
+
+	state, err := json.Marshal(scheduleDialogState{RootID: args.RootId})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode dialog state")
+	}
+
+	if appErr := p.API.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: args.TriggerId,
+		URL:       fmt.Sprintf("/plugins/%s%s", url.PathEscape(manifest.Id), pathCreateWebinar),
+		Dialog:    p.getCreateWebinarDialog(user, string(state)),
+	}); appErr != nil {
+		p.client.Log.Error("Failed to open the create webinar dialog", "Error", appErr.Error())
+		return "Unable to open the dialog for creating a webinar", nil
+	}
+
+	return "", nil
+}
+
+func (p *Plugin) getCreateWebinarDialog(user *model.User, state string) model.Dialog {
+	return model.Dialog{
+		Title:       "Create a Zoom Webinar",
+		SubmitLabel: "Create",
+		State:       state,
+		Elements:    append(scheduleTimeDialogElements(user, defaultWebinarTopic), registrationDialogElement(registrationAutomatic)),
+	}
+}
+
+func (p *Plugin) handleCreateWebinar(w http.ResponseWriter, r *http.Request) {
+	submitRequest, state, ok := p.decodeScheduleDialogSubmission(w, r)
+	if !ok {
+		return
+	}
+
+	response := p.createWebinarFromDialog(submitRequest.UserId, submitRequest.ChannelId, state.RootID, submitRequest.Submission)
+	p.writeDialogResponse(w, response)
+}
+
+func (p *Plugin) createWebinarFromDialog(userID, channelID, rootID string, submission map[string]any) *model.SubmitDialogResponse {
+	if topic, _ := submission[scheduleFieldTopic].(string); strings.TrimSpace(topic) == "" {
+		submission[scheduleFieldTopic] = defaultWebinarTopic
+	}
+
+	webinar, fieldErrors := parseScheduleSubmission(submission, time.Now())
+	if len(fieldErrors) > 0 {
+		return &model.SubmitDialogResponse{Errors: fieldErrors}
+	}
+
+	restrict, err := p.isChannelRestrictedForMeetings(channelID)
+	if err != nil {
+		p.API.LogError("Unable to check channel preference", "ChannelID", channelID, "Error", err.Error())
+		return &model.SubmitDialogResponse{Error: "Error occurred while creating the webinar"}
+	}
+	if restrict {
+		return &model.SubmitDialogResponse{Error: "Creating Zoom meeting is disabled for this channel."}
+	}
+
+	if _, appErr := p.API.GetChannelMember(channelID, userID); appErr != nil {
+		return &model.SubmitDialogResponse{Error: "You are not a member of this channel."}
+	}
+
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return &model.SubmitDialogResponse{Error: "Unable to find your user account."}
+	}
+
+	zoomUser, authErr := p.authenticateAndFetchZoomUser(user)
+	if authErr != nil {
+		p.API.LogWarn("failed to authenticate the Zoom user", "error", authErr.Error())
+		return &model.SubmitDialogResponse{Error: "Unable to connect to Zoom. Run `/zoom connect` and try again."}
+	}
+
+	if err := p.createWebinar(user, zoomUser, channelID, rootID, webinar); err != nil {
+		p.API.LogWarn("failed to create the webinar", "error", err.Error())
+		return &model.SubmitDialogResponse{Error: "Zoom could not create the webinar. Webinars need a Zoom Webinar license."}
+	}
+
+	return &model.SubmitDialogResponse{}
+}
+
+// createWebinar creates the webinar in Zoom, posts its card and maps the webinar to the channel
+// so its webhooks update that card.
+func (p *Plugin) createWebinar(user *model.User, zoomUser *zoom.User, channelID, rootID string, webinar *scheduledMeeting) error {
+	client, _, err := p.getActiveClient(user)
+	if err != nil {
+		return errors.Wrap(err, "could not get the active Zoom client")
+	}
+
+	request := &zoom.CreateWebinarRequest{
+		Topic:     webinar.Topic,
+		Type:      zoom.WebinarTypeScheduled,
+		StartTime: webinar.Start.Format(zoomStartTimeLayout),
+		Duration:  webinar.Duration,
+		Timezone:  webinar.Timezone,
+		Settings: zoom.WebinarSettings{
+			HostVideo:                    true,
+			PanelistsVideo:               true,
+			ApprovalType:                 webinarApprovalTypes[webinar.Registration],
+			RegistrantsConfirmationEmail: true,
+		},
+	}
+	violations, err := p.enforceWebinarSecurityPolicy(channelID, request)
+	if err != nil {
+		return err
+	}
+
+	created, err := client.CreateWebinar(zoomUser, request)
+	if err != nil {
+		return errors.Wrap(err, "could not create the Zoom webinar")
+	}
+	p.postSecurityPolicyNote(user.Id, channelID, rootID, "webinar", violations)
+
+	post, err := p.postWebinar(user, created, webinar, channelID, rootID)
+	if err != nil {
+		return errors.Wrap(err, "could not post the webinar")
+	}
+
+	// Keep the mapping until a day after the webinar ends, leaving room for recording webhooks.
+	ttl := int64(time.Until(webinar.Start.Add(time.Duration(webinar.Duration)*time.Minute)).Seconds()) + adHocMeetingChannelTTL
+	if err := p.storeWebinarEntry(created.ID, &webinarEntry{ChannelID: channelID, PostID: post.Id, CreatedBy: user.Id}, ttl); err != nil {
+		p.API.LogWarn("failed to store channel for webinar", "webinar_id", created.ID, "error", err.Error())
+	}
+
+	return nil
+}
+
+// postWebinar posts the card of a created webinar. Attendees are linked to the registration
+// page when registration is required. The host's start and panelist links are left out of the
+// post, as every channel member can read its props, and are fetched by the host on demand.
+func (p *Plugin) postWebinar(creator *model.User, created *zoom.Webinar, webinar *scheduledMeeting, channelID, rootID string) (*model.Post, error) {
+	link := created.JoinURL
+	if created.Settings.ApprovalType != zoom.WebinarApprovalNoRegistration && created.RegistrationURL != "" {
+		link = created.RegistrationURL
+	}
+	if link == "" {
+		link = fmt.Sprintf("%s/w/%v", p.getZoomURL(), created.ID)
+	}
+
+	linkText := "Join Webinar"
+	if created.Settings.ApprovalType != zoom.WebinarApprovalNoRegistration {
+		linkText = "Register"
+	}
+
+	startText := webinar.Start.Format(scheduledPostTimeLayout)
+	slackAttachment := model.SlackAttachment{
+		Fallback: fmt.Sprintf("Webinar scheduled for %s at [%d](%s).\n\n[%s](%s)", startText, created.ID, link, linkText, link),
+		Title:    webinar.Topic,
+		Text:     fmt.Sprintf("Webinar ID: [%d](%s)\n\nStarts: %s\n\nDuration: %d minute(s)\n\n[%s](%s)", created.ID, link, startText, webinar.Duration, linkText, link),
+	}
+
+	post := &model.Post{
+		UserId:    creator.Id,
+		ChannelId: channelID,
+		RootId:    rootID,
+		Message:   "I have scheduled a webinar",
+		Type:      "custom_zoom",
+		Props: map[string]interface{}{
+			"attachments":              []*model.SlackAttachment{&slackAttachment},
+			"meeting_id":               created.ID,
+			"meeting_uuid":             created.UUID,
+			"meeting_link":             link,
+			"meeting_status":           zoom.MeetingStatusScheduled,
+			"meeting_personal":         false,
+			"meeting_topic":            webinar.Topic,
+			"meeting_creator_username": creator.Username,
+			"meeting_provider":         zoomProviderName,
+			"meeting_start_time":       webinar.Start.UnixMilli(),
+			"meeting_duration":         webinar.Duration,
+			"meeting_timezone":         webinar.Timezone,
+			"meeting_webinar":          true,
+			"webinar_registration":     created.Settings.ApprovalType != zoom.WebinarApprovalNoRegistration,
+			"webinar_registrant_count": 0,
+			"webinar_host_user_id":     creator.Id,
+		},
+	}
+
+	createdPost, appErr := p.API.CreatePost(post)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return createdPost, nil
+}
+
+// getWebinarPost returns the card of a webinar created with /zoom webinar, or nil when the
+// webinar wasn't created from Mattermost or its card is gone.
+func (p *Plugin) getWebinarPost(webinarID string) (*webinarEntry, *model.Post, error) {
+	id, err := strconv.Atoi(webinarID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid webinar ID")
+	}
+
+	entry, err := p.getWebinarEntry(id)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get the webinar entry")
+	}
+	if entry == nil || entry.PostID == "" {
+		return nil, nil, nil
+	}
+
+	post, err := p.client.Post.GetPost(entry.PostID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get the webinar post")
+	}
+	if post.DeleteAt != 0 {
+		return nil, nil, nil
+	}
+	return entry, post, nil
+}
+
+func (p *Plugin) handleWebinarStarted(w http.ResponseWriter, _ *http.Request, body []byte) {
+	var webhook zoom.MeetingWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		p.API.LogError("Error unmarshaling webinar webhook", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, post, err := p.getWebinarPost(webhook.Payload.Object.ID)
+	if err != nil {
+		p.API.LogWarn("Could not find the webinar post", "webinar_id", webhook.Payload.Object.ID, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if post == nil || post.Props["meeting_status"] != zoom.MeetingStatusScheduled {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	post.Message = "I have started a webinar"
+	post.Props["meeting_status"] = zoom.WebhookStatusStarted
+	if !webhook.Payload.Object.StartTime.IsZero() {
+		post.Props["meeting_actual_start_time"] = webhook.Payload.Object.StartTime.UnixMilli()
+	}
+	if uuid := webhook.Payload.Object.UUID; uuid != "" {
+		post.Props["meeting_uuid"] = uuid
+		// Recording webhooks find the card of the webinar by its UUID.
+		if appErr := p.storeMeetingPostID(uuid, post.Id); appErr != nil {
+			p.API.LogWarn("failed to store UUID mapping for webinar post", "error", appErr.Error())
+		}
+	}
+
+	if err := p.client.Post.UpdatePost(post); err != nil {
+		p.API.LogWarn("Could not update the webinar post", "post_id", post.Id, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (p *Plugin) handleWebinarEnded(w http.ResponseWriter, _ *http.Request, body []byte) {
+	var webhook zoom.MeetingWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		p.API.LogError("Error unmarshaling webinar webhook", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, post, err := p.getWebinarPost(webhook.Payload.Object.ID)
+	if err != nil {
+		p.API.LogWarn("Could not find the webinar post", "webinar_id", webhook.Payload.Object.ID, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if post == nil || post.Props["meeting_status"] == zoom.WebhookStatusEnded {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := p.endMeetingPost(post, webhook.Payload.Object); err != nil {
+		p.API.LogWarn("Could not update the webinar post", "post_id", post.Id, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleWebinarRegistrationCreated updates the registrant count on the card of the webinar.
+// The count is taken from Zoom when possible, so that repeated or missed events don't skew it.
+func (p *Plugin) handleWebinarRegistrationCreated(w http.ResponseWriter, _ *http.Request, body []byte) {
+	var webhook zoom.RegistrationWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		p.API.LogError("Error unmarshaling webinar registration webhook", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entry, post, err := p.getWebinarPost(webhook.Payload.Object.ID)
+	if err != nil {
+		p.API.LogWarn("Could not find the webinar post", "webinar_id", webhook.Payload.Object.ID, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if post == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	count, _ := post.Props["webinar_registrant_count"].(float64)
+	registrants, err := p.getWebinarRegistrants(entry.CreatedBy, webhook.Payload.Object.ID)
+	if err != nil {
+		p.API.LogDebug("Could not fetch the webinar registrants, counting the event instead", "webinar_id", webhook.Payload.Object.ID, "err", err.Error())
+		count++
+	} else {
+		count = float64(len(registrants))
+	}
+
+	post.Props["webinar_registrant_count"] = int(count)
+	if err := p.client.Post.UpdatePost(post); err != nil {
+		p.API.LogWarn("Could not update the webinar post", "post_id", post.Id, "err", err.Error())
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// getWebinarRegistrants fetches the registrants of a webinar with the Zoom connection of userID.
+func (p *Plugin) getWebinarRegistrants(userID, webinarID string) ([]zoom.WebinarRegistrant, error) {
+	id, err := strconv.Atoi(webinarID)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid webinar ID")
+	}
+
+	client, err := p.getFirstActiveClient([]string{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetWebinarRegistrants(id)
+}
+
+// runWebinarRegistrantsCommand lists the registrants of a webinar. Only the user who created
+// the webinar from Mattermost and system admins may see them.
+func (p *Plugin) runWebinarRegistrantsCommand(user *model.User, webinarID string) (string, error) {
+	entry, post, err := p.getWebinarPost(webinarID)
+	if err != nil {
+		return fmt.Sprintf("Unable to find the webinar %s.", webinarID), nil
+	}
+	if entry == nil || post == nil {
+		return fmt.Sprintf("Webinar %s was not created with `/zoom webinar`.", webinarID), nil
+	}
+	if entry.CreatedBy != user.Id && !p.API.HasPermissionTo(user.Id, model.PermissionManageSystem) {
+		return "Only the host of the webinar can list its registrants.", nil
+	}
+
+	registrants, err := p.getWebinarRegistrants(entry.CreatedBy, webinarID)
+	if err != nil {
+		return "Unable to fetch the webinar registrants from Zoom.", errors.Wrap(err, "cannot fetch webinar registrants")
+	}
+
+	topic := getString("meeting_topic", post.Props)
+	if len(registrants) == 0 {
+		return fmt.Sprintf("Nobody has registered for %s yet.", topic), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#### Registrants of %s\n%d registrant(s)\n\n| Name | Email | Registered |\n| :---- | :---- | :---- |", topic, len(registrants)))
+	for _, registrant := range registrants {
+		name := strings.TrimSpace(registrant.FirstName + " " + registrant.LastName)
+		registered := ""
+		if !registrant.CreateTime.IsZero() {
+			registered = registrant.CreateTime.UTC().Format(webinarRegistrantsTimeLayout)
+		}
+		sb.WriteString(fmt.Sprintf("\n|%s|%s|%s|", strings.ReplaceAll(name, "|", "\\|"), registrant.Email, registered))
+	}
+
+	return sb.String(), nil
+}
+
+// handleWebinarHostLinks returns the start and panelist links of the webinar of a card to its host.
+// Zoom's start links expire, so they are fetched anew every time.
+func (p *Plugin) handleWebinarHostLinks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get(MattermostUserIDHeader)
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	post, appErr := p.API.GetPost(r.URL.Query().Get("post_id"))
+	if appErr != nil || !isWebinarPost(post) {
+		http.Error(w, "webinar not found", http.StatusNotFound)
+		return
+	}
+	if getString("webinar_host_user_id", post.Props) != userID {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	webinarID, ok := post.Props["meeting_id"].(float64)
+	if !ok {
+		http.Error(w, "webinar not found", http.StatusNotFound)
+		return
+	}
+
+	client, err := p.getFirstActiveClient([]string{userID})
+	if err != nil {
+		http.Error(w, "Unable to connect to Zoom", http.StatusBadRequest)
+		return
+	}
+
+	webinar, err := client.GetWebinar(int(webinarID))
+	if err != nil {
+		p.API.LogWarn("Could not fetch the webinar", "webinar_id", int(webinarID), "err", err.Error())
+		http.Error(w, "could not fetch the webinar", http.StatusBadGateway)
+		return
+	}
+
+	panelists, err := client.GetWebinarPanelists(int(webinarID))
+	if err != nil {
+		p.API.LogWarn("Could not fetch the webinar panelists", "webinar_id", int(webinarID), "err", err.Error())
+		http.Error(w, "could not fetch the webinar panelists", http.StatusBadGateway)
+		return
+	}
+
+	links := webinarHostLinks{StartURL: webinar.StartURL, Panelists: []webinarPanelistLink{}}
+	for _, panelist := range panelists {
+		name := panelist.Name
+		if name == "" {
+			name = panelist.Email
+		}
+		links.Panelists = append(links.Panelists, webinarPanelistLink{Name: name, JoinURL: panelist.JoinURL})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(links); err != nil {
+		p.API.LogWarn("failed to write the response", "error", err.Error())
+	}
+}
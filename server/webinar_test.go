@@ -0,0 +1,205 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest/mock"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
+
+	"github.com/mattermost/mattermost-plugin-zoom/server/zoom"
+)
+
+func TestOAuthClientWebinars(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/users/host@example.com/webinars":
+			var request zoom.CreateWebinarRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, zoom.WebinarTypeScheduled, request.Type)
+			assert.Equal(t, zoom.WebinarApprovalManual, request.Settings.ApprovalType)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":77,"topic":"Launch","registration_url":"https://zoom.us/webinar/register/77"}`))
+		case r.URL.Path == "/webinars/77":
+			_, _ = w.Write([]byte(`{"id":77,"start_url":"https://zoom.us/s/77","join_url":"https://zoom.us/w/77"}`))
+		case r.URL.Path == "/webinars/77/panelists":
+			_, _ = w.Write([]byte(`{"total_records":1,"panelists":[{"id":"p1","name":"Speaker","email":"speaker@example.com","join_url":"https://zoom.us/w/77?tk=p1"}]}`))
+		case r.URL.Path == "/webinars/77/registrants" && r.URL.Query().Get("next_page_token") == "":
+			_, _ = w.Write([]byte(`{"next_page_token":"page2","registrants":[{"email":"a@example.com"}]}`))
+		case r.URL.Path == "/webinars/77/registrants" && r.URL.Query().Get("next_page_token") == "page2":
+			_, _ = w.Write([]byte(`{"registrants":[{"email":"b@example.com"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	token := &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}
+	client := zoom.NewOAuthClient(token, &oauth2.Config{}, "", ts.URL, false, nil)
+
+	created, err := client.CreateWebinar(&zoom.User{Email: "host@example.com"}, &zoom.CreateWebinarRequest{
+		Topic:    "Launch",
+		Type:     zoom.WebinarTypeScheduled,
+		Settings: zoom.WebinarSettings{ApprovalType: zoom.WebinarApprovalManual},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 77, created.ID)
+	assert.Equal(t, "https://zoom.us/webinar/register/77", created.RegistrationURL)
+
+	webinar, err := client.GetWebinar(77)
+	require.NoError(t, err)
+	assert.Equal(t, "https://zoom.us/s/77", webinar.StartURL)
+
+	panelists, err := client.GetWebinarPanelists(77)
+	require.NoError(t, err)
+	assert.Equal(t, []zoom.WebinarPanelist{{ID: "p1", Name: "Speaker", Email: "speaker@example.com", JoinURL: "https://zoom.us/w/77?tk=p1"}}, panelists)
+
+	registrants, err := client.GetWebinarRegistrants(77)
+	require.NoError(t, err)
+	require.Len(t, registrants, 2)
+	assert.Equal(t, "b@example.com", registrants[1].Email)
+
+	_, err = client.GetWebinar(78)
+	assert.Error(t, err)
+}
+
+func webinarTestPlugin(api *plugintest.API) *Plugin {
+	p := &Plugin{botUserID: "bot-id"}
+	p.setConfiguration(testConfig)
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+	return p
+}
+
+func webinarTestPost(status string) *model.Post {
+	return &model.Post{
+		Id:        "post-id",
+		ChannelId: "channel-id",
+		CreateAt:  time.Now().Add(-time.Hour).UnixMilli(),
+		Props: model.StringInterface{
+			"meeting_id":               float64(77),
+			"meeting_status":           status,
+			"meeting_topic":            "Launch",
+			"meeting_webinar":          true,
+			"webinar_registration":     true,
+			"webinar_registrant_count": float64(2),
+			"webinar_host_user_id":     "host-id",
+		},
+	}
+}
+
+func TestWebinarWebhooks(t *testing.T) {
+	entry, err := json.Marshal(webinarEntry{ChannelID: "channel-id", PostID: "post-id", CreatedBy: "host-id"})
+	require.NoError(t, err)
+
+	t.Run("started", func(t *testing.T) {
+		api := &plugintest.API{}
+		allowFlexibleLogging(api)
+		api.On("KVGet", "webinar_channel_77").Return(entry, nil)
+		api.On("GetPost", "post-id").Return(webinarTestPost(zoom.MeetingStatusScheduled), nil)
+		api.On("KVSetWithExpiry", meetingPostKey("uuid"), []byte("post-id"), int64(meetingPostIDTTL)).Return(nil).Once()
+		api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "I have started a webinar" && post.Props["meeting_status"] == zoom.WebhookStatusStarted && post.Props["meeting_uuid"] == "uuid"
+		})).Return(&model.Post{}, nil).Once()
+
+		w := httptest.NewRecorder()
+		webinarTestPlugin(api).handleWebinarStarted(w, nil, []byte(`{"event":"webinar.started","payload":{"object":{"id":"77","uuid":"uuid"}}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("ended", func(t *testing.T) {
+		api := &plugintest.API{}
+		allowFlexibleLogging(api)
+		api.On("KVGet", "webinar_channel_77").Return(entry, nil)
+		api.On("GetPost", "post-id").Return(webinarTestPost(zoom.WebhookStatusStarted), nil)
+		api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Message == "The webinar has ended." && post.Props["meeting_status"] == zoom.WebhookStatusEnded
+		})).Return(&model.Post{}, nil).Once()
+
+		w := httptest.NewRecorder()
+		webinarTestPlugin(api).handleWebinarEnded(w, nil, []byte(`{"event":"webinar.ended","payload":{"object":{"id":"77","uuid":"uuid"}}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("registration created without a connected host", func(t *testing.T) {
+		api := &plugintest.API{}
+		allowFlexibleLogging(api)
+		api.On("KVGet", "webinar_channel_77").Return(entry, nil)
+		api.On("GetPost", "post-id").Return(webinarTestPost(zoom.MeetingStatusScheduled), nil)
+		api.On("GetUser", "host-id").Return(nil, &model.AppError{Message: "not found"})
+		api.On("UpdatePost", mock.MatchedBy(func(post *model.Post) bool {
+			return post.Props["webinar_registrant_count"] == 3
+		})).Return(&model.Post{}, nil).Once()
+
+		w := httptest.NewRecorder()
+		webinarTestPlugin(api).handleWebinarRegistrationCreated(w, nil, []byte(`{"event":"webinar.registration_created","payload":{"object":{"id":"77","registrant":{"id":"r1","email":"a@example.com"}}}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		api.AssertExpectations(t)
+	})
+
+	t.Run("unknown webinar", func(t *testing.T) {
+		api := &plugintest.API{}
+		allowFlexibleLogging(api)
+		api.On("KVGet", "webinar_channel_78").Return(nil, nil)
+
+		w := httptest.NewRecorder()
+		webinarTestPlugin(api).handleWebinarStarted(w, nil, []byte(`{"event":"webinar.started","payload":{"object":{"id":"78","uuid":"uuid"}}}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		api.AssertNotCalled(t, "UpdatePost", mock.Anything)
+	})
+}
+
+func TestHandleWebinarHostLinks(t *testing.T) {
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("GetLicense").Return(nil)
+	api.On("GetPost", "post-id").Return(webinarTestPost(zoom.MeetingStatusScheduled), nil)
+	p := webinarTestPlugin(api)
+
+	request := httptest.NewRequest(http.MethodGet, pathWebinarHostLinks+"?post_id=post-id", nil)
+	request.Header.Set(MattermostUserIDHeader, "attendee-id")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(nil, w, request)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	request = httptest.NewRequest(http.MethodGet, pathWebinarHostLinks+"?post_id=post-id", nil)
+	w = httptest.NewRecorder()
+	p.ServeHTTP(nil, w, request)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRunWebinarRegistrantsCommand(t *testing.T) {
+	entry, err := json.Marshal(webinarEntry{ChannelID: "channel-id", PostID: "post-id", CreatedBy: "host-id"})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	allowFlexibleLogging(api)
+	api.On("KVGet", "webinar_channel_77").Return(entry, nil)
+	api.On("GetPost", "post-id").Return(webinarTestPost(zoom.MeetingStatusScheduled), nil)
+	api.On("HasPermissionTo", "attendee-id", model.PermissionManageSystem).Return(false)
+	p := webinarTestPlugin(api)
+
+	message, err := p.runWebinarCommand(&model.CommandArgs{}, []string{webinarActionRegistrants, "77"}, &model.User{Id: "attendee-id"})
+	require.NoError(t, err)
+	assert.Equal(t, "Only the host of the webinar can list its registrants.", message)
+
+	message, err = p.runWebinarCommand(&model.CommandArgs{}, []string{"unknown"}, &model.User{Id: "attendee-id"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(message, "* `/zoom webinar create`"))
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package zoom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const grantTypeAccountCredentials = "account_credentials"
+
+// AccountCredentials identify a Server-to-Server OAuth app of a Zoom account.
+type AccountCredentials struct {
+	AccountID    string
+	ClientID     string
+	ClientSecret string
+}
+
+type accountTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// FetchAccountToken mints an access token of a Server-to-Server OAuth app with an
+// account_credentials grant. The token can't be refreshed, a new one is minted when it expires.
+func FetchAccountToken(zoomURL string, credentials AccountCredentials) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	form := url.Values{}
+	form.Set("grant_type", grantTypeAccountCredentials)
+	form.Set("account_id", credentials.AccountID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/oauth/token", zoomURL), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the account token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(credentials.ClientID, credentials.ClientSecret)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch the account token")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d error returned while fetching the account token", res.StatusCode)
+	}
+
+	var body accountTokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal the account token")
+	}
+	if body.AccessToken == "" {
+		return nil, errors.New("the account token response has no access token")
+	}
+
+	return &oauth2.Token{
+		AccessToken: body.AccessToken,
+		TokenType:   body.TokenType,
+		Expiry:      time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
@@ -34,14 +34,20 @@ var errNotFound = errors.New("not found")
 // Client interface for Zoom
 type Client interface {
 	GetMeeting(meetingID int) (*Meeting, error)
+	GetPastMeetingParticipants(meetingUUID string) ([]PastMeetingParticipant, error)
 	GetUser(user *model.User, firstConnect bool) (*User, *AuthError)
-	CreateMeeting(user *User, topic string) (*Meeting, error)
+	CreateMeeting(user *User, meetingRequest *CreateMeetingRequest) (*Meeting, error)
+	AddMeetingRegistrant(meetingID int, registrant *MeetingRegistrantRequest) (*MeetingRegistration, error)
+	UpdateMeetingRegistrantStatus(meetingID int, status *RegistrantStatusRequest) error
+	GetWebinar(webinarID int) (*Webinar, error)
+	GetWebinarRegistrants(webinarID int) ([]WebinarRegistrant, error)
+	GetWebinarPanelists(webinarID int) ([]WebinarPanelist, error)
+	CreateWebinar(user *User, webinarRequest *CreateWebinarRequest) (*Webinar, error)
 	OpenDialogRequest(body *model.OpenDialogRequest) error
 }
 
+// PluginAPI gives the OAuth client the stored tokens, refreshed when they are about to expire.
 type PluginAPI interface {
-	GetZoomSuperUserToken() (*oauth2.Token, error)
-	SetZoomSuperUserToken(*oauth2.Token) error
-	GetZoomOAuthUserInfo(userID string) (*OAuthUserInfo, error)
-	UpdateZoomOAuthUserInfo(userID string, info *OAuthUserInfo) error
+	RefreshZoomSuperUserToken() (*oauth2.Token, error)
+	RefreshZoomOAuthUserToken(userID string) (*oauth2.Token, error)
 }
@@ -3,6 +3,8 @@
 
 package zoom
 
+import "time"
+
 // MeetingType as defined at https://marketplace.zoom.us/docs/api-reference/zoom-api/meetings/meetingcreate
 type MeetingType int
 
@@ -19,30 +21,58 @@ const (
 	MeetingTypeRecurringWithFixedTime MeetingType = 8
 )
 
+// RecurrenceType as defined at https://marketplace.zoom.us/docs/api-reference/zoom-api/meetings/meetingcreate
+type RecurrenceType int
+
+const (
+	// RecurrenceTypeDaily repeats the meeting every RepeatInterval days
+	RecurrenceTypeDaily RecurrenceType = 1
+	// RecurrenceTypeWeekly repeats the meeting every RepeatInterval weeks on WeeklyDays
+	RecurrenceTypeWeekly RecurrenceType = 2
+	// RecurrenceTypeMonthly repeats the meeting every RepeatInterval months on MonthlyDay
+	RecurrenceTypeMonthly RecurrenceType = 3
+)
+
+// MeetingRecurrence describes how a recurring meeting repeats.
+type MeetingRecurrence struct {
+	Type           RecurrenceType `json:"type"`
+	RepeatInterval int            `json:"repeat_interval"`
+	WeeklyDays     string         `json:"weekly_days,omitempty"`
+	MonthlyDay     int            `json:"monthly_day,omitempty"`
+	MonthlyWeekDay int            `json:"monthly_week_day,omitempty"`
+	EndTimes       int            `json:"end_times,omitempty"`
+	EndDateTime    string         `json:"end_date_time,omitempty"`
+}
+
+// TrackingField is a tracking field of a meeting, as set up in the Zoom account.
+type TrackingField struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
 // Meeting is defined at https://marketplace.zoom.us/docs/api-reference/zoom-api/meetings/meeting
 type Meeting struct {
-	UUID              string      `json:"uuid"`
-	ID                int         `json:"id"`
-	HostID            string      `json:"host_id"`
-	Topic             string      `json:"topic"`
-	Type              MeetingType `json:"type"`
-	Status            string      `json:"status"`
-	StartTime         string      `json:"start_time"`
-	Duration          int         `json:"duration"`
-	Timezone          string      `json:"timezone"`
-	CreatedAt         string      `json:"created_at"`
-	Agenda            string      `json:"agenda"`
-	JoinURL           string      `json:"join_url"`
-	StartURL          string      `json:"start_url"`
-	Password          string      `json:"password"`
-	H323Password      string      `json:"h323_password"`
-	EncryptedPassword string      `json:"encrypted_password"`
-	PMI               int         `json:"pmi"`
-	TrackingFields    []struct {
-		Field string `json:"field"`
-		Value string `json:"value"`
-	} `json:"tracking_fields"`
-	Occurrences []struct {
+	UUID              string             `json:"uuid"`
+	ID                int                `json:"id"`
+	HostID            string             `json:"host_id"`
+	Topic             string             `json:"topic"`
+	Type              MeetingType        `json:"type"`
+	Status            string             `json:"status"`
+	StartTime         string             `json:"start_time"`
+	Duration          int                `json:"duration"`
+	Timezone          string             `json:"timezone"`
+	CreatedAt         string             `json:"created_at"`
+	Agenda            string             `json:"agenda"`
+	JoinURL           string             `json:"join_url"`
+	StartURL          string             `json:"start_url"`
+	RegistrationURL   string             `json:"registration_url"`
+	Password          string             `json:"password"`
+	H323Password      string             `json:"h323_password"`
+	EncryptedPassword string             `json:"encrypted_password"`
+	PMI               int                `json:"pmi"`
+	TrackingFields    []TrackingField    `json:"tracking_fields"`
+	Recurrence        *MeetingRecurrence `json:"recurrence,omitempty"`
+	Occurrences       []struct {
 		OccurrenceID string `json:"occurrence_id"`
 		StartTime    string `json:"start_time"`
 		Duration     int    `json:"duration"`
@@ -84,28 +114,17 @@ type Meeting struct {
 
 // CreateMeetingRequest as defined at https://marketplace.zoom.us/docs/api-reference/zoom-api/meetings/meetingcreate
 type CreateMeetingRequest struct {
-	Topic          string      `json:"topic"`
-	Type           MeetingType `json:"type"`
-	StartTime      string      `json:"start_time,omitempty"`
-	Duration       int         `json:"duration,omitempty"`
-	ScheduleFor    string      `json:"schedule_for,omitempty"`
-	Timezone       string      `json:"timezone,omitempty"`
-	Password       string      `json:"password"`
-	Agenda         string      `json:"agenda"`
-	TrackingFields []struct {
-		Field string `json:"field"`
-		Value string `json:"value"`
-	} `json:"tracking_fields"`
-	Recurrence *struct {
-		Type           int    `json:"type"`
-		RepeatInterval int    `json:"repeat_interval"`
-		WeeklyDays     string `json:"weekly_days,omitempty"`
-		MonthlyDay     int    `json:"monthly_day,omitempty"`
-		MonthlyWeekDay int    `json:"monthly_week_day,omitempty"`
-		EndTimes       int    `json:"end_times,omitempty"`
-		EndDateTime    int    `json:"end_date_time,omitempty"`
-	} `json:"recurrence,omitempty"`
-	Settings struct {
+	Topic          string             `json:"topic"`
+	Type           MeetingType        `json:"type"`
+	StartTime      string             `json:"start_time,omitempty"`
+	Duration       int                `json:"duration,omitempty"`
+	ScheduleFor    string             `json:"schedule_for,omitempty"`
+	Timezone       string             `json:"timezone,omitempty"`
+	Password       string             `json:"password"`
+	Agenda         string             `json:"agenda"`
+	TrackingFields []TrackingField    `json:"tracking_fields"`
+	Recurrence     *MeetingRecurrence `json:"recurrence,omitempty"`
+	Settings       struct {
 		HostVideo             bool     `json:"host_video"`
 		ParticipantVideo      bool     `json:"participant_video"`
 		CNMeeting             bool     `json:"cn_meeting"`
@@ -138,3 +157,24 @@ type CreateMeetingRequest struct {
 		AuthenticationName           string `json:"authentication_name"`
 	} `json:"settings,omitempty"`
 }
+
+// PastMeetingParticipant is one attendance record of an ended meeting. A participant who
+// rejoins the meeting has a record for every time they joined.
+type PastMeetingParticipant struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	UserEmail string    `json:"user_email"`
+	JoinTime  time.Time `json:"join_time"`
+	LeaveTime time.Time `json:"leave_time"`
+	Duration  int       `json:"duration"`
+}
+
+// PastMeetingParticipants is defined at https://developers.zoom.us/docs/api/meetings/#tag/meetings/GET/past_meetings/{meetingId}/participants
+type PastMeetingParticipants struct {
+	PageCount     int                      `json:"page_count"`
+	PageSize      int                      `json:"page_size"`
+	TotalRecords  int                      `json:"total_records"`
+	NextPageToken string                   `json:"next_page_token"`
+	Participants  []PastMeetingParticipant `json:"participants"`
+}
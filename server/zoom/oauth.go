@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
@@ -29,10 +30,12 @@ var ErrFetchingUser = errors.New("error returned while fetching Zoom user")
 
 // OAuthUserInfo represents a Zoom user authenticated via OAuth.
 type OAuthUserInfo struct {
-	ZoomEmail  string
-	OAuthToken *oauth2.Token // Zoom OAuth Token, ttl 15 years
-	UserID     string        // Mattermost userID
-	ZoomID     string        // Zoom userID
+	ZoomEmail   string
+	OAuthToken  *oauth2.Token // Zoom OAuth Token, ttl 15 years
+	UserID      string        // Mattermost userID
+	ZoomID      string        // Zoom userID
+	BrokenAt    int64         `json:",omitempty"` // when refreshing the token failed for good, 0 while the connection works
+	RefreshedAt int64         `json:",omitempty"` // when the token was last obtained from Zoom
 }
 
 // OAuthClient represents an OAuth-based Zoom client.
@@ -104,13 +107,64 @@ func (c *OAuthClient) GetMeeting(meetingID int) (*Meeting, error) {
 	return &meeting, nil
 }
 
-// CreateMeeting creates a new meeting for the user and returns the created meeting.
-func (c *OAuthClient) CreateMeeting(user *User, topic string) (*Meeting, error) {
-	client := c.config.Client(context.Background(), c.token)
-	meetingRequest := CreateMeetingRequest{
-		Topic: topic,
-		Type:  MeetingTypeInstant,
+// GetPastMeetingParticipants returns the attendance records of an ended meeting instance via OAuth.
+func (c *OAuthClient) GetPastMeetingParticipants(meetingUUID string) ([]PastMeetingParticipant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	// Zoom requires UUIDs that begin with a slash or contain a double slash to be double encoded.
+	escapedUUID := url.PathEscape(meetingUUID)
+	if strings.HasPrefix(meetingUUID, "/") || strings.Contains(meetingUUID, "//") {
+		escapedUUID = url.PathEscape(escapedUUID)
+	}
+
+	client := c.config.Client(ctx, c.token)
+
+	var participants []PastMeetingParticipant
+	nextPageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("page_size", "300")
+		if nextPageToken != "" {
+			query.Set("next_page_token", nextPageToken)
+		}
+
+		res, err := client.Get(fmt.Sprintf("%s/past_meetings/%s/participants?%s", c.apiURL, escapedUUID, query.Encode()))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not fetch Zoom meeting participants")
+		}
+
+		page, err := decodePastMeetingParticipants(res)
+		if err != nil {
+			return nil, err
+		}
+
+		participants = append(participants, page.Participants...)
+		if page.NextPageToken == "" {
+			return participants, nil
+		}
+		nextPageToken = page.NextPageToken
 	}
+}
+
+func decodePastMeetingParticipants(res *http.Response) (*PastMeetingParticipants, error) {
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d error returned while fetching Zoom meeting participants", res.StatusCode)
+	}
+
+	var page PastMeetingParticipants
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal Zoom meeting participants")
+	}
+
+	return &page, nil
+}
+
+// CreateMeeting creates a new meeting for the user from the given request and returns the created meeting.
+func (c *OAuthClient) CreateMeeting(user *User, meetingRequest *CreateMeetingRequest) (*Meeting, error) {
+	client := c.config.Client(context.Background(), c.token)
 	b, err := json.Marshal(meetingRequest)
 	if err != nil {
 		return nil, err
@@ -136,57 +190,211 @@ func (c *OAuthClient) CreateMeeting(user *User, topic string) (*Meeting, error)
 	return &ret, err
 }
 
+// AddMeetingRegistrant registers an attendee for a meeting that requires registration via OAuth.
+func (c *OAuthClient) AddMeetingRegistrant(meetingID int, registrant *MeetingRegistrantRequest) (*MeetingRegistration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(registrant)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.config.Client(ctx, c.token)
+	res, err := client.Post(fmt.Sprintf("%s/meetings/%v/registrants", c.apiURL, meetingID), "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not add Zoom meeting registrant")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("%d error returned while adding Zoom meeting registrant", res.StatusCode)
+	}
+
+	var registration MeetingRegistration
+	if err := json.NewDecoder(res.Body).Decode(&registration); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal Zoom meeting registration")
+	}
+
+	return &registration, nil
+}
+
+// UpdateMeetingRegistrantStatus approves or denies meeting registrants via OAuth.
+func (c *OAuthClient) UpdateMeetingRegistrantStatus(meetingID int, status *RegistrantStatusRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/meetings/%v/registrants/status", c.apiURL, meetingID), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.config.Client(ctx, c.token).Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not update Zoom meeting registrant status")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("%d error returned while updating Zoom meeting registrant status", res.StatusCode)
+	}
+
+	return nil
+}
+
+// GetWebinar returns the Zoom webinar with the given ID via OAuth.
+func (c *OAuthClient) GetWebinar(webinarID int) (*Webinar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	client := c.config.Client(ctx, c.token)
+	res, err := client.Get(fmt.Sprintf("%s/webinars/%v", c.apiURL, webinarID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch Zoom webinar")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d error returned while fetching Zoom webinar", res.StatusCode)
+	}
+
+	var webinar Webinar
+	if err := json.NewDecoder(res.Body).Decode(&webinar); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal Zoom webinar data")
+	}
+
+	return &webinar, nil
+}
+
+// GetWebinarRegistrants returns the approved registrants of a webinar via OAuth.
+func (c *OAuthClient) GetWebinarRegistrants(webinarID int) ([]WebinarRegistrant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	client := c.config.Client(ctx, c.token)
+
+	var registrants []WebinarRegistrant
+	nextPageToken := ""
+	for {
+		query := url.Values{}
+		query.Set("page_size", "300")
+		if nextPageToken != "" {
+			query.Set("next_page_token", nextPageToken)
+		}
+
+		res, err := client.Get(fmt.Sprintf("%s/webinars/%v/registrants?%s", c.apiURL, webinarID, query.Encode()))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not fetch Zoom webinar registrants")
+		}
+
+		page, err := decodeWebinarRegistrants(res)
+		if err != nil {
+			return nil, err
+		}
+
+		registrants = append(registrants, page.Registrants...)
+		if page.NextPageToken == "" {
+			return registrants, nil
+		}
+		nextPageToken = page.NextPageToken
+	}
+}
+
+func decodeWebinarRegistrants(res *http.Response) (*WebinarRegistrants, error) {
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d error returned while fetching Zoom webinar registrants", res.StatusCode)
+	}
+
+	var page WebinarRegistrants
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal Zoom webinar registrants")
+	}
+
+	return &page, nil
+}
+
+// GetWebinarPanelists returns the panelists of a webinar, with their join links, via OAuth.
+func (c *OAuthClient) GetWebinarPanelists(webinarID int) ([]WebinarPanelist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	client := c.config.Client(ctx, c.token)
+	res, err := client.Get(fmt.Sprintf("%s/webinars/%v/panelists", c.apiURL, webinarID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch Zoom webinar panelists")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d error returned while fetching Zoom webinar panelists", res.StatusCode)
+	}
+
+	var panelists WebinarPanelists
+	if err := json.NewDecoder(res.Body).Decode(&panelists); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal Zoom webinar panelists")
+	}
+
+	return panelists.Panelists, nil
+}
+
+// CreateWebinar creates a new webinar for the user from the given request and returns the created webinar.
+func (c *OAuthClient) CreateWebinar(user *User, webinarRequest *CreateWebinarRequest) (*Webinar, error) {
+	client := c.config.Client(context.Background(), c.token)
+	b, err := json.Marshal(webinarRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	urlStr := fmt.Sprintf("%s/users/%s/webinars", c.apiURL, url.PathEscape(user.Email))
+	res, err := client.Post(urlStr, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return nil, errors.New(res.Status)
+	}
+
+	var ret Webinar
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
 func (c *OAuthClient) getUserViaOAuth(user *model.User, firstConnect bool) (*User, error) {
 	urlStr := fmt.Sprintf("%s/users/me", c.apiURL)
 	if c.isAccountLevel {
 		urlStr = fmt.Sprintf("%s/users/%s", c.apiURL, url.PathEscape(user.Email))
 	}
 
+	// The plugin refreshes the stored token when it is about to expire, once across the cluster.
 	if !firstConnect {
+		var token *oauth2.Token
+		var err error
 		if c.isAccountLevel {
-			currentToken, err := c.api.GetZoomSuperUserToken()
+			token, err = c.api.RefreshZoomSuperUserToken()
 			if err != nil {
 				return nil, errors.Wrap(err, "error getting Zoom super user token")
 			}
-
-			tokenSource := c.config.TokenSource(context.Background(), currentToken)
-			updatedToken, err := tokenSource.Token()
-			if err != nil {
-				return nil, errors.Wrap(err, "error getting token from token source")
-			}
-
-			if updatedToken.RefreshToken != currentToken.RefreshToken {
-				kvErr := c.api.SetZoomSuperUserToken(updatedToken)
-				if kvErr != nil {
-					return nil, errors.Wrap(kvErr, "error setting new token")
-				}
-			}
-
-			c.token = updatedToken
 		} else {
-			info, err := c.api.GetZoomOAuthUserInfo(user.Id)
+			token, err = c.api.RefreshZoomOAuthUserToken(user.Id)
 			if err != nil {
 				return nil, errors.Wrap(err, "error getting Zoom user token")
 			}
-
-			currentToken := info.OAuthToken
-
-			tokenSource := c.config.TokenSource(context.Background(), currentToken)
-			updatedToken, err := tokenSource.Token()
-			if err != nil {
-				return nil, errors.Wrap(err, "error getting token from token source")
-			}
-
-			if updatedToken.RefreshToken != currentToken.RefreshToken {
-				info.OAuthToken = updatedToken
-				kvErr := c.api.UpdateZoomOAuthUserInfo(user.Id, info)
-				if kvErr != nil {
-					return nil, errors.Wrap(kvErr, "error setting new token")
-				}
-			}
-
-			c.token = updatedToken
 		}
+
+		c.token = token
 	}
 
 	client := c.config.Client(context.Background(), c.token)
@@ -0,0 +1,51 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package zoom
+
+const (
+	// MeetingApprovalAutomatic approves meeting registrants automatically
+	MeetingApprovalAutomatic = 0
+	// MeetingApprovalManual leaves meeting registrants to be approved by the host
+	MeetingApprovalManual = 1
+	// MeetingApprovalNoRegistration lets attendees join without registering
+	MeetingApprovalNoRegistration = 2
+
+	// MeetingRegistrationOnce registers attendees once for every occurrence of a recurring meeting
+	MeetingRegistrationOnce = 1
+
+	// RegistrantStatusPending is the status of registrants waiting for the host's approval
+	RegistrantStatusPending = "pending"
+
+	// RegistrantActionApprove and RegistrantActionDeny are the actions of RegistrantStatusRequest
+	RegistrantActionApprove = "approve"
+	RegistrantActionDeny    = "deny"
+)
+
+// MeetingRegistrantRequest as defined at https://developers.zoom.us/docs/api/meetings/#tag/meetings/POST/meetings/{meetingId}/registrants
+type MeetingRegistrantRequest struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name,omitempty"`
+}
+
+// MeetingRegistration is the response of Zoom to a new meeting registrant.
+type MeetingRegistration struct {
+	ID           int    `json:"id"`
+	RegistrantID string `json:"registrant_id"`
+	Topic        string `json:"topic"`
+	StartTime    string `json:"start_time"`
+	JoinURL      string `json:"join_url"`
+}
+
+// RegistrantStatusRequest as defined at https://developers.zoom.us/docs/api/meetings/#tag/meetings/PUT/meetings/{meetingId}/registrants/status
+type RegistrantStatusRequest struct {
+	Action      string                 `json:"action"`
+	Registrants []RegistrantStatusItem `json:"registrants"`
+}
+
+// RegistrantStatusItem identifies a registrant whose status is updated.
+type RegistrantStatusItem struct {
+	ID    string `json:"id"`
+	Email string `json:"email,omitempty"`
+}
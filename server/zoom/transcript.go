@@ -0,0 +1,189 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package zoom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const webVTTTimingSeparator = "-->"
+
+// TranscriptCue is a cue of a WebVTT transcript. Speaker is empty when the cue has no speaker label.
+type TranscriptCue struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	Text    string
+}
+
+// TranscriptSegment is the consecutive cues of a single speaker.
+type TranscriptSegment struct {
+	Speaker string
+	Start   time.Duration
+	End     time.Duration
+	Text    string
+}
+
+// ParseWebVTT parses the cues of a WebVTT file, such as the audio transcript of a cloud recording.
+// Zoom labels the speaker by starting the cue text with "Name: ", and voice spans of the form
+// "<v Name>text</v>" are understood as well.
+func ParseWebVTT(data []byte) ([]TranscriptCue, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "WEBVTT") {
+		return nil, errors.New("not a WebVTT file")
+	}
+
+	var (
+		cues  []TranscriptCue
+		cue   *TranscriptCue
+		lines []string
+	)
+	flush := func() {
+		if cue != nil && len(lines) > 0 {
+			cue.Speaker, cue.Text = splitCueSpeaker(strings.Join(lines, " "))
+			if cue.Text != "" {
+				cues = append(cues, *cue)
+			}
+		}
+		cue, lines = nil, nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			flush()
+		case cue == nil && strings.Contains(line, webVTTTimingSeparator):
+			start, end, err := parseCueTiming(line)
+			if err != nil {
+				return nil, err
+			}
+			cue = &TranscriptCue{Start: start, End: end}
+		case cue != nil:
+			lines = append(lines, line)
+		}
+		// Cue identifiers, NOTE, STYLE and REGION blocks are skipped.
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return cues, nil
+}
+
+// GroupTranscript merges the consecutive cues of the same speaker into segments.
+func GroupTranscript(cues []TranscriptCue) []TranscriptSegment {
+	var segments []TranscriptSegment
+	for _, cue := range cues {
+		if n := len(segments); n > 0 && segments[n-1].Speaker == cue.Speaker {
+			segments[n-1].End = cue.End
+			segments[n-1].Text += " " + cue.Text
+			continue
+		}
+		segments = append(segments, TranscriptSegment{
+			Speaker: cue.Speaker,
+			Start:   cue.Start,
+			End:     cue.End,
+			Text:    cue.Text,
+		})
+	}
+	return segments
+}
+
+// Markdown renders the segment as a paragraph led by the speaker and the time it started
+// speaking. The speaker is written as given, so it can be a mention.
+func (s TranscriptSegment) Markdown(speaker string) string {
+	if speaker == "" {
+		speaker = "Unknown speaker"
+	}
+	return fmt.Sprintf("**%s** `%s`\n%s", speaker, FormatTranscriptTime(s.Start), s.Text)
+}
+
+// FormatTranscriptTime formats an offset into the recording as h:mm:ss, or m:ss under an hour.
+func FormatTranscriptTime(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	hours, minutes, seconds := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
+
+func parseCueTiming(line string) (time.Duration, time.Duration, error) {
+	startValue, rest, _ := strings.Cut(line, webVTTTimingSeparator)
+	// Cue settings may follow the end time.
+	endFields := strings.Fields(rest)
+	if len(endFields) == 0 {
+		return 0, 0, errors.Errorf("invalid cue timing %q", line)
+	}
+
+	start, err := parseWebVTTTimestamp(strings.TrimSpace(startValue))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseWebVTTTimestamp(endFields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseWebVTTTimestamp parses a timestamp of the form hh:mm:ss.ttt, where the hours are optional.
+func parseWebVTTTimestamp(value string) (time.Duration, error) {
+	clock, millis, _ := strings.Cut(value, ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errors.Errorf("invalid timestamp %q", value)
+	}
+
+	var d time.Duration
+	units := []time.Duration{time.Second, time.Minute, time.Hour}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, errors.Errorf("invalid timestamp %q", value)
+		}
+		d += time.Duration(n) * units[len(parts)-1-i]
+	}
+	if millis != "" {
+		n, err := strconv.Atoi(millis)
+		if err != nil || n < 0 {
+			return 0, errors.Errorf("invalid timestamp %q", value)
+		}
+		d += time.Duration(n) * time.Millisecond
+	}
+	return d, nil
+}
+
+// splitCueSpeaker separates the speaker label from the text of a cue.
+func splitCueSpeaker(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "<v") {
+		if end := strings.Index(text, ">"); end > 2 {
+			tag := text[2:end]
+			// Classes, as in <v.loud Name>, come before the name.
+			if strings.HasPrefix(tag, ".") {
+				_, tag, _ = strings.Cut(tag, " ")
+			}
+			return strings.TrimSpace(tag), strings.TrimSpace(strings.ReplaceAll(text[end+1:], "</v>", ""))
+		}
+	}
+
+	// A colon far into the text is part of the speech rather than a label.
+	if speaker, rest, found := strings.Cut(text, ": "); found && speaker != "" && len([]rune(speaker)) <= 64 {
+		return strings.TrimSpace(speaker), strings.TrimSpace(rest)
+	}
+	return "", text
+}
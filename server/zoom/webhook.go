@@ -14,17 +14,26 @@ const (
 	WebhookStatusEnded           = "ENDED"
 	RecordingWebhookTypeComplete = "RECORDING_MEETING_COMPLETED"
 	RecentlyCreated              = "RECENTLY_CREATED"
+	MeetingStatusScheduled       = "SCHEDULED"
 
 	EventTypeMeetingStarted      EventType = "meeting.started"
 	EventTypeMeetingEnded        EventType = "meeting.ended"
 	EventTypeTranscriptCompleted EventType = "recording.transcript_completed"
 	EventTypeRecordingCompleted  EventType = "recording.completed"
 	EventTypeValidateWebhook     EventType = "endpoint.url_validation"
+	EventTypeParticipantJoined   EventType = "meeting.participant_joined"
+	EventTypeParticipantLeft     EventType = "meeting.participant_left"
+
+	EventTypeWebinarStarted             EventType = "webinar.started"
+	EventTypeWebinarEnded               EventType = "webinar.ended"
+	EventTypeWebinarRegistrationCreated EventType = "webinar.registration_created"
+	EventTypeMeetingRegistrationCreated EventType = "meeting.registration_created"
 
 	RecordingTypeAudioTranscript = "audio_transcript"
 	RecordingTypeChat            = "chat_file"
 
 	RecordingFileTypeMP4 = "MP4"
+	RecordingFileTypeM4A = "M4A"
 )
 
 type MeetingWebhookObject struct {
@@ -44,11 +53,78 @@ type MeetingWebhookPayload struct {
 	Object    MeetingWebhookObject `json:"object"`
 }
 
+// MeetingWebhook is the body of the meeting.started and meeting.ended events. The
+// webinar.started and webinar.ended events carry the webinar in the same shape.
 type MeetingWebhook struct {
 	Event   EventType             `json:"event"`
 	Payload MeetingWebhookPayload `json:"payload"`
 }
 
+// WebhookRegistrant is the registrant carried by meeting.registration_created and
+// webinar.registration_created.
+type WebhookRegistrant struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Status    string `json:"status"`
+	JoinURL   string `json:"join_url"`
+}
+
+type RegistrationWebhookObject struct {
+	ID         string            `json:"id"`
+	UUID       string            `json:"uuid"`
+	HostID     string            `json:"host_id"`
+	Topic      string            `json:"topic"`
+	Type       int               `json:"type"`
+	Registrant WebhookRegistrant `json:"registrant"`
+}
+
+type RegistrationWebhookPayload struct {
+	AccountID string                    `json:"account_id"`
+	Object    RegistrationWebhookObject `json:"object"`
+}
+
+type RegistrationWebhook struct {
+	Event     EventType                  `json:"event"`
+	EventTime int                        `json:"event_ts"`
+	Payload   RegistrationWebhookPayload `json:"payload"`
+}
+
+// WebhookParticipant is the participant carried by meeting.participant_joined and
+// meeting.participant_left. ID is the participant's Zoom user ID and is empty for guests.
+type WebhookParticipant struct {
+	UserID            string    `json:"user_id"`
+	UserName          string    `json:"user_name"`
+	ID                string    `json:"id"`
+	ParticipantUUID   string    `json:"participant_uuid"`
+	ParticipantUserID string    `json:"participant_user_id"`
+	Email             string    `json:"email"`
+	JoinTime          time.Time `json:"join_time"`
+	LeaveTime         time.Time `json:"leave_time"`
+	LeaveReason       string    `json:"leave_reason"`
+}
+
+type ParticipantWebhookObject struct {
+	ID          string             `json:"id"`
+	UUID        string             `json:"uuid"`
+	HostID      string             `json:"host_id"`
+	Topic       string             `json:"topic"`
+	Type        int                `json:"type"`
+	Participant WebhookParticipant `json:"participant"`
+}
+
+type ParticipantWebhookPayload struct {
+	AccountID string                   `json:"account_id"`
+	Object    ParticipantWebhookObject `json:"object"`
+}
+
+type ParticipantWebhook struct {
+	Event     EventType                 `json:"event"`
+	EventTime int                       `json:"event_ts"`
+	Payload   ParticipantWebhookPayload `json:"payload"`
+}
+
 type ValidationWebhookPayload struct {
 	PlainToken string `json:"plainToken"`
 }
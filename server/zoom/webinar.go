@@ -0,0 +1,109 @@
+// Copyright (c) 2017-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package zoom
+
+import "time"
+
+// WebinarType as defined at https://developers.zoom.us/docs/api/meetings/#tag/webinars/POST/users/{userId}/webinars
+type WebinarType int
+
+const (
+	// WebinarTypeScheduled webinar
+	WebinarTypeScheduled WebinarType = 5
+	// WebinarTypeRecurringWithNoFixedTime webinar
+	WebinarTypeRecurringWithNoFixedTime WebinarType = 6
+	// WebinarTypeRecurringWithFixedTime webinar
+	WebinarTypeRecurringWithFixedTime WebinarType = 9
+)
+
+// WebinarApprovalType chooses whether attendees register for a webinar, and how they are approved.
+type WebinarApprovalType int
+
+const (
+	// WebinarApprovalAutomatic approves registrants automatically
+	WebinarApprovalAutomatic WebinarApprovalType = 0
+	// WebinarApprovalManual leaves registrants to be approved by the host
+	WebinarApprovalManual WebinarApprovalType = 1
+	// WebinarApprovalNoRegistration lets attendees join without registering
+	WebinarApprovalNoRegistration WebinarApprovalType = 2
+)
+
+// WebinarSettings are the settings of a webinar used by the plugin.
+type WebinarSettings struct {
+	HostVideo                    bool                `json:"host_video"`
+	PanelistsVideo               bool                `json:"panelists_video"`
+	ApprovalType                 WebinarApprovalType `json:"approval_type"`
+	RegistrationType             int                 `json:"registration_type,omitempty"`
+	AutoRecording                string              `json:"auto_recording,omitempty"`
+	AlternativeHosts             string              `json:"alternative_hosts,omitempty"`
+	RegistrantsConfirmationEmail bool                `json:"registrants_confirmation_email"`
+	RegistrantsEmailNotification bool                `json:"registrants_email_notification"`
+	MeetingAuthentication        bool                `json:"meeting_authentication"`
+}
+
+// Webinar is defined at https://developers.zoom.us/docs/api/meetings/#tag/webinars/GET/webinars/{webinarId}
+type Webinar struct {
+	UUID            string          `json:"uuid"`
+	ID              int             `json:"id"`
+	HostID          string          `json:"host_id"`
+	HostEmail       string          `json:"host_email"`
+	Topic           string          `json:"topic"`
+	Type            WebinarType     `json:"type"`
+	StartTime       string          `json:"start_time"`
+	Duration        int             `json:"duration"`
+	Timezone        string          `json:"timezone"`
+	Agenda          string          `json:"agenda"`
+	CreatedAt       string          `json:"created_at"`
+	JoinURL         string          `json:"join_url"`
+	StartURL        string          `json:"start_url"`
+	RegistrationURL string          `json:"registration_url"`
+	Password        string          `json:"password"`
+	Settings        WebinarSettings `json:"settings"`
+}
+
+// CreateWebinarRequest as defined at https://developers.zoom.us/docs/api/meetings/#tag/webinars/POST/users/{userId}/webinars
+type CreateWebinarRequest struct {
+	Topic     string          `json:"topic"`
+	Type      WebinarType     `json:"type"`
+	StartTime string          `json:"start_time,omitempty"`
+	Duration  int             `json:"duration,omitempty"`
+	Timezone  string          `json:"timezone,omitempty"`
+	Password  string          `json:"password,omitempty"`
+	Agenda    string          `json:"agenda,omitempty"`
+	Settings  WebinarSettings `json:"settings"`
+}
+
+// WebinarRegistrant is a person registered to attend a webinar.
+type WebinarRegistrant struct {
+	ID         string    `json:"id"`
+	Email      string    `json:"email"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Status     string    `json:"status"`
+	CreateTime time.Time `json:"create_time"`
+	JoinURL    string    `json:"join_url"`
+}
+
+// WebinarPanelist is a speaker of a webinar, who joins it with their own link.
+type WebinarPanelist struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	JoinURL string `json:"join_url"`
+}
+
+// WebinarPanelists is defined at https://developers.zoom.us/docs/api/meetings/#tag/webinars/GET/webinars/{webinarId}/panelists
+type WebinarPanelists struct {
+	TotalRecords int               `json:"total_records"`
+	Panelists    []WebinarPanelist `json:"panelists"`
+}
+
+// WebinarRegistrants is defined at https://developers.zoom.us/docs/api/meetings/#tag/webinars/GET/webinars/{webinarId}/registrants
+type WebinarRegistrants struct {
+	PageCount     int                 `json:"page_count"`
+	PageSize      int                 `json:"page_size"`
+	TotalRecords  int                 `json:"total_records"`
+	NextPageToken string              `json:"next_page_token"`
+	Registrants   []WebinarRegistrant `json:"registrants"`
+}
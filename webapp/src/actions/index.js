@@ -5,12 +5,12 @@ import {PostTypes} from 'mattermost-redux/action_types';
 
 import Client from '../client';
 
-export function startMeeting(channelId, rootId = '', force = false, topic = '') {
+export function startMeeting(channelId, rootId = '', force = false, topic = '', template = '', scheduleFor = '') {
     return async (dispatch, getState) => {
         const userId = getState().entities.bots.accounts.user_id;
         const connectionId = getState().websocket?.connectionId || '';
         try {
-            const {error} = await Client.startMeeting(channelId, rootId, topic, force, connectionId);
+            const {error} = await Client.startMeeting(channelId, rootId, topic, force, connectionId, template, scheduleFor);
             if (error) {
                 dispatchError(dispatch, channelId, rootId, userId, error);
                 return error;
@@ -11,17 +11,32 @@ export default class Client {
         this.url = url + '/plugins/' + manifest.id;
     }
 
-    startMeeting = async (channelId, rootId, topic = '', force = false, connectionId = '') => {
+    startMeeting = async (channelId, rootId, topic = '', force = false, connectionId = '', template = '', scheduleFor = '') => {
         const res = await doPost(`${this.url}/api/v1/meetings${force ? '?force=true' : ''}`, {
             channel_id: channelId,
             topic,
             root_id: rootId,
             connection_id: connectionId,
+            template,
+            schedule_for: scheduleFor,
         });
 
         return {meetingUrl: res.meeting_url, error: res.error};
     };
 
+    registerForMeeting = async (postId) => {
+        const res = await doPost(`${this.url}/api/v1/meeting-registration`, {post_id: postId});
+        return {status: res.status};
+    };
+
+    getWebinarHostLinks = async (postId) => {
+        const res = await doGet(`${this.url}/api/v1/webinar-host-links?post_id=${encodeURIComponent(postId)}`);
+        return {
+            startUrl: res.start_url,
+            panelists: (res.panelists || []).map((panelist) => ({name: panelist.name, joinUrl: panelist.join_url})),
+        };
+    };
+
     getChannelIdForThread = async (baseURL, threadId) => {
         const threadDetails = await doGet(`${baseURL}/api/v4/posts/${threadId}/thread`);
         return threadDetails.posts[threadId].channel_id;
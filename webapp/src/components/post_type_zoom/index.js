@@ -5,7 +5,8 @@ import {connect} from 'react-redux';
 import {bindActionCreators} from 'redux';
 
 import {getBool} from 'mattermost-redux/selectors/entities/preferences';
-import {getCurrentChannelId} from 'mattermost-redux/selectors/entities/common';
+import {getCurrentChannelId, getCurrentUserId} from 'mattermost-redux/selectors/entities/common';
+import {getCurrentTimezone} from 'mattermost-redux/selectors/entities/timezone';
 
 import {startMeeting} from '../../actions';
 
@@ -17,7 +18,9 @@ function mapStateToProps(state, ownProps) {
         fromBot: ownProps.post.props.from_bot,
         creatorName: ownProps.post.props.meeting_creator_username || 'Someone',
         useMilitaryTime: getBool(state, 'display_settings', 'use_military_time', false),
+        timezone: getCurrentTimezone(state),
         currentChannelId: getCurrentChannelId(state),
+        currentUserId: getCurrentUserId(state),
     };
 }
 
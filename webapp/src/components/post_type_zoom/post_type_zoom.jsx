@@ -6,6 +6,7 @@ import PropTypes from 'prop-types';
 
 import {makeStyleFromTheme} from 'mattermost-redux/utils/theme_utils';
 
+import Client from '../../client';
 import {Svgs} from '../../constants';
 import {formatDate} from '../../utils/date_utils';
 
@@ -32,6 +33,11 @@ export default class PostTypeZoom extends React.PureComponent {
          */
         useMilitaryTime: PropTypes.bool,
 
+        /**
+         * The viewer's timezone, used to display the meeting times.
+         */
+        timezone: PropTypes.string,
+
         /*
          * Logged in user's theme.
          */
@@ -47,6 +53,11 @@ export default class PostTypeZoom extends React.PureComponent {
          */
         currentChannelId: PropTypes.string.isRequired,
 
+        /*
+         * Logged in user's ID, used to show webinar hosts their links.
+         */
+        currentUserId: PropTypes.string,
+
         /*
          * Whether the post was sent from a bot. Used for backwards compatibility.
          */
@@ -67,9 +78,179 @@ export default class PostTypeZoom extends React.PureComponent {
         super(props);
 
         this.state = {
+            hostLinks: null,
+            hostLinksError: '',
+            registrationMessage: '',
         };
     }
 
+    register = async () => {
+        try {
+            const {status} = await Client.registerForMeeting(this.props.post.id);
+            let registrationMessage = 'You are registered. Your link to join was sent to you in a direct message.';
+            if (status === 'pending') {
+                registrationMessage = 'You are registered. The host will review your registration.';
+            }
+            this.setState({registrationMessage});
+        } catch (e) {
+            this.setState({registrationMessage: 'Unable to register for the meeting.'});
+        }
+    };
+
+    renderRegistration(style) {
+        if (this.state.registrationMessage) {
+            return (
+                <div style={style.summaryItem}>{this.state.registrationMessage}</div>
+            );
+        }
+
+        return (
+            <button
+                className='btn btn-primary'
+                style={style.button}
+                onClick={this.register}
+            >
+                {'REGISTER'}
+            </button>
+        );
+    }
+
+    renderInvitations(style) {
+        const invitees = (this.props.post.props || {}).meeting_invitees || [];
+        const names = (status) => invitees.filter((invitee) => invitee.status === status).map((invitee) => '@' + invitee.username).join(', ');
+
+        const declined = names('declined');
+        const waiting = names('pending');
+        const missed = names('missed');
+        if (!declined && !waiting && !missed) {
+            return null;
+        }
+
+        return (
+            <div>
+                {declined && (
+                    <div>
+                        <span style={style.summaryItem}>{'Declined: '}</span>
+                        {this.renderPostWithMarkdown(declined)}
+                    </div>
+                )}
+                {waiting && (
+                    <div>
+                        <span style={style.summaryItem}>{'Waiting for: '}</span>
+                        {this.renderPostWithMarkdown(waiting)}
+                    </div>
+                )}
+                {missed && (
+                    <div>
+                        <span style={style.summaryItem}>{'Missed: '}</span>
+                        {this.renderPostWithMarkdown(missed)}
+                    </div>
+                )}
+            </div>
+        );
+    }
+
+    renderHosts(style) {
+        const props = this.props.post.props || {};
+        const alternativeHosts = props.meeting_alternative_hosts || [];
+        if (alternativeHosts.length === 0 && !props.meeting_scheduled_by_username) {
+            return null;
+        }
+
+        const hosts = [];
+        if (props.meeting_creator_username) {
+            hosts.push('@' + props.meeting_creator_username);
+        }
+        hosts.push(...alternativeHosts);
+
+        let text = hosts.join(', ');
+        if (props.meeting_scheduled_by_username) {
+            text += ' (scheduled by @' + props.meeting_scheduled_by_username + ')';
+        }
+
+        return (
+            <div>
+                <span style={style.summaryItem}>{'Hosts: '}</span>
+                {this.renderPostWithMarkdown(text)}
+            </div>
+        );
+    }
+
+    showHostLinks = async () => {
+        try {
+            const hostLinks = await Client.getWebinarHostLinks(this.props.post.id);
+            this.setState({hostLinks, hostLinksError: ''});
+        } catch (e) {
+            this.setState({hostLinksError: 'Unable to get the host links from Zoom.'});
+        }
+    };
+
+    renderWebinarDetails(style) {
+        const props = this.props.post.props || {};
+
+        let registrants;
+        if (props.webinar_registration) {
+            registrants = (
+                <div style={style.summaryItem}>{'Registrants: ' + (props.webinar_registrant_count || 0)}</div>
+            );
+        }
+
+        // The start and panelist links are only fetched for the host, and never stored in the post.
+        let hostLinks;
+        if (props.webinar_host_user_id && props.webinar_host_user_id === this.props.currentUserId && props.meeting_status !== 'ENDED') {
+            if (this.state.hostLinks) {
+                hostLinks = (
+                    <div style={style.summaryItem}>
+                        <a
+                            rel='noopener noreferrer'
+                            target='_blank'
+                            href={this.state.hostLinks.startUrl}
+                        >
+                            {'Start the webinar as host'}
+                        </a>
+                        {this.state.hostLinks.panelists.map((panelist) => (
+                            <React.Fragment key={panelist.joinUrl}>
+                                <br/>
+                                <a
+                                    rel='noopener noreferrer'
+                                    target='_blank'
+                                    href={panelist.joinUrl}
+                                >
+                                    {'Panelist join link for ' + panelist.name}
+                                </a>
+                            </React.Fragment>
+                        ))}
+                        {this.state.hostLinks.panelists.length === 0 && (
+                            <div>{'No panelists are invited to this webinar.'}</div>
+                        )}
+                    </div>
+                );
+            } else {
+                hostLinks = (
+                    <div>
+                        <button
+                            className='btn btn-tertiary'
+                            style={style.button}
+                            onClick={this.showHostLinks}
+                        >
+                            {'SHOW HOST LINKS'}
+                        </button>
+                        {this.state.hostLinksError && (
+                            <div style={style.summaryItem}>{this.state.hostLinksError}</div>
+                        )}
+                    </div>
+                );
+            }
+        }
+
+        return (
+            <div>
+                {registrants}
+                {hostLinks}
+            </div>
+        );
+    }
+
     renderPostWithMarkdown(post) {
         const {formatText, messageHtmlToComponent} = window.PostUtils;
         const markdownOptions = {
@@ -87,6 +268,14 @@ export default class PostTypeZoom extends React.PureComponent {
         const post = this.props.post;
         const props = post.props || {};
 
+        const webinar = Boolean(props.meeting_webinar);
+        const registration = !webinar && Boolean(props.meeting_registration);
+        const idLabel = webinar ? 'Webinar ID : ' : 'Meeting ID : ';
+        let joinLabel = 'JOIN MEETING';
+        if (webinar) {
+            joinLabel = props.webinar_registration ? 'REGISTER' : 'JOIN WEBINAR';
+        }
+
         let preText;
         let content;
         let subtitle;
@@ -107,10 +296,57 @@ export default class PostTypeZoom extends React.PureComponent {
                         style={style.buttonIcon}
                         dangerouslySetInnerHTML={{__html: Svgs.VIDEO_CAMERA_3}}
                     />
-                    {'JOIN MEETING'}
+                    {joinLabel}
                 </a>
             );
 
+            const participants = props.meeting_participants || [];
+            if (participants.length > 0) {
+                const count = props.meeting_participant_count ?? participants.length;
+                const names = participants.map((participant) => (participant.username ? '@' + participant.username : participant.name)).join(', ');
+                content = (
+                    <div>
+                        <span style={style.summaryItem}>{'In the meeting (' + count + '): '}</span>
+                        {this.renderPostWithMarkdown(names)}
+                        {content}
+                    </div>
+                );
+            }
+
+            if (webinar) {
+                content = (
+                    <div>
+                        {this.renderWebinarDetails(style)}
+                        {content}
+                    </div>
+                );
+            } else if (props.meeting_alternative_hosts || props.meeting_scheduled_by_username) {
+                content = (
+                    <div>
+                        {this.renderHosts(style)}
+                        {content}
+                    </div>
+                );
+            }
+
+            if (props.meeting_invitees) {
+                content = (
+                    <div>
+                        {content}
+                        {this.renderInvitations(style)}
+                    </div>
+                );
+            }
+
+            if (registration) {
+                content = (
+                    <div>
+                        {content}
+                        {this.renderRegistration(style)}
+                    </div>
+                );
+            }
+
             if (props.meeting_personal) {
                 subtitle = (
                     <span>
@@ -127,7 +363,7 @@ export default class PostTypeZoom extends React.PureComponent {
             } else {
                 subtitle = (
                     <span>
-                        {'Meeting ID : '}
+                        {idLabel}
                         <a
                             rel='noopener noreferrer'
                             target='_blank'
@@ -138,6 +374,41 @@ export default class PostTypeZoom extends React.PureComponent {
                     </span>
                 );
             }
+        } else if (props.meeting_status === 'SCHEDULED') {
+            preText = post.message;
+            if (this.props.fromBot) {
+                preText = `${this.props.creatorName} has scheduled a meeting`;
+            }
+
+            subtitle = idLabel + props.meeting_id;
+
+            const start = formatDate(new Date(props.meeting_start_time), this.props.useMilitaryTime, this.props.timezone);
+            content = (
+                <div>
+                    <span style={style.summaryItem}>{'Starts: ' + start}</span>
+                    <br/>
+                    <span style={style.summaryItem}>{'Duration: ' + props.meeting_duration + ' minute(s)'}</span>
+                    <br/>
+                    {props.meeting_recurrence && (
+                        <div style={style.summaryItem}>{'Repeats: ' + props.meeting_recurrence}</div>
+                    )}
+                    {webinar ? this.renderWebinarDetails(style) : this.renderHosts(style)}
+                    <a
+                        className='btn btn-primary'
+                        style={style.button}
+                        rel='noopener noreferrer'
+                        target='_blank'
+                        href={props.meeting_link}
+                    >
+                        <i
+                            style={style.buttonIcon}
+                            dangerouslySetInnerHTML={{__html: Svgs.VIDEO_CAMERA_3}}
+                        />
+                        {joinLabel}
+                    </a>
+                    {registration && this.renderRegistration(style)}
+                </div>
+            );
         } else if (props.meeting_status === 'ENDED') {
             preText = post.message;
             if (this.props.fromBot) {
@@ -147,17 +418,39 @@ export default class PostTypeZoom extends React.PureComponent {
             if (props.meeting_personal) {
                 subtitle = 'Personal Meeting ID (PMI) : ' + props.meeting_id;
             } else {
-                subtitle = 'Meeting ID : ' + props.meeting_id;
+                subtitle = idLabel + props.meeting_id;
             }
 
-            const startDate = new Date(post.create_at);
-            const start = formatDate(startDate);
+            const startDate = new Date(props.meeting_actual_start_time ?? post.create_at);
+            const start = formatDate(startDate, this.props.useMilitaryTime, this.props.timezone);
             const rawEnd = props.meeting_end_time ?? post.update_at;
             const endTime = new Date(rawEnd).getTime();
             const startTime = startDate.getTime();
             const durationMs = Number.isFinite(endTime) && endTime >= startTime ? endTime - startTime : 0;
             const length = Math.ceil(durationMs / 60000);
 
+            let schedule;
+            if (props.meeting_start_time) {
+                const scheduledDate = new Date(props.meeting_start_time);
+                const late = Math.floor((startTime - scheduledDate.getTime()) / 60000);
+                const overrun = props.meeting_duration ? length - props.meeting_duration : 0;
+
+                // Cards reused for a later occurrence carry the schedule of an earlier one.
+                if (Math.abs(late) <= 12 * 60) {
+                    schedule = (
+                        <div>
+                            <span style={style.summaryItem}>{'Scheduled: ' + formatDate(scheduledDate, this.props.useMilitaryTime, this.props.timezone)}</span>
+                            {late > 0 && (
+                                <div style={style.summaryItem}>{'Started ' + late + ' minute(s) late'}</div>
+                            )}
+                            {overrun > 0 && (
+                                <div style={style.summaryItem}>{'Ran ' + overrun + ' minute(s) over the scheduled ' + props.meeting_duration + ' minute(s)'}</div>
+                            )}
+                        </div>
+                    );
+                }
+            }
+
             content = (
                 <div>
                     <h2 style={style.summary}>
@@ -166,6 +459,8 @@ export default class PostTypeZoom extends React.PureComponent {
                     <span style={style.summaryItem}>{'Date: ' + start}</span>
                     <br/>
                     <span style={style.summaryItem}>{'Meeting Length: ' + length + ' minute(s)'}</span>
+                    {schedule}
+                    {props.meeting_invitees && this.renderInvitations(style)}
                 </div>
             );
         } else if (props.meeting_status === 'RECENTLY_CREATED') {
@@ -181,7 +476,7 @@ export default class PostTypeZoom extends React.PureComponent {
                         className='btn btn-lg btn-primary'
                         style={style.button}
                         rel='noopener noreferrer'
-                        onClick={() => this.props.actions.startMeeting(post.channel_id, post.root_id, true, props.meeting_topic)}
+                        onClick={() => this.props.actions.startMeeting(post.channel_id, post.root_id, true, props.meeting_topic, props.meeting_template, props.meeting_schedule_for)}
                     >
                         {'CREATE NEW MEETING'}
                     </button>
@@ -202,7 +497,7 @@ export default class PostTypeZoom extends React.PureComponent {
             );
         }
 
-        let title = 'Zoom Meeting';
+        let title = webinar ? 'Zoom Webinar' : 'Zoom Meeting';
         if (props.meeting_topic) {
             title = props.meeting_topic;
         }
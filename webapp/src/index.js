@@ -12,7 +12,7 @@ import {PostTypeChat} from './components/post_type_chat';
 import {startMeeting} from './actions';
 import Client from './client';
 import {getPluginURL, getServerRoute} from './selectors';
-import {handleMeetingStarted} from './websocket/index.ts';
+import {handleMeetingInvitation, handleMeetingStarted} from './websocket/index.ts';
 
 class Plugin {
     // eslint-disable-next-line no-unused-vars
@@ -50,6 +50,10 @@ class Plugin {
             `custom_${manifest.id}_meeting_started`,
             handleMeetingStarted,
         );
+        registry.registerWebSocketEventHandler(
+            `custom_${manifest.id}_meeting_invitation`,
+            handleMeetingInvitation,
+        );
 
         registry.registerPostTypeComponent('custom_zoom', PostTypeZoom);
         registry.registerPostTypeComponent('custom_zoom_transcript', PostTypeTranscription);
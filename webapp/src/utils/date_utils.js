@@ -1,7 +1,21 @@
 // Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
 // See LICENSE.txt for license information.
 
-export function formatDate(date, useMilitaryTime = false) {
+// toTimezone returns a date whose local fields show the given date in the given timezone.
+function toTimezone(date, timezone) {
+    if (!timezone) {
+        return date;
+    }
+
+    try {
+        return new Date(date.toLocaleString('en-US', {timeZone: timezone}));
+    } catch (e) {
+        return date;
+    }
+}
+
+export function formatDate(rawDate, useMilitaryTime = false, timezone = '') {
+    const date = toTimezone(rawDate, timezone);
     const monthNames = [
         'Jan', 'Feb', 'Mar',
         'Apr', 'May', 'Jun', 'Jul',
@@ -4,3 +4,26 @@
 export function handleMeetingStarted(msg: { data: { meeting_url: string } }) {
     window.open(msg.data.meeting_url, '_blank');
 }
+
+type MeetingInvitation = {
+    meeting_url: string;
+    topic: string;
+    creator_username: string;
+};
+
+// handleMeetingInvitation rings the members of a DM or GM when a meeting starts in it. The bot
+// also sends them the invitation, so the notification is skipped when the browser doesn't allow it.
+export function handleMeetingInvitation(msg: { data: MeetingInvitation }) {
+    if (typeof Notification === 'undefined' || Notification.permission !== 'granted') {
+        return;
+    }
+
+    const notification = new Notification(`@${msg.data.creator_username} is inviting you to a Zoom meeting`, {
+        body: msg.data.topic,
+        requireInteraction: true,
+    });
+    notification.onclick = () => {
+        window.open(msg.data.meeting_url, '_blank');
+        notification.close();
+    };
+}